@@ -0,0 +1,140 @@
+// Package e2e starts the whole stack in containers, the collector, Jaeger and
+// stubbed upstreams included, and checks the trace a request through service
+// A leaves behind. It needs a Docker daemon and is skipped without one.
+package e2e
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/network"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// auditKey satisfies the services' required AUDIT_HMAC_KEY.
+const auditKey = "e2e-audit-key"
+
+// stack is the running environment: the URLs the test reaches from the host.
+type stack struct {
+	serviceAURL string
+	jaegerURL   string
+}
+
+// startStack builds both services and the stub upstreams from their
+// Dockerfiles and starts them on one network, with the names
+// docker-compose.yml gives them, so the services are configured the same way.
+func startStack(t *testing.T) stack {
+	t.Helper()
+	ctx := context.Background()
+
+	net, err := network.New(ctx)
+	if err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+	t.Cleanup(func() { net.Remove(ctx) })
+
+	jaeger := start(t, net, "jaeger", testcontainers.ContainerRequest{
+		Image:        "jaegertracing/all-in-one:1.54",
+		Env:          map[string]string{"COLLECTOR_OTLP_ENABLED": "true"},
+		ExposedPorts: []string{"16686/tcp"},
+		WaitingFor:   wait.ForHTTP("/").WithPort("16686/tcp"),
+	})
+
+	collectorConfig, err := filepath.Abs(filepath.Join("testdata", "otel-collector-config.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start(t, net, "otel-collector", testcontainers.ContainerRequest{
+		Image: "otel/opentelemetry-collector:0.96.0",
+		Cmd:   []string{"--config", "/etc/otel-collector-config.yml"},
+		Files: []testcontainers.ContainerFile{{
+			HostFilePath:      collectorConfig,
+			ContainerFilePath: "/etc/otel-collector-config.yml",
+			FileMode:          0o644,
+		}},
+		WaitingFor: wait.ForLog("Everything is ready"),
+	})
+
+	start(t, net, "stub-upstreams", testcontainers.ContainerRequest{
+		FromDockerfile: dockerfile("service-b/Dockerfile.stub"),
+		ExposedPorts:   []string{"8282/tcp"},
+		WaitingFor:     wait.ForListeningPort("8282/tcp"),
+	})
+
+	start(t, net, "go-service-b", testcontainers.ContainerRequest{
+		FromDockerfile: dockerfile("service-b/Dockerfile.prod"),
+		Env: map[string]string{
+			"OTEL_SERVICE_NAME":           "go-service-b",
+			"OTEL_EXPORTER_OTLP_ENDPOINT": "otel-collector:4317",
+			"AUDIT_HMAC_KEY":              auditKey,
+			"HTTP_PORT":                   "8181",
+			"ADMIN_PORT":                  "9181",
+			"VIACEP_BASE_URL":             "http://stub-upstreams:8282",
+			"WEATHER_BASE_URL":            "http://stub-upstreams:8282",
+		},
+		ExposedPorts: []string{"9181/tcp"},
+		WaitingFor:   wait.ForHTTP("/readyz").WithPort("9181/tcp"),
+	})
+
+	serviceA := start(t, net, "go-service-a", testcontainers.ContainerRequest{
+		FromDockerfile: dockerfile("service-a/Dockerfile.prod"),
+		Env: map[string]string{
+			"OTEL_SERVICE_NAME":           "go-service-a",
+			"OTEL_EXPORTER_OTLP_ENDPOINT": "otel-collector:4317",
+			"AUDIT_HMAC_KEY":              auditKey,
+			"HTTP_PORT":                   "8080",
+			"ADMIN_PORT":                  "9080",
+			"EXTERNAL_CALL_URL":           "http://go-service-b:8181",
+		},
+		ExposedPorts: []string{"8080/tcp", "9080/tcp"},
+		WaitingFor:   wait.ForHTTP("/readyz").WithPort("9080/tcp"),
+	})
+
+	return stack{
+		serviceAURL: endpoint(t, serviceA, "8080/tcp"),
+		jaegerURL:   endpoint(t, jaeger, "16686/tcp"),
+	}
+}
+
+// dockerfile builds from the repository root, as docker-compose.yml does.
+func dockerfile(path string) testcontainers.FromDockerfile {
+	return testcontainers.FromDockerfile{Context: "..", Dockerfile: path}
+}
+
+// start runs req on net under alias and terminates it when the test ends.
+func start(t *testing.T, net *testcontainers.DockerNetwork, alias string, req testcontainers.ContainerRequest) testcontainers.Container {
+	t.Helper()
+
+	req.Networks = []string{net.Name}
+	req.NetworkAliases = map[string][]string{net.Name: {alias}}
+	if req.WaitingFor != nil {
+		req.WaitingFor = wait.ForAll(req.WaitingFor).WithDeadline(3 * time.Minute)
+	}
+
+	container, err := testcontainers.GenericContainer(context.Background(), testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if container != nil {
+		t.Cleanup(func() { container.Terminate(context.Background()) })
+	}
+	if err != nil {
+		t.Fatalf("failed to start %s: %v", alias, err)
+	}
+
+	return container
+}
+
+func endpoint(t *testing.T, container testcontainers.Container, port nat.Port) string {
+	t.Helper()
+
+	url, err := container.PortEndpoint(context.Background(), port, "http")
+	if err != nil {
+		t.Fatalf("failed to get the %s endpoint: %v", port, err)
+	}
+	return url
+}
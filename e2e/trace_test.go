@@ -0,0 +1,187 @@
+package e2e
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+type jaegerSpan struct {
+	SpanID        string `json:"spanID"`
+	OperationName string `json:"operationName"`
+	References    []struct {
+		RefType string `json:"refType"`
+		SpanID  string `json:"spanID"`
+	} `json:"references"`
+	ProcessID string `json:"processID"`
+}
+
+func (s jaegerSpan) parent() string {
+	for _, ref := range s.References {
+		if ref.RefType == "CHILD_OF" {
+			return ref.SpanID
+		}
+	}
+	return ""
+}
+
+type jaegerTrace struct {
+	Spans     []jaegerSpan `json:"spans"`
+	Processes map[string]struct {
+		ServiceName string `json:"serviceName"`
+	} `json:"processes"`
+}
+
+// expectedSpan is a span the trace must hold, with the span expected as its
+// parent; "" means the caller's.
+type expectedSpan struct {
+	name    string
+	service string
+	parent  string
+}
+
+var zipcodeTrace = []expectedSpan{
+	{name: "POST /city-by-zipcode", service: "go-service-a"},
+	{name: "SearchCityByZipCode", service: "go-service-a", parent: "POST /city-by-zipcode"},
+	{name: "GET /city-weather", service: "go-service-b", parent: "SearchCityByZipCode"},
+	{name: "getViaCep", service: "go-service-b", parent: "GET /city-weather"},
+	{name: "getWeather", service: "go-service-b", parent: "GET /city-weather"},
+}
+
+func TestCrossServiceTrace(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts the whole stack in containers")
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	stack := startStack(t)
+	traceID, callerSpanID := randomHex(16), randomHex(8)
+
+	req, err := http.NewRequest(http.MethodPost, stack.serviceAURL+"/city-by-zipcode", strings.NewReader(`{"cep": "29902555"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-"+traceID+"-"+callerSpanID+"-01")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request to service A failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var weather contracts.TemperatureWithCity
+	if err := json.NewDecoder(resp.Body).Decode(&weather); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if weather.CityName != "Linhares" {
+		t.Errorf("city = %q, want Linhares", weather.CityName)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	trace, err := fetchTrace(ctx, stack.jaegerURL, traceID, zipcodeTrace)
+	if err != nil {
+		t.Fatalf("failed to fetch trace %s from Jaeger: %v", traceID, err)
+	}
+
+	ids := map[string]string{"": callerSpanID}
+	for _, want := range zipcodeTrace {
+		span, ok := findSpan(trace, want.name)
+		if !ok {
+			t.Errorf("span %q missing from trace %s", want.name, traceID)
+			continue
+		}
+		ids[want.name] = span.SpanID
+
+		if got := trace.Processes[span.ProcessID].ServiceName; got != want.service {
+			t.Errorf("span %q reported by %q, want %q", want.name, got, want.service)
+		}
+		if wantParent, ok := ids[want.parent]; ok && span.parent() != wantParent {
+			t.Errorf("span %q has parent %s, want %q (%s)", want.name, span.parent(), want.parent, wantParent)
+		}
+	}
+}
+
+// fetchTrace polls Jaeger until the trace holds every expected span, or until
+// ctx expires. Spans reach Jaeger in batches through the collector, so the
+// first reads are usually incomplete.
+func fetchTrace(ctx context.Context, jaegerURL, traceID string, expected []expectedSpan) (jaegerTrace, error) {
+	for {
+		trace, err := getTrace(ctx, jaegerURL, traceID)
+		if err == nil && hasAll(trace, expected) {
+			return trace, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			// The incomplete trace still says which spans are missing.
+			return trace, nil
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func getTrace(ctx context.Context, jaegerURL, traceID string) (jaegerTrace, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jaegerURL+"/api/traces/"+traceID, nil)
+	if err != nil {
+		return jaegerTrace{}, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return jaegerTrace{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return jaegerTrace{}, fmt.Errorf("jaeger answered %s", resp.Status)
+	}
+
+	var body struct {
+		Data []jaegerTrace `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return jaegerTrace{}, fmt.Errorf("failed to decode trace: %w", err)
+	}
+	if len(body.Data) == 0 {
+		return jaegerTrace{}, fmt.Errorf("trace %s not found", traceID)
+	}
+	return body.Data[0], nil
+}
+
+func hasAll(trace jaegerTrace, expected []expectedSpan) bool {
+	for _, want := range expected {
+		if _, ok := findSpan(trace, want.name); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func findSpan(trace jaegerTrace, name string) (jaegerSpan, bool) {
+	for _, span := range trace.Spans {
+		if span.OperationName == name {
+			return span, true
+		}
+	}
+	return jaegerSpan{}, false
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
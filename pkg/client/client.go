@@ -0,0 +1,260 @@
+// Package client is a typed Go client for service A's city weather API, with
+// tracing, retries and structured errors built in.
+//
+//	c, err := client.New("https://weather.example.com", client.WithAPIKey(key))
+//	weather, err := c.GetCityWeather(ctx, "29902555", client.IncludeConditions)
+//	if errors.Is(err, client.ErrZipcodeNotFound) { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/luis-olivetti/go-observability/pkg/client"
+
+// IncludeConditions asks for the optional conditions section.
+const IncludeConditions = "conditions"
+
+// maxErrorBody bounds how much of an error response is read.
+const maxErrorBody = 64 << 10
+
+// Client calls service A. It is safe for concurrent use.
+type Client struct {
+	baseURL     *neturl.URL
+	http        *http.Client
+	apiKey      string
+	bearer      string
+	userAgent   string
+	tracer      trace.Tracer
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the default client, e.g. to set TLS or a proxy.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.http = httpClient }
+}
+
+// WithAPIKey authenticates with the X-Api-Key header.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithBearerToken authenticates with a JWT in the Authorization header.
+func WithBearerToken(token string) Option {
+	return func(c *Client) { c.bearer = token }
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// WithTracerProvider records client spans with tp instead of the global
+// provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *Client) { c.tracer = tp.Tracer(instrumentationName) }
+}
+
+// WithRetry sets how many attempts a call makes (1 disables retries) and the
+// initial backoff, which doubles on each retry up to 30 times the initial
+// value.
+func WithRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxAttempts = maxAttempts
+		c.backoff = backoff
+		c.maxBackoff = 30 * backoff
+	}
+}
+
+// New returns a client for the service A deployment at baseURL.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := neturl.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base url %q", baseURL)
+	}
+
+	c := &Client{
+		baseURL:     u,
+		http:        &http.Client{Timeout: 10 * time.Second},
+		userAgent:   "go-observability-client",
+		tracer:      otel.GetTracerProvider().Tracer(instrumentationName),
+		maxAttempts: 3,
+		backoff:     200 * time.Millisecond,
+		maxBackoff:  5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.maxAttempts < 1 {
+		c.maxAttempts = 1
+	}
+
+	return c, nil
+}
+
+// GetCityWeather returns the current temperature of the city zipcode belongs
+// to. include lists optional sections, such as IncludeConditions.
+func (c *Client) GetCityWeather(ctx context.Context, zipcode string, include ...string) (*contracts.TemperatureWithCity, error) {
+	ctx, span := c.tracer.Start(ctx, "GetCityWeather", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	body, err := json.Marshal(map[string]string{"cep": zipcode})
+	if err != nil {
+		return nil, err
+	}
+
+	u := *c.baseURL
+	u.Path += "/city-by-zipcode"
+	if len(include) > 0 {
+		u.RawQuery = neturl.Values{"include": include}.Encode()
+	}
+
+	var weather contracts.TemperatureWithCity
+	if err := c.do(ctx, span, "POST", u.String(), body, &weather); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return &weather, nil
+}
+
+// do sends the request, retrying network errors, 429 and 5xx answers other
+// than 501 with exponential backoff and jitter.
+func (c *Client) do(ctx context.Context, span trace.Span, method, url string, body []byte, out any) error {
+	span.SetAttributes(
+		attribute.String("http.request.method", method),
+		attribute.String("server.address", c.baseURL.Host),
+	)
+
+	var lastErr error
+	for attempt := 1; attempt <= c.maxAttempts; attempt++ {
+		if attempt > 1 {
+			wait := c.wait(attempt, lastErr)
+			span.AddEvent("retry", trace.WithAttributes(
+				attribute.Int("attempt", attempt),
+				attribute.String("reason", lastErr.Error()),
+			))
+
+			select {
+			case <-ctx.Done():
+				return errors.Join(ctx.Err(), lastErr)
+			case <-time.After(wait):
+			}
+		}
+
+		status, err := c.attempt(ctx, method, url, body, out)
+		span.SetAttributes(attribute.Int("http.resend_count", attempt-1))
+		if status != 0 {
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+		}
+		if err == nil || !retryable(ctx, status, err) {
+			return err
+		}
+		lastErr = err
+	}
+
+	return lastErr
+}
+
+func (c *Client) attempt(ctx context.Context, method, url string, body []byte, out any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.apiKey != "" {
+		req.Header.Set("X-Api-Key", c.apiKey)
+	}
+	if c.bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearer)
+	}
+
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, decodeError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+func decodeError(resp *http.Response) error {
+	apiErr := &Error{Status: resp.StatusCode}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	var problem contracts.Problem
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), contracts.ProblemContentType) && json.Unmarshal(data, &problem) == nil {
+		apiErr.Code = problem.Code
+		apiErr.Detail = problem.Detail
+		apiErr.Fields = problem.Errors
+		return apiErr
+	}
+
+	apiErr.Detail = strings.TrimSpace(string(data))
+	return apiErr
+}
+
+func retryable(ctx context.Context, status int, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if status == 0 {
+		// Transport error: nothing was answered, so trying again is safe.
+		return true
+	}
+	return status == http.StatusTooManyRequests || (status >= 500 && status != http.StatusNotImplemented)
+}
+
+func (c *Client) wait(attempt int, lastErr error) time.Duration {
+	var apiErr *Error
+	if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > 0 {
+		if apiErr.RetryAfter > c.maxBackoff {
+			return c.maxBackoff
+		}
+		return apiErr.RetryAfter
+	}
+
+	backoff := c.backoff << (attempt - 2)
+	if backoff <= 0 || backoff > c.maxBackoff {
+		backoff = c.maxBackoff
+	}
+	// Full jitter keeps many clients from retrying in lockstep.
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
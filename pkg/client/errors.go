@@ -0,0 +1,56 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+)
+
+// Error is a non-2xx answer from service A.
+type Error struct {
+	Status int
+	// Code is the machine-readable error code, e.g. contracts.CodeZipcodeNotFound.
+	// It is empty when the response was not a problem document.
+	Code   contracts.ErrorCode
+	Detail string
+	Fields []contracts.FieldError
+	// RetryAfter is set when the server asked the caller to back off.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	message := e.Detail
+	if message == "" {
+		message = http.StatusText(e.Status)
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("cityweather: %d %s: %s", e.Status, e.Code, message)
+	}
+	return fmt.Sprintf("cityweather: %d: %s", e.Status, message)
+}
+
+// Is lets errors.Is match the sentinels below: by code when the sentinel has
+// one, by status otherwise.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	if t.Code != "" {
+		return e.Code == t.Code
+	}
+	return e.Status == t.Status
+}
+
+var (
+	ErrInvalidZipcode  = &Error{Status: http.StatusUnprocessableEntity, Code: contracts.CodeZipcodeInvalid}
+	ErrZipcodeNotFound = &Error{Status: http.StatusNotFound, Code: contracts.CodeZipcodeNotFound}
+	ErrUpstream        = &Error{Status: http.StatusBadGateway, Code: contracts.CodeUpstreamError}
+	ErrUpstreamTimeout = &Error{Status: http.StatusGatewayTimeout, Code: contracts.CodeUpstreamTimeout}
+	ErrProviderQuota   = &Error{Status: http.StatusBadGateway, Code: contracts.CodeProviderQuota}
+	ErrUnauthorized    = &Error{Status: http.StatusUnauthorized}
+	ErrForbidden       = &Error{Status: http.StatusForbidden}
+	ErrRateLimited     = &Error{Status: http.StatusTooManyRequests}
+)
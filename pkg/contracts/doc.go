@@ -0,0 +1,5 @@
+// Package contracts holds the types and constants that make up the API
+// between service A and service B: response bodies, error codes, and the
+// headers and baggage members both sides must agree on. It has no
+// dependencies so either service can import it freely.
+package contracts
@@ -0,0 +1,3 @@
+module github.com/luis-olivetti/go-observability/pkg/contracts
+
+go 1.21.3
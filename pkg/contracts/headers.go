@@ -0,0 +1,38 @@
+package contracts
+
+// Headers service A sets when signing its calls to service B. The signature
+// is the hex HMAC-SHA256 over the method, request URI, timestamp, nonce and
+// body hash; service B rejects timestamps outside its replay window and
+// nonces it already saw within it.
+const (
+	SignatureHeader          = "X-Signature"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+	SignatureNonceHeader     = "X-Signature-Nonce"
+	ContentSHA256Header      = "X-Content-Sha256"
+)
+
+// DebugTraceHeader asks service A for a full trace of one request. It is only
+// honoured for allowlisted callers, which service A then marks with the
+// DebugBaggageKey member.
+const DebugTraceHeader = "X-Debug-Trace"
+
+// MirrorHeader is set to "true" on the copies of production requests service
+// A mirrors to staging, which also carry the MirrorBaggageKey member. Their
+// responses are discarded.
+const MirrorHeader = "X-Mirror"
+
+// Debug is added to the responses of service A, success or problem document,
+// when a debug trace was granted and a trace URL template is configured.
+type Debug struct {
+	TraceURL string `json:"trace_url"`
+}
+
+// Baggage members service A propagates to service B. Service B only reads
+// them; service A drops any the caller sent itself.
+const (
+	TenantBaggageKey    = "tenant.id"
+	ClientBaggageKey    = "client.id"
+	SyntheticBaggageKey = "synthetic"
+	DebugBaggageKey     = "debug.trace"
+	MirrorBaggageKey    = "mirror"
+)
@@ -0,0 +1,50 @@
+package contracts
+
+// ProblemContentType is the media type of error responses.
+const ProblemContentType = "application/problem+json"
+
+// FieldError points at the request field that failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Problem is an RFC 7807 problem document.
+type Problem struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Code   ErrorCode    `json:"code"`
+	Detail string       `json:"detail,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+	Debug  *Debug       `json:"debug,omitempty"`
+}
+
+// ErrorCode is the stable, machine-readable code of an error response, set
+// on every problem document and recorded on the server span as error.type.
+// Clients branch on it rather than on the detail, which may change. Service A
+// relays the codes returned by service B to its own callers.
+type ErrorCode string
+
+const (
+	CodeBadRequest       ErrorCode = "BAD_REQUEST"
+	CodeValidationFailed ErrorCode = "VALIDATION_FAILED"
+	CodePayloadTooLarge  ErrorCode = "PAYLOAD_TOO_LARGE"
+	CodeZipcodeInvalid   ErrorCode = "ZIPCODE_INVALID"
+	CodeZipcodeNotFound  ErrorCode = "ZIPCODE_NOT_FOUND"
+	CodeNotFound         ErrorCode = "NOT_FOUND"
+	CodeMethodNotAllowed ErrorCode = "METHOD_NOT_ALLOWED"
+	CodeUnauthorized     ErrorCode = "UNAUTHORIZED"
+	CodeForbidden        ErrorCode = "FORBIDDEN"
+	CodeQuotaExceeded    ErrorCode = "QUOTA_EXCEEDED"
+	CodeClientBlocked    ErrorCode = "CLIENT_BLOCKED"
+	CodeUpstreamError    ErrorCode = "UPSTREAM_ERROR"
+	CodeUpstreamTimeout  ErrorCode = "UPSTREAM_TIMEOUT"
+	// CodeProviderQuota is an upstream provider refusing calls because the
+	// quota of the service's own account is spent.
+	CodeProviderQuota ErrorCode = "PROVIDER_QUOTA"
+	// CodeCallBudgetExceeded is a request that needed more upstream calls
+	// than a single request may trigger.
+	CodeCallBudgetExceeded ErrorCode = "CALL_BUDGET_EXCEEDED"
+	CodeInternal           ErrorCode = "INTERNAL"
+)
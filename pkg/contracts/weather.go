@@ -0,0 +1,46 @@
+package contracts
+
+// Conditions is the optional weather section, returned when the caller asks
+// for include=conditions.
+type Conditions struct {
+	Text      string  `json:"text"`
+	Code      int     `json:"code"`
+	Icon      string  `json:"icon"`
+	Humidity  int     `json:"humidity"`
+	WindKph   float64 `json:"wind_kph"`
+	WindDir   string  `json:"wind_dir"`
+	FeelsLike float64 `json:"feelslike_C"`
+}
+
+// TemperatureWithCity is the body of a successful lookup, served by service B
+// on /city-weather and relayed by service A on /city-by-zipcode.
+type TemperatureWithCity struct {
+	Celsius    float64     `json:"temp_C"`
+	Fahrenheit float64     `json:"temp_F"`
+	Kelvin     float64     `json:"temp_K"`
+	CityName   string      `json:"city"`
+	Conditions *Conditions `json:"conditions,omitempty"`
+	Debug      *Debug      `json:"debug,omitempty"`
+}
+
+// Alert is one severe-weather alert in effect, as issued by the provider's
+// source, such as INMET. Times are kept as the provider formats them.
+type Alert struct {
+	Headline    string `json:"headline"`
+	Event       string `json:"event"`
+	Severity    string `json:"severity"`
+	Urgency     string `json:"urgency"`
+	Areas       string `json:"areas"`
+	Effective   string `json:"effective"`
+	Expires     string `json:"expires"`
+	Description string `json:"description"`
+	Instruction string `json:"instruction,omitempty"`
+}
+
+// CityAlerts is the body of /alerts, served by service B and relayed by
+// service A: the alerts in effect for the city of a zipcode, an empty list
+// when there are none.
+type CityAlerts struct {
+	CityName string  `json:"city"`
+	Alerts   []Alert `json:"alerts"`
+}
@@ -0,0 +1,130 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/callbudget"
+	"github.com/luis-olivetti/go-observability/pkg/platform/errclass"
+	"github.com/luis-olivetti/go-observability/pkg/platform/logsample"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Error pairs the status and code a client is answered with and the internal
+// message and cause, which are only ever recorded on spans and logs; the
+// client reads the catalog message of Code instead.
+type Error struct {
+	Status  int
+	Code    contracts.ErrorCode
+	Message string
+	Cause   error
+}
+
+func New(status int, code contracts.ErrorCode, message string, cause error) *Error {
+	return &Error{Status: status, Code: code, Message: message, Cause: cause}
+}
+
+func (e *Error) Error() string {
+	if e.Cause == nil {
+		return e.Message
+	}
+
+	return fmt.Sprintf("%s: %v", e.Message, e.Cause)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, contracts.CodeBadRequest, message, nil)
+}
+
+// FromClass builds the error of a failure of class, answered with the status
+// errclass.Default maps it to.
+func FromClass(class errclass.Class, code contracts.ErrorCode, message string, cause error) *Error {
+	return New(errclass.Default.Status(class), code, message, cause)
+}
+
+func InvalidZipcode(cause error) *Error {
+	return FromClass(errclass.Validation, contracts.CodeZipcodeInvalid, "invalid zipcode", cause)
+}
+
+func ZipcodeNotFound(cause error) *Error {
+	return FromClass(errclass.NotFound, contracts.CodeZipcodeNotFound, "cannot find zipcode", cause)
+}
+
+// UpstreamFailure hides which upstream failed and how from the client; only
+// whether it timed out shows in the status and code.
+func UpstreamFailure(cause error) *Error {
+	if errors.Is(cause, callbudget.ErrExceeded) {
+		return CallBudgetExceeded(cause)
+	}
+	return Upstream(errclass.Of(cause), cause)
+}
+
+// CallBudgetExceeded reports a call refused because the request had already
+// made as many upstream calls as it may. It is this service's failure, not
+// the upstream's.
+func CallBudgetExceeded(cause error) *Error {
+	return New(http.StatusInternalServerError, contracts.CodeCallBudgetExceeded, "outbound call budget exceeded", cause)
+}
+
+// Upstream reports an upstream call that failed with class.
+func Upstream(class errclass.Class, cause error) *Error {
+	if class == errclass.UpstreamTimeout {
+		return FromClass(class, contracts.CodeUpstreamTimeout, "timed out fetching weather data", cause)
+	}
+	return FromClass(class, contracts.CodeUpstreamError, "failed to fetch weather data", cause)
+}
+
+// UpstreamStatus reports an error status answered by an upstream, as
+// classified by errclass.FromStatus; 429 is the provider quota.
+func UpstreamStatus(status int, cause error) *Error {
+	class := errclass.FromStatus(status)
+	switch {
+	case class == errclass.Validation:
+		return InvalidZipcode(cause)
+	case class == errclass.NotFound:
+		return ZipcodeNotFound(cause)
+	case status == http.StatusTooManyRequests:
+		return ProviderQuota(cause)
+	default:
+		return Upstream(class, cause)
+	}
+}
+
+// ProviderQuota reports an upstream refusing calls because the quota of the
+// service's account with it is spent.
+func ProviderQuota(cause error) *Error {
+	return FromClass(errclass.UpstreamError, contracts.CodeProviderQuota, "upstream provider quota exhausted", cause)
+}
+
+func Internal(cause error) *Error {
+	return New(http.StatusInternalServerError, contracts.CodeInternal, "internal error", cause)
+}
+
+// Write records err in full on the span and in the log, and answers the
+// client with the problem document of its code only. Errors that are not an
+// *Error are reported as internal errors.
+func Write(w http.ResponseWriter, r *http.Request, span trace.Span, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		apiErr = Internal(err)
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, string(apiErr.Code))
+	span.SetAttributes(semconv.ErrorTypeKey.String(string(apiErr.Code)))
+	if apiErr.Status >= http.StatusInternalServerError {
+		logsample.Errorf(trace.ContextWithSpan(context.Background(), span), "%s: %v", apiErr.Code, err)
+	}
+
+	problem.Write(w, r, apiErr.Status, apiErr.Code, nil, nil)
+}
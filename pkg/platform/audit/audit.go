@@ -0,0 +1,165 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Event describes an administrative action.
+type Event struct {
+	Actor   string            `json:"actor"`
+	Action  string            `json:"action"`
+	Target  string            `json:"target,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Entry is an Event as written to the audit trail. Each entry carries the
+// hash of the previous one, so removing or editing an entry breaks the chain.
+type Entry struct {
+	Time time.Time `json:"time"`
+	Event
+	TraceID  string `json:"trace_id,omitempty"`
+	SpanID   string `json:"span_id,omitempty"`
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// Sink receives every entry once it has been chained.
+type Sink interface {
+	Write(ctx context.Context, entry Entry) error
+}
+
+// LogSink writes entries as records of handler, so they reach the collector
+// through the OTLP log pipeline with the trace of the audited action. Records
+// skip the level check: an audit trail must not depend on LOG_LEVEL.
+//
+// The audit.* attributes are for searching. The chain is checked against
+// audit.entry, the entry exactly as hashed: decode it into an Entry and pass
+// the entries, in order, to Verify.
+type LogSink struct {
+	handler slog.Handler
+}
+
+func NewLogSink(handler slog.Handler) *LogSink {
+	return &LogSink{handler: handler}
+}
+
+func (s *LogSink) Write(ctx context.Context, entry Entry) error {
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	details := make([]any, 0, len(entry.Details))
+	for key, value := range entry.Details {
+		details = append(details, slog.String(key, value))
+	}
+
+	record := slog.NewRecord(entry.Time, slog.LevelInfo, "audit "+entry.Action, 0)
+	record.AddAttrs(
+		slog.String("audit.actor", entry.Actor),
+		slog.String("audit.action", entry.Action),
+		slog.String("audit.target", entry.Target),
+		slog.Group("audit.details", details...),
+		slog.String("audit.prev_hash", entry.PrevHash),
+		slog.String("audit.hash", entry.Hash),
+		slog.String("audit.entry", string(encoded)),
+	)
+	return s.handler.Handle(ctx, record)
+}
+
+type Logger struct {
+	// Clock defaults to the wall clock when nil.
+	Clock clock.Clock
+
+	sinks []Sink
+	key   []byte
+
+	mu       sync.Mutex
+	prevHash string
+}
+
+// NewLogger creates an audit logger. Entries are chained with HMAC-SHA256
+// under key, so the chain cannot be recomputed without it.
+func NewLogger(key []byte, sinks ...Sink) *Logger {
+	return &Logger{sinks: sinks, key: key}
+}
+
+func (l *Logger) Record(ctx context.Context, event Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := Entry{
+		Time:     clock.Or(l.Clock).Now().UTC(),
+		Event:    event,
+		PrevHash: l.prevHash,
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		entry.TraceID = spanContext.TraceID().String()
+		entry.SpanID = spanContext.SpanID().String()
+	}
+
+	sum, err := l.hash(entry)
+	if err != nil {
+		return err
+	}
+	entry.Hash = sum
+	l.prevHash = sum
+
+	for _, sink := range l.sinks {
+		if err := sink.Write(ctx, entry); err != nil {
+			return fmt.Errorf("failed to write audit entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Logger) hash(entry Entry) (string, error) {
+	entry.Hash = ""
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	var h hash.Hash
+	if len(l.key) > 0 {
+		h = hmac.New(sha256.New, l.key)
+	} else {
+		h = sha256.New()
+	}
+	h.Write(payload)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verify checks that entries form an unbroken chain, returning the index of
+// the first entry that does not match.
+func Verify(key []byte, entries []Entry) (int, bool) {
+	l := &Logger{key: key}
+	for i, entry := range entries {
+		if entry.PrevHash != l.prevHash {
+			return i, false
+		}
+
+		sum, err := l.hash(entry)
+		if err != nil || sum != entry.Hash {
+			return i, false
+		}
+		l.prevHash = sum
+	}
+
+	return len(entries), true
+}
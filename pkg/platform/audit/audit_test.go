@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+)
+
+var testKey = []byte("audit-key")
+
+type memorySink struct {
+	entries []Entry
+}
+
+func (s *memorySink) Write(_ context.Context, entry Entry) error {
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func recordThree(t *testing.T, sinks ...Sink) {
+	t.Helper()
+
+	logger := NewLogger(testKey, sinks...)
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	logger.Clock = clk
+	for _, action := range []string{"config.reload", "sampling.override.start", "sampling.override.end"} {
+		event := Event{Actor: "ops", Action: action, Details: map[string]string{"ratio": "1"}}
+		if err := logger.Record(context.Background(), event); err != nil {
+			t.Fatalf("Record(%s) error = %v", action, err)
+		}
+		clk.Advance(time.Minute)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       []byte
+		tamper    func(entries []Entry) []Entry
+		wantIndex int
+		wantOK    bool
+	}{
+		{
+			name:      "untouched",
+			key:       testKey,
+			tamper:    func(entries []Entry) []Entry { return entries },
+			wantIndex: 3,
+			wantOK:    true,
+		},
+		{
+			name: "edited entry",
+			key:  testKey,
+			tamper: func(entries []Entry) []Entry {
+				entries[1].Actor = "someone-else"
+				return entries
+			},
+			wantIndex: 1,
+			wantOK:    false,
+		},
+		{
+			name: "edited details",
+			key:  testKey,
+			tamper: func(entries []Entry) []Entry {
+				entries[2].Details = map[string]string{"ratio": "0"}
+				return entries
+			},
+			wantIndex: 2,
+			wantOK:    false,
+		},
+		{
+			name:      "removed entry",
+			key:       testKey,
+			tamper:    func(entries []Entry) []Entry { return append(entries[:1], entries[2:]...) },
+			wantIndex: 1,
+			wantOK:    false,
+		},
+		{
+			name:      "wrong key",
+			key:       []byte("other-key"),
+			tamper:    func(entries []Entry) []Entry { return entries },
+			wantIndex: 0,
+			wantOK:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &memorySink{}
+			recordThree(t, sink)
+
+			index, ok := Verify(tt.key, tt.tamper(sink.entries))
+			if index != tt.wantIndex || ok != tt.wantOK {
+				t.Errorf("Verify() = (%d, %t), want (%d, %t)", index, ok, tt.wantIndex, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLogSinkEntriesVerify(t *testing.T) {
+	var out bytes.Buffer
+	recordThree(t, NewLogSink(slog.NewJSONHandler(&out, nil)))
+
+	var entries []Entry
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var record struct {
+			Entry string `json:"audit.entry"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("failed to decode log record: %v", err)
+		}
+
+		var entry Entry
+		if err := json.Unmarshal([]byte(record.Entry), &entry); err != nil {
+			t.Fatalf("failed to decode audit.entry %q: %v", record.Entry, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("got %d log records, want 3", len(entries))
+	}
+	if index, ok := Verify(testKey, entries); !ok {
+		t.Errorf("chain rebuilt from the logs breaks at entry %d", index)
+	}
+}
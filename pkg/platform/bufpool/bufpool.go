@@ -0,0 +1,71 @@
+package bufpool
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/jsoncodec"
+)
+
+// maxRetained keeps an occasional huge payload from pinning memory in the
+// pool for the lifetime of the process.
+const maxRetained = 64 << 10
+
+// Buffer is a pooled buffer with a JSON encoder bound to it, so neither is
+// reallocated per request.
+type Buffer struct {
+	bytes.Buffer
+	enc jsoncodec.Encoder
+}
+
+var pool = sync.Pool{
+	New: func() any {
+		b := &Buffer{}
+		b.enc = jsoncodec.Default.NewEncoder(&b.Buffer)
+		return b
+	},
+}
+
+// Get returns an empty buffer from the pool.
+func Get() *Buffer {
+	return pool.Get().(*Buffer)
+}
+
+// Put resets b and returns it to the pool. b must not be used afterwards.
+func Put(b *Buffer) {
+	if b.Cap() > maxRetained {
+		return
+	}
+
+	b.Reset()
+	pool.Put(b)
+}
+
+// Encode appends the JSON encoding of v, followed by a newline.
+func (b *Buffer) Encode(v any) error {
+	return b.enc.Encode(v)
+}
+
+// WriteJSON encodes v before touching w, so an encoding failure never leaves
+// a half-written body behind, and sends it with an exact Content-Length.
+func WriteJSON(w http.ResponseWriter, status int, contentType string, v any) error {
+	b := Get()
+	defer Put(b)
+
+	if err := b.Encode(v); err != nil {
+		return err
+	}
+
+	return Write(w, status, contentType, b)
+}
+
+// Write sends the contents of b as the response body.
+func Write(w http.ResponseWriter, status int, contentType string, b *Buffer) error {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(b.Len()))
+	w.WriteHeader(status)
+	_, err := w.Write(b.Bytes())
+	return err
+}
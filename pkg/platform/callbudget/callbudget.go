@@ -0,0 +1,81 @@
+package callbudget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrExceeded is returned by Transport for calls past the budget of the
+// request that triggers them.
+var ErrExceeded = errors.New("outbound call budget of the request exceeded")
+
+const (
+	CallsKey  = attribute.Key("outbound.calls")
+	BudgetKey = attribute.Key("outbound.call_budget")
+)
+
+type budgetKey struct{}
+
+// budget counts the outbound calls of one inbound request, the ones refused
+// included.
+type budget struct {
+	max   int64
+	calls atomic.Int64
+}
+
+// Middleware gives every request a budget of max outbound calls, so retries,
+// fallbacks and hedged calls composed on top of each other cannot multiply
+// into a storm of upstream calls. Zero leaves requests without a budget.
+func Middleware(max int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if max <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), budgetKey{}, &budget{max: int64(max)})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Exempt returns ctx without a budget, for calls that are not made on behalf
+// of the request, such as copies mirrored elsewhere.
+func Exempt(ctx context.Context) context.Context {
+	return context.WithValue(ctx, budgetKey{}, (*budget)(nil))
+}
+
+// Attributes returns the outbound calls made so far and the budget, for the
+// server span; none when the request has no budget.
+func Attributes(ctx context.Context) []attribute.KeyValue {
+	b, _ := ctx.Value(budgetKey{}).(*budget)
+	if b == nil {
+		return nil
+	}
+	return []attribute.KeyValue{CallsKey.Int64(b.calls.Load()), BudgetKey.Int64(b.max)}
+}
+
+// Transport counts each request sent through base against the budget of its
+// context and fails it fast with ErrExceeded once the budget is spent.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	b, _ := req.Context().Value(budgetKey{}).(*budget)
+	if b != nil {
+		if calls := b.calls.Add(1); calls > b.max {
+			return nil, fmt.Errorf("call %d to %s: %w (%d)", calls, req.URL.Host, ErrExceeded, b.max)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,123 @@
+package callbudget
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		max       int
+		exempt    bool
+		calls     int
+		wantSent  int64
+		wantAttrs []attribute.KeyValue
+	}{
+		{name: "within the budget", max: 3, calls: 2, wantSent: 2, wantAttrs: []attribute.KeyValue{CallsKey.Int64(2), BudgetKey.Int64(3)}},
+		{name: "budget spent", max: 3, calls: 3, wantSent: 3, wantAttrs: []attribute.KeyValue{CallsKey.Int64(3), BudgetKey.Int64(3)}},
+		// Refused calls count too, so the span shows how far past the
+		// budget the request tried to go.
+		{name: "past the budget", max: 2, calls: 5, wantSent: 2, wantAttrs: []attribute.KeyValue{CallsKey.Int64(5), BudgetKey.Int64(2)}},
+		{name: "no budget", max: 0, calls: 5, wantSent: 5},
+		{name: "exempt", max: 1, exempt: true, calls: 5, wantSent: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent int64
+			client := &http.Client{Transport: Transport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				sent++
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+			}))}
+
+			var attrs []attribute.KeyValue
+			handler := Middleware(tt.max)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx := r.Context()
+				if tt.exempt {
+					ctx = Exempt(ctx)
+				}
+				for i := 0; i < tt.calls; i++ {
+					req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://upstream/", nil)
+					resp, err := client.Do(req)
+					if int64(i) < tt.wantSent {
+						if err != nil {
+							t.Fatalf("call %d failed: %v", i+1, err)
+						}
+						resp.Body.Close()
+						continue
+					}
+					if !errors.Is(err, ErrExceeded) {
+						t.Fatalf("call %d error = %v, want ErrExceeded", i+1, err)
+					}
+				}
+				attrs = Attributes(ctx)
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if sent != tt.wantSent {
+				t.Errorf("upstream got %d calls, want %d", sent, tt.wantSent)
+			}
+			if len(attrs) != len(tt.wantAttrs) {
+				t.Fatalf("Attributes = %v, want %v", attrs, tt.wantAttrs)
+			}
+			for i := range attrs {
+				if attrs[i] != tt.wantAttrs[i] {
+					t.Errorf("Attributes = %v, want %v", attrs, tt.wantAttrs)
+				}
+			}
+		})
+	}
+}
+
+func TestTransportBudgetPerRequest(t *testing.T) {
+	var sent atomic.Int64
+	client := &http.Client{Transport: Transport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent.Add(1)
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	}))}
+
+	// Concurrent calls of one request share its budget; each request gets
+	// its own.
+	handler := Middleware(4)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, "http://upstream/", nil)
+				if resp, err := client.Do(req); err == nil {
+					resp.Body.Close()
+				}
+			}()
+		}
+		wg.Wait()
+	}))
+	for i := 0; i < 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	if got := sent.Load(); got != 12 {
+		t.Errorf("upstream got %d calls, want 4 for each of the 3 requests", got)
+	}
+}
+
+func TestAttributesWithoutMiddleware(t *testing.T) {
+	if attrs := Attributes(context.Background()); attrs != nil {
+		t.Errorf("Attributes = %v, want none outside a request", attrs)
+	}
+}
@@ -0,0 +1,39 @@
+package cep
+
+// Valid reports whether zipcode is a CEP: eight digits, optionally with a
+// hyphen after the fifth one (01001000 or 01001-000). It runs on every
+// request, so it checks bytes directly instead of matching a regexp.
+func Valid(zipcode string) bool {
+	switch len(zipcode) {
+	case 8:
+		return digits(zipcode)
+	case 9:
+		return zipcode[5] == '-' && digits(zipcode[:5]) && digits(zipcode[6:])
+	default:
+		return false
+	}
+}
+
+// Normalize returns zipcode in the canonical form, eight digits without
+// separators, and whether it is a valid CEP at all.
+func Normalize(zipcode string) (string, bool) {
+	if !Valid(zipcode) {
+		return "", false
+	}
+
+	if len(zipcode) == 9 {
+		return zipcode[:5] + zipcode[6:], true
+	}
+
+	return zipcode, true
+}
+
+func digits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,47 @@
+package cep
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// zipcode draws valid CEPs, with and without the hyphen.
+func zipcode() *rapid.Generator[string] {
+	return rapid.Custom(func(t *rapid.T) string {
+		digits := rapid.StringOfN(rapid.RuneFrom([]rune("0123456789")), 8, 8, -1).Draw(t, "digits")
+		if rapid.Bool().Draw(t, "hyphen") {
+			return digits[:5] + "-" + digits[5:]
+		}
+		return digits
+	})
+}
+
+func TestNormalizeIdempotent(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		zip := rapid.OneOf(zipcode(), rapid.String()).Draw(t, "zipcode")
+
+		once, ok := Normalize(zip)
+		if !ok {
+			return
+		}
+		twice, ok := Normalize(once)
+		if !ok || twice != once {
+			t.Fatalf("Normalize(%q) = %q, normalizing again gave (%q, %v)", zip, once, twice, ok)
+		}
+	})
+}
+
+func TestNormalizeKeepsDigits(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		zip := zipcode().Draw(t, "zipcode")
+
+		got, ok := Normalize(zip)
+		if !ok {
+			t.Fatalf("Normalize(%q) rejected a valid CEP", zip)
+		}
+		if want := zip[:5] + zip[len(zip)-3:]; got != want {
+			t.Fatalf("Normalize(%q) = %q, want %q", zip, got, want)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package cep
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name    string
+		zipcode string
+		want    bool
+	}{
+		{name: "eight digits", zipcode: "01001000", want: true},
+		{name: "with hyphen", zipcode: "01001-000", want: true},
+		{name: "empty", zipcode: "", want: false},
+		{name: "seven digits", zipcode: "0100100", want: false},
+		{name: "nine digits", zipcode: "010010001", want: false},
+		{name: "letter", zipcode: "0100100a", want: false},
+		{name: "hyphen misplaced", zipcode: "0100-1000", want: false},
+		{name: "hyphen without digits after", zipcode: "01001000-", want: false},
+		{name: "two hyphens", zipcode: "01001--00", want: false},
+		{name: "dot separator", zipcode: "01001.000", want: false},
+		{name: "spaces", zipcode: " 01001000", want: false},
+		{name: "full-width digits", zipcode: "０１００１０００", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Valid(tt.zipcode); got != tt.want {
+				t.Errorf("Valid(%q) = %v, want %v", tt.zipcode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		zipcode string
+		want    string
+		wantOK  bool
+	}{
+		{name: "canonical", zipcode: "29902555", want: "29902555", wantOK: true},
+		{name: "hyphen removed", zipcode: "29902-555", want: "29902555", wantOK: true},
+		{name: "invalid", zipcode: "2990-2555", want: "", wantOK: false},
+		{name: "empty", zipcode: "", want: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Normalize(tt.zipcode)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Normalize(%q) = (%q, %v), want (%q, %v)", tt.zipcode, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func FuzzNormalize(f *testing.F) {
+	for _, seed := range []string{"01001000", "01001-000", "0100-1000", "", "01001000-", "０１００１０００"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, zipcode string) {
+		got, ok := Normalize(zipcode)
+		if ok != Valid(zipcode) {
+			t.Fatalf("Normalize(%q) ok = %v, Valid = %v", zipcode, ok, Valid(zipcode))
+		}
+		if !ok {
+			if got != "" {
+				t.Fatalf("Normalize(%q) = %q for an invalid CEP", zipcode, got)
+			}
+			return
+		}
+		if len(got) != 8 || !digits(got) {
+			t.Fatalf("Normalize(%q) = %q, want eight digits", zipcode, got)
+		}
+	})
+}
+
+// cepPattern is the regexp Valid replaced, kept as the benchmark baseline.
+var cepPattern = regexp.MustCompile(`^\d{5}-?\d{3}$`)
+
+func BenchmarkValid(b *testing.B) {
+	inputs := []string{"01001000", "01001-000", "0100100a", "not a zipcode at all"}
+
+	b.Run("bytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Valid(inputs[i%len(inputs)])
+		}
+	})
+
+	b.Run("regexp", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			cepPattern.MatchString(inputs[i%len(inputs)])
+		}
+	})
+}
+
+func TestValidMatchesRegexp(t *testing.T) {
+	for _, zipcode := range []string{"01001000", "01001-000", "0100-1000", "0100100", "01001000-", "abcde-fgh", ""} {
+		if got, want := Valid(zipcode), cepPattern.MatchString(zipcode); got != want {
+			t.Errorf("Valid(%q) = %v, regexp says %v", zipcode, got, want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Distribution shapes the latency added to upstream calls.
+type Distribution string
+
+const (
+	// Fixed always adds Latency.
+	Fixed Distribution = "fixed"
+	// Uniform adds a value in [Latency-Spread, Latency+Spread].
+	Uniform Distribution = "uniform"
+	// Normal adds a value with mean Latency and standard deviation Spread.
+	Normal Distribution = "normal"
+	// Exponential adds a value with mean Latency, producing a long tail.
+	Exponential Distribution = "exponential"
+)
+
+// ErrInjected is returned by the transport for injected connection failures.
+var ErrInjected = errors.New("chaos: injected upstream failure")
+
+// Config describes the faults injected when chaos mode is on. Rates are
+// probabilities between 0 and 1.
+type Config struct {
+	FailureRate float64
+	// FailureStatus is the HTTP status returned for injected failures; zero
+	// injects a connection error instead.
+	FailureStatus int
+
+	Distribution Distribution
+	Latency      time.Duration
+	Spread       time.Duration
+
+	SpanDropRate float64
+}
+
+// Injector applies the faults of a Config. A nil *Injector injects nothing,
+// so callers can wire it unconditionally.
+type Injector struct {
+	cfg     Config
+	counter metric.Int64Counter
+}
+
+func New(cfg Config) (*Injector, error) {
+	for name, rate := range map[string]float64{"failure rate": cfg.FailureRate, "span drop rate": cfg.SpanDropRate} {
+		if rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("chaos %s must be between 0 and 1, got %v", name, rate)
+		}
+	}
+	if cfg.FailureStatus != 0 && (cfg.FailureStatus < 100 || cfg.FailureStatus > 599) {
+		return nil, fmt.Errorf("invalid chaos failure status: %d", cfg.FailureStatus)
+	}
+	if cfg.Latency < 0 || cfg.Spread < 0 {
+		return nil, fmt.Errorf("chaos latency must not be negative")
+	}
+
+	cfg.Distribution = Distribution(strings.ToLower(string(cfg.Distribution)))
+	switch cfg.Distribution {
+	case "":
+		cfg.Distribution = Fixed
+	case Fixed, Uniform, Normal, Exponential:
+	default:
+		return nil, fmt.Errorf("invalid chaos latency distribution: %s", cfg.Distribution)
+	}
+
+	counter, err := otel.Meter("microservice-meter").Int64Counter("chaos.injected_faults",
+		metric.WithDescription("Faults injected by chaos mode, by kind"))
+	if err != nil {
+		log.Printf("failed to create chaos counter: %v", err)
+	}
+
+	return &Injector{cfg: cfg, counter: counter}, nil
+}
+
+// Transport wraps base so upstream calls suffer the configured latency and
+// failures. Each injected fault is added as an event to the caller's span.
+func (i *Injector) Transport(base http.RoundTripper) http.RoundTripper {
+	if i == nil {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{injector: i, base: base}
+}
+
+// SpanProcessor wraps next so that a share of finished spans is never
+// exported, simulating a lossy telemetry pipeline.
+func (i *Injector) SpanProcessor(next sdktrace.SpanProcessor) sdktrace.SpanProcessor {
+	if i == nil || i.cfg.SpanDropRate == 0 {
+		return next
+	}
+	return &dropProcessor{SpanProcessor: next, injector: i}
+}
+
+func (i *Injector) delay() time.Duration {
+	mean := float64(i.cfg.Latency)
+	spread := float64(i.cfg.Spread)
+
+	var d float64
+	switch i.cfg.Distribution {
+	case Uniform:
+		d = mean - spread + rand.Float64()*2*spread
+	case Normal:
+		d = mean + rand.NormFloat64()*spread
+	case Exponential:
+		d = rand.ExpFloat64() * mean
+	default:
+		d = mean
+	}
+
+	return time.Duration(math.Max(d, 0))
+}
+
+func (i *Injector) record(ctx context.Context, fault string, attrs ...attribute.KeyValue) {
+	attrs = append([]attribute.KeyValue{attribute.String("chaos.fault", fault)}, attrs...)
+
+	if i.counter != nil {
+		i.counter.Add(ctx, 1, metric.WithAttributes(attrs[0]))
+	}
+	trace.SpanFromContext(ctx).AddEvent("chaos", trace.WithAttributes(attrs...))
+}
+
+type transport struct {
+	injector *Injector
+	base     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if d := t.injector.delay(); d > 0 {
+		t.injector.record(ctx, "latency", attribute.Int64("chaos.latency_ms", d.Milliseconds()))
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+
+	if rate := t.injector.cfg.FailureRate; rate > 0 && rand.Float64() < rate {
+		status := t.injector.cfg.FailureStatus
+		t.injector.record(ctx, "failure", attribute.Int("chaos.status", status))
+		if status == 0 {
+			return nil, ErrInjected
+		}
+		return &http.Response{
+			Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			StatusCode: status,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{"Content-Type": {"text/plain"}},
+			Body:       io.NopCloser(strings.NewReader(ErrInjected.Error())),
+			Request:    req,
+		}, nil
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+type dropProcessor struct {
+	sdktrace.SpanProcessor
+	injector *Injector
+}
+
+func (p *dropProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if rand.Float64() < p.injector.cfg.SpanDropRate {
+		if p.injector.counter != nil {
+			p.injector.counter.Add(context.Background(), 1, metric.WithAttributes(attribute.String("chaos.fault", "span_drop")))
+		}
+		return
+	}
+	p.SpanProcessor.OnEnd(s)
+}
@@ -0,0 +1,222 @@
+// Package configfile reads the optional config file named by CONFIG_FILE into
+// viper, beneath the environment, and watches it for the settings a service
+// can change while it runs. Which settings those are is up to each service.
+package configfile
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/luis-olivetti/go-observability/pkg/platform/featureflag"
+	"github.com/luis-olivetti/go-observability/pkg/platform/sampling"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Change describes a config file change that was applied.
+type Change struct {
+	Version  int64
+	Path     string
+	Checksum string
+}
+
+// emptyChecksum is the SHA-256 of an empty file.
+const emptyChecksum = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+var (
+	version      atomic.Int64
+	lastChecksum string
+	reloadMu     sync.Mutex
+)
+
+// UseFile makes Read load path instead of the file named by CONFIG_FILE.
+func UseFile(path string) {
+	viper.Set("CONFIG_FILE", path)
+}
+
+// Override sets key above the environment and the config file; it backs the
+// command-line flags, the highest-precedence source.
+func Override(key, value string) {
+	viper.Set(key, value)
+}
+
+// Read loads CONFIG_FILE, when set, beneath the environment: variables still
+// override whatever the file says.
+func Read() error {
+	path := viper.GetString("CONFIG_FILE")
+	if path == "" {
+		return nil
+	}
+
+	viper.SetConfigFile(path)
+	if err := viper.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	flattenSections()
+
+	checksum, err := fileChecksum(path)
+	if err != nil {
+		return err
+	}
+	reloadMu.Lock()
+	lastChecksum = checksum
+	reloadMu.Unlock()
+	version.Store(1)
+
+	return nil
+}
+
+// flattenSections lets the config file group related settings, so that
+//
+//	sampling:
+//	  ratio: 0.1
+//
+// is read as SAMPLING_RATIO, the same key the environment uses.
+func flattenSections() {
+	flat := make(map[string]any)
+	for _, key := range viper.AllKeys() {
+		if strings.Contains(key, ".") {
+			flat[strings.ReplaceAll(key, ".", "_")] = viper.Get(key)
+		}
+	}
+
+	if len(flat) > 0 {
+		if err := viper.MergeConfigMap(flat); err != nil {
+			log.Printf("failed to flatten config file sections: %v", err)
+		}
+	}
+}
+
+// Sampling reads SAMPLING_RATIO and SAMPLING_LATENCY_THRESHOLD; it is nil
+// when SAMPLING_RATIO is not set.
+func Sampling() (*sampling.Config, error) {
+	if viper.GetString("SAMPLING_RATIO") == "" {
+		return nil, nil
+	}
+
+	cfg := &sampling.Config{
+		Ratio:            viper.GetFloat64("SAMPLING_RATIO"),
+		LatencyThreshold: viper.GetDuration("SAMPLING_LATENCY_THRESHOLD"),
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("SAMPLING_RATIO is invalid: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Features reads every FEATURE_<NAME> setting, or <name> under the feature
+// section of the config file, as the rule of flag <name>.
+func Features() (map[string]featureflag.Rule, error) {
+	keys := make(map[string]bool)
+	for _, key := range viper.AllKeys() {
+		if strings.HasPrefix(key, "feature_") {
+			keys[key] = true
+		}
+	}
+	for _, env := range os.Environ() {
+		if key, _, _ := strings.Cut(env, "="); strings.HasPrefix(key, "FEATURE_") {
+			keys[strings.ToLower(key)] = true
+		}
+	}
+
+	features := make(map[string]featureflag.Rule, len(keys))
+	for key := range keys {
+		rule, err := featureflag.ParseRule(viper.GetString(key))
+		if err != nil {
+			return nil, fmt.Errorf("%s is invalid: %w", strings.ToUpper(key), err)
+		}
+		features[strings.TrimPrefix(key, "feature_")] = rule
+	}
+
+	return features, nil
+}
+
+// Watch re-reads CONFIG_FILE whenever it changes and calls apply, which reads
+// the new settings from viper. A file that does not parse, or that apply
+// rejects by returning an error, is logged and ignored, so the previous
+// settings stay in effect. The number of applied versions is exported as the
+// config.version gauge. Watch does nothing when no config file is set.
+func Watch(apply func(Change) error) {
+	path := viper.GetString("CONFIG_FILE")
+	if path == "" {
+		return
+	}
+
+	meter := otel.Meter("microservice-meter")
+	reloads, err := meter.Int64Counter("config.reloads",
+		metric.WithDescription("Config file changes seen, by result"))
+	if err != nil {
+		log.Printf("failed to create config reloads counter: %v", err)
+	}
+	_, err = meter.Int64ObservableGauge("config.version",
+		metric.WithDescription("Version of the config file in effect, starting at 1"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(version.Load())
+			return nil
+		}))
+	if err != nil {
+		log.Printf("failed to create config version gauge: %v", err)
+	}
+
+	count := func(result string) {
+		if reloads != nil {
+			reloads.Add(context.Background(), 1, metric.WithAttributes(attribute.String("result", result)))
+		}
+	}
+
+	viper.OnConfigChange(func(fsnotify.Event) {
+		reloadMu.Lock()
+		defer reloadMu.Unlock()
+
+		// Editors and ConfigMap updates fire several events per change, and
+		// writers that truncate first briefly leave an empty file behind.
+		checksum, err := fileChecksum(path)
+		if err != nil || checksum == lastChecksum || checksum == emptyChecksum {
+			return
+		}
+		lastChecksum = checksum
+
+		// viper keeps the previous settings when the file does not parse,
+		// so parse it on its own to tell a bad file from an unchanged one.
+		probe := viper.New()
+		probe.SetConfigFile(path)
+		if err := probe.ReadInConfig(); err != nil {
+			log.Printf("rejected config reload of %s: %v", path, err)
+			count("rejected")
+			return
+		}
+
+		flattenSections()
+		next := version.Load() + 1
+		if err := apply(Change{Version: next, Path: path, Checksum: checksum}); err != nil {
+			log.Printf("rejected config reload of %s: %v", path, err)
+			count("rejected")
+			return
+		}
+
+		version.Store(next)
+		count("applied")
+	})
+	viper.WatchConfig()
+}
+
+func fileChecksum(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,200 @@
+package debugtrace
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// Header is sent by callers that want a full trace of their request.
+	Header = contracts.DebugTraceHeader
+	// Key is the baggage member set once a debug trace is granted; it
+	// carries the decision to every service the request reaches.
+	Key = contracts.DebugBaggageKey
+)
+
+// Attribute marks every span of a debug trace. The tail sampler keeps any
+// trace carrying it, whatever the ratio.
+const Attribute = attribute.Key("debug.trace")
+
+// sensitiveHeaders are never copied onto spans, debug trace or not.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+	http.CanonicalHeaderKey(contracts.SignatureHeader): true,
+}
+
+// Requested reports whether r asks for a debug trace, through the header or
+// a debug.trace member in the baggage it sent. Whether the caller may have
+// one is decided separately.
+func Requested(r *http.Request) bool {
+	if value := r.Header.Get(Header); value != "" {
+		enabled, _ := strconv.ParseBool(value)
+		return enabled
+	}
+
+	bag, err := baggage.Parse(strings.Join(r.Header.Values("baggage"), ","))
+	if err != nil {
+		return false
+	}
+	enabled, _ := strconv.ParseBool(bag.Member(Key).Value())
+	return enabled
+}
+
+// Enabled reports whether ctx belongs to a granted debug trace.
+func Enabled(ctx context.Context) bool {
+	return baggage.FromContext(ctx).Member(Key).Value() == "true"
+}
+
+// TraceIDPlaceholder is replaced by the trace id in trace URL templates.
+const TraceIDPlaceholder = "{trace_id}"
+
+// TraceURL expands template, such as
+// http://localhost:9411/zipkin/traces/{trace_id}, with the trace of ctx. It
+// returns "" unless ctx belongs to a debug trace and template is set.
+func TraceURL(ctx context.Context, template string) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if template == "" || !Enabled(ctx) || !spanContext.HasTraceID() {
+		return ""
+	}
+
+	return strings.ReplaceAll(template, TraceIDPlaceholder, spanContext.TraceID().String())
+}
+
+// Annotate records the request headers on span, minus credentials, when
+// ctx belongs to a debug trace.
+func Annotate(ctx context.Context, span trace.Span, r *http.Request) {
+	if !Enabled(ctx) {
+		return
+	}
+
+	span.SetAttributes(headerAttributes("http.request.header.", r.Header)...)
+}
+
+func headerAttributes(prefix string, header http.Header) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for name, values := range header {
+		if sensitiveHeaders[name] {
+			continue
+		}
+		attrs = append(attrs, attribute.StringSlice(prefix+strings.ToLower(name), values))
+	}
+
+	return attrs
+}
+
+// Transport adds connection-level events (DNS, connect, TLS, first byte) and
+// the exchanged headers to the active span of requests that belong to a
+// debug trace. Other requests go through untouched.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !Enabled(ctx) {
+		return t.base.RoundTrip(req)
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent("http.request", trace.WithAttributes(append(
+		headerAttributes("http.request.header.", req.Header),
+		attribute.String("server.address", req.URL.Host),
+		attribute.String("url.path", req.URL.Path),
+	)...))
+
+	req = req.WithContext(httptrace.WithClientTrace(ctx, clientTrace(span)))
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	span.AddEvent("http.response", trace.WithAttributes(append(
+		headerAttributes("http.response.header.", resp.Header),
+		attribute.Int("http.response.status_code", resp.StatusCode),
+	)...))
+
+	return resp, nil
+}
+
+func clientTrace(span trace.Span) *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			span.AddEvent("http.got_conn", trace.WithAttributes(
+				attribute.Bool("http.conn.reused", info.Reused),
+				attribute.Bool("http.conn.was_idle", info.WasIdle),
+			))
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			attrs := []attribute.KeyValue{attribute.Int("dns.addresses", len(info.Addrs))}
+			if info.Err != nil {
+				attrs = append(attrs, attribute.String("error.message", info.Err.Error()))
+			}
+			span.AddEvent("http.dns_done", trace.WithAttributes(attrs...))
+		},
+		ConnectDone: func(network, addr string, err error) {
+			attrs := []attribute.KeyValue{attribute.String("network.peer.address", addr)}
+			if err != nil {
+				attrs = append(attrs, attribute.String("error.message", err.Error()))
+			}
+			span.AddEvent("http.connect_done", trace.WithAttributes(attrs...))
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			attrs := []attribute.KeyValue{attribute.String("tls.protocol.version", tls.VersionName(state.Version))}
+			if err != nil {
+				attrs = append(attrs, attribute.String("error.message", err.Error()))
+			}
+			span.AddEvent("http.tls_handshake_done", trace.WithAttributes(attrs...))
+		},
+		GotFirstResponseByte: func() {
+			span.AddEvent("http.first_response_byte")
+		},
+	}
+}
+
+// SpanProcessor marks every span started within a debug trace with
+// Attribute, so the sampler and the trace backend can tell them apart.
+type SpanProcessor struct{}
+
+func (SpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if Enabled(parent) {
+		s.SetAttributes(Attribute.Bool(true))
+	}
+}
+
+func (SpanProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+func (SpanProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+func (SpanProcessor) ForceFlush(context.Context) error {
+	return nil
+}
+
+// Forced reports whether s belongs to a debug trace.
+func Forced(s sdktrace.ReadOnlySpan) bool {
+	for _, attr := range s.Attributes() {
+		if attr.Key == Attribute {
+			return attr.Value.AsBool()
+		}
+	}
+	return false
+}
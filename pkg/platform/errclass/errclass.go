@@ -0,0 +1,131 @@
+package errclass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Class is the kind of failure behind an error response, independent of the
+// status it is reported with.
+type Class string
+
+const (
+	// Validation is a malformed request or zipcode.
+	Validation Class = "validation"
+	// NotFound is a zipcode, or the city it belongs to, that the upstreams
+	// do not know.
+	NotFound Class = "not_found"
+	// UpstreamTimeout is an upstream that did not answer in time.
+	UpstreamTimeout Class = "upstream_timeout"
+	// UpstreamError is an upstream that failed, answered with an error or
+	// sent a body that could not be read.
+	UpstreamError Class = "upstream_error"
+)
+
+var defaultStatuses = map[Class]int{
+	Validation:      http.StatusUnprocessableEntity,
+	NotFound:        http.StatusNotFound,
+	UpstreamTimeout: http.StatusGatewayTimeout,
+	UpstreamError:   http.StatusBadGateway,
+}
+
+// Mapping gives the HTTP status each class is answered with.
+type Mapping struct {
+	statuses map[Class]int
+}
+
+// NewMapping applies overrides on top of the default statuses.
+func NewMapping(overrides map[Class]int) *Mapping {
+	statuses := make(map[Class]int, len(defaultStatuses))
+	for class, status := range defaultStatuses {
+		statuses[class] = status
+	}
+	for class, status := range overrides {
+		statuses[class] = status
+	}
+
+	return &Mapping{statuses: statuses}
+}
+
+func (m *Mapping) Status(class Class) int {
+	if status, ok := m.statuses[class]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Default is the mapping apierror builds responses with. main replaces it
+// with the configured overrides before serving.
+var Default = NewMapping(nil)
+
+// ParseOverrides reads "class=status,...", such as "upstream_timeout=503".
+// Statuses must be client or server errors.
+func ParseOverrides(raw string) (map[Class]int, error) {
+	overrides := map[Class]int{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid override %q, expected class=status", entry)
+		}
+
+		class := Class(strings.TrimSpace(name))
+		if _, known := defaultStatuses[class]; !known {
+			return nil, fmt.Errorf("unknown error class %q (known: %s)", class, strings.Join(classNames(), ", "))
+		}
+
+		status, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || status < 400 || status > 599 {
+			return nil, fmt.Errorf("status of %s must be between 400 and 599, got %q", class, value)
+		}
+		overrides[class] = status
+	}
+
+	return overrides, nil
+}
+
+func classNames() []string {
+	names := make([]string, 0, len(defaultStatuses))
+	for class := range defaultStatuses {
+		names = append(names, string(class))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Of classifies the error of an upstream call that got no usable response:
+// timeouts, whether of the client, the context or the connection, apart from
+// every other failure.
+func Of(err error) Class {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return UpstreamTimeout
+	}
+	return UpstreamError
+}
+
+// FromStatus classifies an error status answered by an upstream. Upstreams
+// with their own conventions, such as WeatherAPI's error codes, are
+// classified by their client instead.
+func FromStatus(status int) Class {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return Validation
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return UpstreamTimeout
+	default:
+		return UpstreamError
+	}
+}
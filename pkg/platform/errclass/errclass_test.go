@@ -0,0 +1,129 @@
+package errclass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestMapping(t *testing.T) {
+	mapping := NewMapping(map[Class]int{UpstreamTimeout: http.StatusServiceUnavailable})
+
+	tests := []struct {
+		class Class
+		want  int
+	}{
+		{class: Validation, want: http.StatusUnprocessableEntity},
+		{class: NotFound, want: http.StatusNotFound},
+		{class: UpstreamTimeout, want: http.StatusServiceUnavailable},
+		{class: UpstreamError, want: http.StatusBadGateway},
+		{class: "unknown", want: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if got := mapping.Status(tt.class); got != tt.want {
+			t.Errorf("Status(%s) = %d, want %d", tt.class, got, tt.want)
+		}
+	}
+	if got := Default.Status(UpstreamTimeout); got != http.StatusGatewayTimeout {
+		t.Errorf("overrides leaked into the default mapping: Status(%s) = %d", UpstreamTimeout, got)
+	}
+}
+
+func TestParseOverrides(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    map[Class]int
+		wantErr bool
+	}{
+		{raw: "", want: map[Class]int{}},
+		{raw: "upstream_timeout=503, not_found = 422", want: map[Class]int{UpstreamTimeout: 503, NotFound: 422}},
+		{raw: "upstream_timeout", wantErr: true},
+		{raw: "timeout=503", wantErr: true},
+		{raw: "not_found=200", wantErr: true},
+		{raw: "not_found=600", wantErr: true},
+		{raw: "not_found=gone", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseOverrides(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseOverrides(%q) error = %v, want error %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseOverrides(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+		for class, status := range tt.want {
+			if got[class] != status {
+				t.Errorf("ParseOverrides(%q)[%s] = %d, want %d", tt.raw, class, got[class], status)
+			}
+		}
+	}
+}
+
+func TestOf(t *testing.T) {
+	// A real client timeout, as the upstream clients see it.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	_, clientTimeout := (&http.Client{Timeout: 10 * time.Millisecond}).Get(server.URL)
+
+	tests := []struct {
+		name string
+		err  error
+		want Class
+	}{
+		{name: "context deadline", err: context.DeadlineExceeded, want: UpstreamTimeout},
+		{name: "wrapped deadline", err: fmt.Errorf("failed to fetch: %w", context.DeadlineExceeded), want: UpstreamTimeout},
+		{name: "client timeout", err: clientTimeout, want: UpstreamTimeout},
+		{name: "dial timeout", err: &net.OpError{Op: "dial", Err: timeoutError{}}, want: UpstreamTimeout},
+		{name: "connection refused", err: &url.Error{Op: "Get", URL: "http://upstream", Err: errors.New("connection refused")}, want: UpstreamError},
+		{name: "canceled", err: context.Canceled, want: UpstreamError},
+		{name: "decode failure", err: errors.New("unexpected EOF"), want: UpstreamError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Of(tt.err); got != tt.want {
+				t.Errorf("Of(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   Class
+	}{
+		{status: http.StatusBadRequest, want: Validation},
+		{status: http.StatusUnprocessableEntity, want: Validation},
+		{status: http.StatusNotFound, want: NotFound},
+		{status: http.StatusRequestTimeout, want: UpstreamTimeout},
+		{status: http.StatusGatewayTimeout, want: UpstreamTimeout},
+		{status: http.StatusUnauthorized, want: UpstreamError},
+		{status: http.StatusTooManyRequests, want: UpstreamError},
+		{status: http.StatusInternalServerError, want: UpstreamError},
+	}
+
+	for _, tt := range tests {
+		if got := FromStatus(tt.status); got != tt.want {
+			t.Errorf("FromStatus(%d) = %s, want %s", tt.status, got, tt.want)
+		}
+	}
+}
+
+// timeoutError is a net.Error that timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
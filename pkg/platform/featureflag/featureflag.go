@@ -0,0 +1,189 @@
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Rule is the state of one flag: on or off for everyone, or on for a stable
+// percentage of targeting keys.
+type Rule struct {
+	Enabled bool
+	// Percent is in [0, 100]; it is only used when Split is set.
+	Percent int
+	Split   bool
+}
+
+// ParseRule reads "true", "false", "on", "off" or a rollout such as "25%".
+func ParseRule(value string) (Rule, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		n, err := strconv.Atoi(strings.TrimSpace(percent))
+		if err != nil || n < 0 || n > 100 {
+			return Rule{}, fmt.Errorf("rollout must be a percentage between 0%% and 100%%, got %q", value)
+		}
+		return Rule{Percent: n, Split: true}, nil
+	}
+
+	switch value {
+	case "true", "on":
+		return Rule{Enabled: true}, nil
+	case "false", "off":
+		return Rule{Enabled: false}, nil
+	}
+	return Rule{}, fmt.Errorf("flag must be true, false, on, off or a percentage, got %q", value)
+}
+
+// StaticProvider is an OpenFeature provider serving rules read from the
+// environment or the config file. It resolves boolean flags only; the other
+// types get their default with a TYPE_MISMATCH error. The rules can be
+// swapped while requests are evaluated.
+type StaticProvider struct {
+	rules atomic.Pointer[map[string]Rule]
+}
+
+var _ openfeature.FeatureProvider = (*StaticProvider)(nil)
+
+func NewStaticProvider(rules map[string]Rule) *StaticProvider {
+	p := &StaticProvider{}
+	p.Set(rules)
+	return p
+}
+
+// Set replaces every rule; flags missing from rules fall back to the default
+// given at evaluation.
+func (p *StaticProvider) Set(rules map[string]Rule) {
+	p.rules.Store(&rules)
+}
+
+func (p *StaticProvider) Metadata() openfeature.Metadata {
+	return openfeature.Metadata{Name: "static"}
+}
+
+func (p *StaticProvider) Hooks() []openfeature.Hook {
+	return nil
+}
+
+func (p *StaticProvider) BooleanEvaluation(_ context.Context, flag string, defaultValue bool, evalCtx openfeature.FlattenedContext) openfeature.BoolResolutionDetail {
+	rule, ok := (*p.rules.Load())[flag]
+	if !ok {
+		return resolution(defaultValue, openfeature.DefaultReason)
+	}
+
+	if !rule.Split {
+		return resolution(rule.Enabled, openfeature.StaticReason)
+	}
+
+	targetingKey, _ := evalCtx[openfeature.TargetingKey].(string)
+	return resolution(bucket(flag, targetingKey) < rule.Percent, openfeature.SplitReason)
+}
+
+func (p *StaticProvider) StringEvaluation(_ context.Context, _ string, defaultValue string, _ openfeature.FlattenedContext) openfeature.StringResolutionDetail {
+	return openfeature.StringResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch()}
+}
+
+func (p *StaticProvider) FloatEvaluation(_ context.Context, _ string, defaultValue float64, _ openfeature.FlattenedContext) openfeature.FloatResolutionDetail {
+	return openfeature.FloatResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch()}
+}
+
+func (p *StaticProvider) IntEvaluation(_ context.Context, _ string, defaultValue int64, _ openfeature.FlattenedContext) openfeature.IntResolutionDetail {
+	return openfeature.IntResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch()}
+}
+
+func (p *StaticProvider) ObjectEvaluation(_ context.Context, _ string, defaultValue interface{}, _ openfeature.FlattenedContext) openfeature.InterfaceResolutionDetail {
+	return openfeature.InterfaceResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch()}
+}
+
+func resolution(enabled bool, reason openfeature.Reason) openfeature.BoolResolutionDetail {
+	return openfeature.BoolResolutionDetail{
+		Value:                    enabled,
+		ProviderResolutionDetail: openfeature.ProviderResolutionDetail{Variant: variant(enabled), Reason: reason},
+	}
+}
+
+func typeMismatch() openfeature.ProviderResolutionDetail {
+	return openfeature.ProviderResolutionDetail{
+		ResolutionError: openfeature.NewTypeMismatchResolutionError("the static provider only serves boolean flags"),
+		Reason:          openfeature.ErrorReason,
+	}
+}
+
+// bucket places targetingKey in [0, 100) for flag, so a tenant keeps its
+// variant across requests and replicas while the rollout percentage holds.
+func bucket(flag, targetingKey string) int {
+	h := fnv.New32a()
+	h.Write([]byte(flag))
+	h.Write([]byte{0})
+	h.Write([]byte(targetingKey))
+	return int(h.Sum32() % 100)
+}
+
+func variant(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
+
+// SpanHook is an OpenFeature hook recording each evaluation as a
+// feature_flag event on the active span.
+type SpanHook struct {
+	openfeature.UnimplementedHook
+}
+
+func (SpanHook) After(ctx context.Context, hookContext openfeature.HookContext, details openfeature.InterfaceEvaluationDetails, _ openfeature.HookHints) error {
+	trace.SpanFromContext(ctx).AddEvent("feature_flag", trace.WithAttributes(
+		attribute.String("feature_flag.key", hookContext.FlagKey()),
+		attribute.String("feature_flag.provider_name", hookContext.ProviderMetadata().Name),
+		attribute.String("feature_flag.variant", details.Variant),
+		attribute.String("feature_flag.reason", string(details.Reason)),
+	))
+	return nil
+}
+
+func (SpanHook) Error(ctx context.Context, hookContext openfeature.HookContext, err error, _ openfeature.HookHints) {
+	trace.SpanFromContext(ctx).AddEvent("feature_flag", trace.WithAttributes(
+		attribute.String("feature_flag.key", hookContext.FlagKey()),
+		attribute.String("feature_flag.provider_name", hookContext.ProviderMetadata().Name),
+		attribute.String("feature_flag.reason", string(openfeature.ErrorReason)),
+		attribute.String("error.message", err.Error()),
+	))
+}
+
+// Client evaluates boolean flags through an OpenFeature client with the
+// SpanHook installed. A nil Client returns the defaults.
+type Client struct {
+	client *openfeature.Client
+}
+
+// NewClient registers provider as the OpenFeature provider of domain, usually
+// the service name, and returns a client bound to it.
+func NewClient(domain string, provider openfeature.FeatureProvider) (*Client, error) {
+	if err := openfeature.SetNamedProviderAndWait(domain, provider); err != nil {
+		return nil, err
+	}
+
+	client := openfeature.NewClient(domain)
+	client.AddHooks(SpanHook{})
+	return &Client{client: client}, nil
+}
+
+// Boolean reports whether flag is on for targetingKey, usually the tenant.
+// Evaluation errors yield defaultValue.
+func (c *Client) Boolean(ctx context.Context, flag string, defaultValue bool, targetingKey string) bool {
+	if c == nil {
+		return defaultValue
+	}
+
+	value, _ := c.client.BooleanValue(ctx, flag, defaultValue, openfeature.NewEvaluationContext(targetingKey, nil))
+	return value
+}
@@ -0,0 +1,167 @@
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Rule
+		wantErr bool
+	}{
+		{value: "true", want: Rule{Enabled: true}},
+		{value: " ON ", want: Rule{Enabled: true}},
+		{value: "off", want: Rule{}},
+		{value: "25%", want: Rule{Percent: 25, Split: true}},
+		{value: "0 %", want: Rule{Percent: 0, Split: true}},
+		{value: "101%", wantErr: true},
+		{value: "-1%", wantErr: true},
+		{value: "maybe", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRule(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRule(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRule(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestClientBoolean(t *testing.T) {
+	provider := NewStaticProvider(map[string]Rule{
+		"on":   {Enabled: true},
+		"off":  {},
+		"none": {Percent: 0, Split: true},
+		"all":  {Percent: 100, Split: true},
+	})
+	client, err := NewClient(t.Name(), provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		flag         string
+		defaultValue bool
+		want         bool
+		wantReason   string
+	}{
+		{flag: "on", want: true, wantReason: "STATIC"},
+		{flag: "off", defaultValue: true, want: false, wantReason: "STATIC"},
+		{flag: "none", defaultValue: true, want: false, wantReason: "SPLIT"},
+		{flag: "all", want: true, wantReason: "SPLIT"},
+		{flag: "missing", defaultValue: true, want: true, wantReason: "DEFAULT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+			ctx, span := tp.Tracer("").Start(context.Background(), "request")
+
+			if got := client.Boolean(ctx, tt.flag, tt.defaultValue, "tenant-a"); got != tt.want {
+				t.Errorf("Boolean(%q) = %v, want %v", tt.flag, got, tt.want)
+			}
+			span.End()
+
+			events := exporter.GetSpans()[0].Events
+			if len(events) != 1 || events[0].Name != "feature_flag" {
+				t.Fatalf("events = %v, want one feature_flag event", events)
+			}
+			attrs := attribute.NewSet(events[0].Attributes...)
+			for key, want := range map[attribute.Key]string{
+				"feature_flag.key":           tt.flag,
+				"feature_flag.provider_name": "static",
+				"feature_flag.variant":       variant(tt.want),
+				"feature_flag.reason":        tt.wantReason,
+			} {
+				if got, _ := attrs.Value(key); got.AsString() != want {
+					t.Errorf("%s = %q, want %q", key, got.AsString(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestClientSplitIsStable(t *testing.T) {
+	client, err := NewClient(t.Name(), NewStaticProvider(map[string]Rule{"rollout": {Percent: 30, Split: true}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var enabled int
+	for i := 0; i < 1000; i++ {
+		tenant := fmt.Sprintf("tenant-%d", i)
+		first := client.Boolean(context.Background(), "rollout", false, tenant)
+		if again := client.Boolean(context.Background(), "rollout", false, tenant); again != first {
+			t.Fatalf("%s flipped from %v to %v", tenant, first, again)
+		}
+		if first {
+			enabled++
+		}
+	}
+	if enabled < 250 || enabled > 350 {
+		t.Errorf("rollout enabled for %d of 1000 tenants, want about 300", enabled)
+	}
+}
+
+func TestProviderSet(t *testing.T) {
+	provider := NewStaticProvider(map[string]Rule{"flag": {Enabled: true}})
+	client, err := NewClient(t.Name(), provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider.Set(map[string]Rule{"flag": {}})
+	if client.Boolean(context.Background(), "flag", true, "") {
+		t.Error("flag still on after Set turned it off")
+	}
+}
+
+func TestProviderSetConcurrent(t *testing.T) {
+	provider := NewStaticProvider(map[string]Rule{"flag": {Enabled: true}})
+	client, err := NewClient(t.Name(), provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if i == 0 {
+					provider.Set(map[string]Rule{"flag": {Enabled: j%2 == 0}, "rollout": {Percent: j, Split: true}})
+					continue
+				}
+				client.Boolean(context.Background(), "flag", false, "")
+				client.Boolean(context.Background(), "rollout", false, fmt.Sprintf("tenant-%d", j))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	provider.Set(map[string]Rule{"flag": {Enabled: true}})
+	if !client.Boolean(context.Background(), "flag", false, "") {
+		t.Error("flag off after the last Set turned it on")
+	}
+}
+
+func TestNilClient(t *testing.T) {
+	var client *Client
+	if !client.Boolean(context.Background(), "flag", true, "") {
+		t.Error("nil client did not return the default")
+	}
+}
@@ -0,0 +1,40 @@
+package health
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// Checker backs the liveness, readiness and startup endpoints.
+type Checker struct {
+	ready   atomic.Bool
+	startup startup
+}
+
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// SetReady flips readiness; it is set once the public listener is up and
+// cleared when shutdown starts so load balancers drain the instance.
+func (c *Checker) SetReady(ready bool) {
+	c.ready.Store(ready)
+}
+
+// Liveness reports that the process is running and able to serve HTTP.
+func (c *Checker) Liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte("ok"))
+}
+
+// Readiness reports whether the instance should receive traffic.
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	if !c.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("not ready"))
+		return
+	}
+
+	w.Write([]byte("ready"))
+}
@@ -0,0 +1,144 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Startup checks are retried at this interval until they pass; each attempt
+// gets startupAttemptTimeout.
+const (
+	startupRetryInterval  = time.Second
+	startupAttemptTimeout = 2 * time.Second
+)
+
+// StartupCheck is a dependency verified once at startup.
+type StartupCheck struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+type checkState struct {
+	name   string
+	passed bool
+	err    error
+}
+
+// startup tracks the checks behind /startupz.
+type startup struct {
+	mu     sync.Mutex
+	checks []*checkState
+}
+
+// RunStartupChecks runs every check in the background, retrying each until
+// it passes or ctx is done. /startupz reports success once all have passed;
+// unlike readiness, it never goes back to failing.
+func (c *Checker) RunStartupChecks(ctx context.Context, checks ...StartupCheck) {
+	c.startup.mu.Lock()
+	defer c.startup.mu.Unlock()
+
+	for _, check := range checks {
+		state := &checkState{name: check.Name}
+		c.startup.checks = append(c.startup.checks, state)
+		go c.runStartupCheck(ctx, check, state)
+	}
+}
+
+func (c *Checker) runStartupCheck(ctx context.Context, check StartupCheck, state *checkState) {
+	ticker := time.NewTicker(startupRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		attemptCtx, cancel := context.WithTimeout(ctx, startupAttemptTimeout)
+		err := check.Run(attemptCtx)
+		cancel()
+
+		c.startup.mu.Lock()
+		firstFailure := err != nil && state.err == nil
+		state.passed, state.err = err == nil, err
+		c.startup.mu.Unlock()
+
+		if err == nil {
+			return
+		}
+		if firstFailure {
+			log.Printf("startup check %s failed, retrying: %v", check.Name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Startup reports whether every startup check has passed, listing the ones
+// still pending.
+func (c *Checker) Startup(w http.ResponseWriter, r *http.Request) {
+	c.startup.mu.Lock()
+	var pending []string
+	for _, state := range c.startup.checks {
+		switch {
+		case state.passed:
+		case state.err != nil:
+			pending = append(pending, fmt.Sprintf("%s: %v", state.name, state.err))
+		default:
+			pending = append(pending, state.name+": pending")
+		}
+	}
+	c.startup.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain")
+	if len(pending) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(strings.Join(pending, "\n")))
+		return
+	}
+
+	w.Write([]byte("started"))
+}
+
+// DNSCheck verifies that the host of rawURL resolves.
+func DNSCheck(name, rawURL string) StartupCheck {
+	return StartupCheck{
+		Name: "dns:" + name,
+		Run: func(ctx context.Context) error {
+			u, err := url.Parse(rawURL)
+			if err != nil {
+				return err
+			}
+			if net.ParseIP(u.Hostname()) != nil {
+				return nil
+			}
+			_, err = net.DefaultResolver.LookupHost(ctx, u.Hostname())
+			return err
+		},
+	}
+}
+
+// UpstreamCheck sends one HEAD request to rawURL. Any HTTP answer counts: the
+// check is about reaching the upstream, not about what it says.
+func UpstreamCheck(name string, client *http.Client, rawURL string) StartupCheck {
+	return StartupCheck{
+		Name: "upstream:" + name,
+		Run: func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+			if err != nil {
+				return err
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return err
+			}
+			return resp.Body.Close()
+		},
+	}
+}
@@ -0,0 +1,134 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/debugtrace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// secretField matches JSON string fields that may hold credentials, such as
+// a token echoed back by a provider.
+var secretField = regexp.MustCompile(`(?i)("[^"]*(?:key|token|secret|password|authorization)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// CaptureConfig sets which upstream calls have their bodies recorded.
+type CaptureConfig struct {
+	// Percent of the calls captured, above 0 and up to 100. Calls made
+	// within a debug trace are always captured.
+	Percent float64
+	// MaxBytes caps the body recorded of each request and response.
+	MaxBytes int
+}
+
+// Capture records the bodies exchanged in a share of the calls to the
+// upstream called name, to diagnose payload quirks of a provider, such as
+// ViaCEP answering an unknown CEP with "erro": "true" instead of true,
+// without reproducing them locally. Each captured call adds an
+// http.capture event to the active span and logs the same at debug level.
+// Bodies are cut to cfg.MaxBytes and secret JSON fields are redacted; CEPs
+// and URL secrets are rendered as the scrubber of each signal says. With a
+// nil cfg base is returned as is.
+func Capture(name string, cfg *CaptureConfig, base http.RoundTripper) http.RoundTripper {
+	if cfg == nil {
+		return base
+	}
+	return &captureTransport{upstream: name, cfg: *cfg, base: base}
+}
+
+type captureTransport struct {
+	upstream string
+	cfg      CaptureConfig
+	base     http.RoundTripper
+}
+
+func (t *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !debugtrace.Enabled(ctx) && rand.Float64()*100 >= t.cfg.Percent {
+		return t.base.RoundTrip(req)
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("upstream", t.upstream),
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.full", req.URL.String()),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			captured, truncated, _ := t.read(body)
+			body.Close()
+			attrs = append(attrs, t.bodyAttributes("http.request.body", captured, truncated)...)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	attrs = append(attrs, attribute.Int("http.response.status_code", resp.StatusCode))
+
+	// The caller still reads the whole body: what was read here is put back
+	// in front of the rest.
+	captured, truncated, readErr := t.read(resp.Body)
+	var rest io.Reader = resp.Body
+	if readErr != nil {
+		rest = errReader{readErr}
+	}
+	resp.Body = &replayedBody{Reader: io.MultiReader(bytes.NewReader(captured), rest), Closer: resp.Body}
+	attrs = append(attrs, t.bodyAttributes("http.response.body", captured, truncated)...)
+
+	trace.SpanFromContext(ctx).AddEvent("http.capture", trace.WithAttributes(attrs...))
+
+	args := make([]any, 0, len(attrs))
+	for _, attr := range attrs {
+		args = append(args, slog.Any(string(attr.Key), attr.Value.AsInterface()))
+	}
+	slog.DebugContext(ctx, "upstream call captured", args...)
+
+	return resp, nil
+}
+
+// read reads up to MaxBytes of body, reporting whether there was more.
+func (t *captureTransport) read(body io.Reader) ([]byte, bool, error) {
+	captured, err := io.ReadAll(io.LimitReader(body, int64(t.cfg.MaxBytes)+1))
+	if len(captured) > t.cfg.MaxBytes {
+		return captured, true, err
+	}
+	return captured, false, err
+}
+
+func (t *captureTransport) bodyAttributes(key string, body []byte, truncated bool) []attribute.KeyValue {
+	if truncated {
+		body = body[:t.cfg.MaxBytes]
+	}
+	// A cut may split a multi-byte character.
+	for i := 1; truncated && i < utf8.UTFMax && len(body) > 0 && !utf8.Valid(body); i++ {
+		body = body[:len(body)-1]
+	}
+
+	return []attribute.KeyValue{
+		attribute.String(key, secretField.ReplaceAllString(string(body), `${1}"[REDACTED]"`)),
+		attribute.Bool(key+".truncated", truncated),
+	}
+}
+
+type replayedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// errReader fails with the error the capture ran into, so the caller sees it
+// after the bytes read before it.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,223 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// latencyWeight is the weight of the latest call in an endpoint's
+	// moving average; older calls fade geometrically.
+	latencyWeight = 0.3
+	// exploreRate is the share of calls sent to an endpoint other than the
+	// fastest, so the averages of the others stay current and a recovered
+	// endpoint wins its place back.
+	exploreRate = 0.05
+	// cooldown is how long an endpoint is left aside after a transport error
+	// or a 5xx answer.
+	cooldown = 30 * time.Second
+)
+
+// endpoint is one base URL of an upstream and what is known of it.
+type endpoint struct {
+	base *neturl.URL
+	// latency is the moving average of the time to response headers, zero
+	// until the first call.
+	latency      time.Duration
+	failedAt     time.Time
+	unhealthyTil time.Time
+}
+
+func (e *endpoint) healthy(now time.Time) bool {
+	return !now.Before(e.unhealthyTil)
+}
+
+// Endpoints spreads the calls of the upstream called name over equivalent
+// base URLs, such as regional mirrors or our own proxies. Clients build their
+// URLs on the first one, and each call is moved to the healthy endpoint with
+// the lowest moving average latency; endpoints never called yet are tried
+// first. With a single base URL base is returned as is.
+//
+// The upstream.endpoint.selected gauge is 1 for the endpoint calls currently
+// go to, and upstream.endpoint.latency holds the average of each.
+func Endpoints(name string, baseURLs []string, base http.RoundTripper) (http.RoundTripper, error) {
+	if len(baseURLs) < 2 {
+		return base, nil
+	}
+
+	t := &endpointTransport{upstream: name, base: base}
+	for _, raw := range baseURLs {
+		u, err := neturl.Parse(strings.TrimSuffix(raw, "/"))
+		if err != nil {
+			return nil, err
+		}
+		t.endpoints = append(t.endpoints, &endpoint{base: u})
+	}
+	t.selected = t.endpoints[0]
+	t.watch()
+
+	return t, nil
+}
+
+type endpointTransport struct {
+	upstream string
+	base     http.RoundTripper
+	// clock defaults to the wall clock when nil.
+	clock clock.Clock
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+	selected  *endpoint
+}
+
+func (t *endpointTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	primary := t.endpoints[0].base
+	if req.URL.Scheme != primary.Scheme || req.URL.Host != primary.Host || !strings.HasPrefix(req.URL.Path, primary.Path) {
+		return t.base.RoundTrip(req)
+	}
+
+	target := t.pick()
+	if target != t.endpoints[0] {
+		req = req.Clone(req.Context())
+		req.URL.Scheme = target.base.Scheme
+		req.URL.Host = target.base.Host
+		req.URL.Path = target.base.Path + strings.TrimPrefix(req.URL.Path, primary.Path)
+		req.URL.RawPath = ""
+		req.Host = ""
+	}
+
+	start := clock.Or(t.clock).Now()
+	resp, err := t.base.RoundTrip(req)
+	t.observe(target, clock.Or(t.clock).Now().Sub(start), resp, err)
+
+	return resp, err
+}
+
+// pick returns the endpoint of the next call.
+func (t *endpointTransport) pick() *endpoint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := clock.Or(t.clock).Now()
+	var healthy []*endpoint
+	for _, e := range t.endpoints {
+		if e.healthy(now) {
+			healthy = append(healthy, e)
+		}
+	}
+	if len(healthy) == 0 {
+		// Every endpoint failed recently; the one that failed first has had
+		// the longest to recover.
+		oldest := t.endpoints[0]
+		for _, e := range t.endpoints[1:] {
+			if e.failedAt.Before(oldest.failedAt) {
+				oldest = e
+			}
+		}
+		return oldest
+	}
+
+	// An endpoint not called yet averages zero, so it is tried first.
+	fastest := healthy[0]
+	for _, e := range healthy[1:] {
+		if e.latency < fastest.latency {
+			fastest = e
+		}
+	}
+	if fastest != t.selected {
+		if fastest.latency == 0 {
+			log.Printf("%s calls now go to %s, not called yet", t.upstream, fastest.base)
+		} else {
+			log.Printf("%s calls now go to %s (%s on average)", t.upstream, fastest.base, fastest.latency)
+		}
+		t.selected = fastest
+	}
+
+	if len(healthy) > 1 && rand.Float64() < exploreRate {
+		other := healthy[rand.Intn(len(healthy)-1)]
+		if other == fastest {
+			other = healthy[len(healthy)-1]
+		}
+		return other
+	}
+	return fastest
+}
+
+// observe folds the outcome of one call into the state of e. Calls cancelled
+// by the caller say nothing of the endpoint; timeouts do.
+func (t *endpointTransport) observe(e *endpoint, elapsed time.Duration, resp *http.Response, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if e.latency == 0 {
+		e.latency = elapsed
+	} else {
+		e.latency += time.Duration(latencyWeight * float64(elapsed-e.latency))
+	}
+
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		e.failedAt = clock.Or(t.clock).Now()
+		e.unhealthyTil = e.failedAt.Add(cooldown)
+	}
+}
+
+// watch registers the endpoint gauges.
+func (t *endpointTransport) watch() {
+	meter := otel.Meter("microservice-meter")
+
+	selected, err := meter.Int64ObservableGauge("upstream.endpoint.selected",
+		metric.WithDescription("1 for the endpoint calls to an upstream go to, 0 for its other endpoints"))
+	if err != nil {
+		log.Printf("failed to create upstream endpoint gauge: %v", err)
+		return
+	}
+
+	latency, err := meter.Float64ObservableGauge("upstream.endpoint.latency",
+		metric.WithDescription("Moving average latency of each endpoint of an upstream"),
+		metric.WithUnit("s"))
+	if err != nil {
+		log.Printf("failed to create upstream endpoint latency gauge: %v", err)
+		return
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		for _, e := range t.endpoints {
+			attrs := metric.WithAttributes(
+				attribute.String("upstream", t.upstream),
+				attribute.String("endpoint", e.base.String()),
+			)
+
+			var current int64
+			if e == t.selected {
+				current = 1
+			}
+			o.ObserveInt64(selected, current, attrs)
+			if e.latency > 0 {
+				o.ObserveFloat64(latency, e.latency.Seconds(), attrs)
+			}
+		}
+		return nil
+	}, selected, latency)
+	if err != nil {
+		log.Printf("failed to register upstream endpoint callback: %v", err)
+	}
+}
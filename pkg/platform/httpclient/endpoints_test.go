@@ -0,0 +1,213 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+)
+
+// upstream answers for one endpoint host, taking latency on the fake clock.
+type upstream struct {
+	latency time.Duration
+	status  int
+	err     error
+}
+
+// fakeUpstreams answers each call as the upstream of its host says and
+// records the URLs called.
+type fakeUpstreams struct {
+	clock  *clock.Fake
+	hosts  map[string]*upstream
+	called []string
+}
+
+func (f *fakeUpstreams) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.called = append(f.called, req.URL.String())
+	u := f.hosts[req.URL.Host]
+	f.clock.Advance(u.latency)
+	if u.err != nil {
+		return nil, u.err
+	}
+	return &http.Response{StatusCode: u.status, Body: http.NoBody, Request: req}, nil
+}
+
+func (f *fakeUpstreams) lastHost(t *testing.T) string {
+	t.Helper()
+
+	return hostOf(t, f.called[len(f.called)-1])
+}
+
+func newTestEndpoints(t *testing.T, upstreams *fakeUpstreams, baseURLs ...string) *endpointTransport {
+	t.Helper()
+
+	rt, err := Endpoints("weatherapi", baseURLs, upstreams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := rt.(*endpointTransport)
+	transport.clock = upstreams.clock
+	return transport
+}
+
+func call(t *testing.T, rt http.RoundTripper, ctx context.Context, url string) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := rt.RoundTrip(req); err == nil {
+		resp.Body.Close()
+	}
+}
+
+func TestEndpointsSingleBaseURL(t *testing.T) {
+	base := &fakeUpstreams{}
+	rt, err := Endpoints("viacep", []string{"https://viacep.com.br"}, base)
+	if err != nil || rt != base {
+		t.Errorf("Endpoints = %v, %v, want the base transport as is", rt, err)
+	}
+}
+
+func TestEndpointsRewritesURLs(t *testing.T) {
+	upstreams := &fakeUpstreams{clock: clock.NewFake(time.Unix(1700000000, 0)), hosts: map[string]*upstream{
+		"primary.example":   {latency: time.Second, status: http.StatusOK},
+		"mirror.example":    {latency: time.Millisecond, status: http.StatusOK},
+		"elsewhere.example": {status: http.StatusOK},
+	}}
+	rt := newTestEndpoints(t, upstreams, "https://primary.example/v1/", "http://mirror.example/weather/v1")
+
+	// The primary is called first, then the untried mirror, which is faster.
+	for i := 0; i < 20; i++ {
+		call(t, rt, context.Background(), "https://primary.example/v1/current.json?q=Linhares")
+	}
+	var mirrored bool
+	for _, url := range upstreams.called {
+		if url == "http://mirror.example/weather/v1/current.json?q=Linhares" {
+			mirrored = true
+		}
+	}
+	if !mirrored {
+		t.Errorf("calls = %v, want some moved to the mirror with the path rebased", upstreams.called)
+	}
+
+	// Calls that are not built on the primary are left alone.
+	for _, url := range []string{"https://elsewhere.example/v1/current.json", "http://primary.example/v1/current.json", "https://primary.example/v2/current.json"} {
+		call(t, rt, context.Background(), url)
+		if got := upstreams.called[len(upstreams.called)-1]; got != url {
+			t.Errorf("call to %s went to %s", url, got)
+		}
+	}
+}
+
+func TestEndpointsRouting(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	upstreams := &fakeUpstreams{clock: clk, hosts: map[string]*upstream{
+		"a.example": {latency: 100 * time.Millisecond, status: http.StatusOK},
+		"b.example": {latency: 20 * time.Millisecond, status: http.StatusOK},
+	}}
+	rt := newTestEndpoints(t, upstreams, "https://a.example", "https://b.example")
+	url := "https://a.example/current.json"
+
+	// countCalls makes n calls and counts those that went to host.
+	countCalls := func(n int, host string) int {
+		upstreams.called = nil
+		count := 0
+		for i := 0; i < n; i++ {
+			call(t, rt, context.Background(), url)
+			if upstreams.lastHost(t) == host {
+				count++
+			}
+		}
+		return count
+	}
+
+	// Both endpoints are tried before the fastest takes the calls, leaving a
+	// few to exploration.
+	if got := countCalls(5, "b.example"); got == 0 || got == 5 {
+		t.Fatalf("%d of the first 5 calls went to b, want both endpoints tried", got)
+	}
+	if got := countCalls(200, "b.example"); got < 170 {
+		t.Errorf("%d of 200 calls went to the faster endpoint, want nearly all", got)
+	}
+
+	// A 5xx answer leaves the endpoint aside for the cooldown.
+	upstreams.hosts["b.example"].status = http.StatusBadGateway
+	for upstreams.called = nil; len(upstreams.called) == 0 || upstreams.lastHost(t) != "b.example"; {
+		call(t, rt, context.Background(), url)
+	}
+	upstreams.hosts["b.example"].status = http.StatusOK
+	if got := countCalls(50, "a.example"); got != 50 {
+		t.Errorf("%d of 50 calls went to a during b's cooldown, want all", got)
+	}
+
+	clk.Advance(cooldown)
+	if got := countCalls(50, "b.example"); got < 40 {
+		t.Errorf("%d of 50 calls went to b after its cooldown, want nearly all", got)
+	}
+
+	// With every endpoint failing, each call goes to the one that failed
+	// longest ago, so they take turns.
+	upstreams.hosts["a.example"].err = errors.New("connection refused")
+	upstreams.hosts["b.example"].err = errors.New("connection refused")
+	call(t, rt, context.Background(), url)
+	call(t, rt, context.Background(), url)
+	for i := 0; i < 10; i++ {
+		previous := upstreams.lastHost(t)
+		call(t, rt, context.Background(), url)
+		if got := upstreams.lastHost(t); got == previous {
+			t.Fatalf("call went to %s again, want the endpoint that failed longest ago", got)
+		}
+	}
+}
+
+func TestEndpointsCancelledCallKeepsEndpointHealthy(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	upstreams := &fakeUpstreams{clock: clk, hosts: map[string]*upstream{
+		"a.example": {latency: 100 * time.Millisecond, status: http.StatusOK},
+		"b.example": {latency: 20 * time.Millisecond, err: context.Canceled},
+	}}
+	rt := newTestEndpoints(t, upstreams, "https://a.example", "https://b.example")
+
+	for i := 0; i < 20; i++ {
+		call(t, rt, context.Background(), "https://a.example/current.json")
+	}
+	b := rt.endpoints[1]
+	if !b.healthy(clk.Now()) || b.latency != 0 {
+		t.Errorf("b after cancelled calls: healthy %v, latency %s, want untouched", b.healthy(clk.Now()), b.latency)
+	}
+}
+
+func TestEndpointsMovingAverage(t *testing.T) {
+	rt := &endpointTransport{clock: clock.NewFake(time.Unix(1700000000, 0))}
+	e := &endpoint{}
+	ok := &http.Response{StatusCode: http.StatusOK}
+
+	for _, step := range []struct {
+		elapsed time.Duration
+		want    time.Duration
+	}{
+		{elapsed: 100 * time.Millisecond, want: 100 * time.Millisecond},
+		{elapsed: 200 * time.Millisecond, want: 130 * time.Millisecond},
+		{elapsed: 30 * time.Millisecond, want: 100 * time.Millisecond},
+	} {
+		rt.observe(e, step.elapsed, ok, nil)
+		if e.latency != step.want {
+			t.Errorf("after a %s call average = %s, want %s", step.elapsed, e.latency, step.want)
+		}
+	}
+}
+
+func hostOf(t *testing.T, url string) string {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req.URL.Host
+}
@@ -0,0 +1,88 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// TLSConfig holds the TLS options applied to the connections of a single upstream.
+type TLSConfig struct {
+	CAFile             string
+	MinVersion         string
+	InsecureSkipVerify bool
+}
+
+// Config holds the settings of the client used for a single upstream.
+type Config struct {
+	TLS TLSConfig
+	// MaxIdleConnsPerHost raises the transport's idle pool, which otherwise
+	// keeps only two connections; zero keeps the default.
+	MaxIdleConnsPerHost int
+	// Timeout bounds each call; nil means no limit.
+	Timeout *Timeout
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// New builds a long-lived client for one upstream, with its own transport so
+// TLS settings never leak between upstreams.
+func New(cfg Config) (*http.Client, error) {
+	tlsConfig, err := buildTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if cfg.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+
+	if cfg.Timeout != nil {
+		return &http.Client{Transport: cfg.Timeout.Transport(transport)}, nil
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+func buildTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.MinVersion != "" {
+		version, ok := tlsVersions[strings.TrimPrefix(cfg.MinVersion, "TLS")]
+		if !ok {
+			return nil, fmt.Errorf("invalid TLS min version: %s", cfg.MinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if cfg.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle: %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
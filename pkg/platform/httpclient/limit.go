@@ -0,0 +1,44 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrResponseTooLarge is returned when an upstream body exceeds its cap.
+var ErrResponseTooLarge = errors.New("response body exceeds size limit")
+
+// LimitBody caps how much of an upstream body is read. Unlike
+// io.LimitReader it does not truncate silently: reading past limit bytes
+// fails with ErrResponseTooLarge, so a huge payload surfaces as an upstream
+// failure instead of a confusing decode error.
+func LimitBody(r io.Reader, limit int64) io.Reader {
+	return &limitedBody{r: r, remaining: limit}
+}
+
+type limitedBody struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+
+	// Ask for one byte more than allowed, so an oversized body is detected
+	// even when it ends exactly on a read boundary.
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		n = int(l.remaining)
+		l.remaining = -1
+		return n, ErrResponseTooLarge
+	}
+	l.remaining -= int64(n)
+
+	return n, err
+}
@@ -0,0 +1,59 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// Measure records the latency of every request sent through base in the
+// upstream.request.duration histogram, labelled with upstream and the
+// response status code, or error.type when no response came back. The
+// duration runs until the response headers arrive.
+//
+// Requests carry the context of the client span that sent them, so with
+// exemplars on each bucket links to a trace of a call that landed in it.
+func Measure(upstream string, base http.RoundTripper) http.RoundTripper {
+	meter := otel.Meter("microservice-meter")
+
+	duration, err := meter.Float64Histogram("upstream.request.duration",
+		metric.WithDescription("Latency of upstream calls, by upstream and status code"),
+		metric.WithUnit("s"))
+	if err != nil {
+		log.Printf("failed to create upstream duration histogram: %v", err)
+		return base
+	}
+
+	return &measuredTransport{upstream: upstream, duration: duration, base: base}
+}
+
+type measuredTransport struct {
+	upstream string
+	duration metric.Float64Histogram
+	base     http.RoundTripper
+}
+
+func (t *measuredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	attrs := []attribute.KeyValue{attribute.String("upstream", t.upstream)}
+	switch {
+	case err == nil:
+		attrs = append(attrs, semconv.HTTPResponseStatusCode(resp.StatusCode))
+	case errors.Is(err, context.DeadlineExceeded):
+		attrs = append(attrs, semconv.ErrorTypeKey.String("timeout"))
+	default:
+		attrs = append(attrs, semconv.ErrorTypeKey.String("transport"))
+	}
+	t.duration.Record(req.Context(), time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+
+	return resp, err
+}
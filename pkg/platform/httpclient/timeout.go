@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Timeout bounds each call of a client, from the request to the end of the
+// response body. Set applies to the calls that start afterwards, so the
+// limit can follow a reloaded config file. Zero means no limit.
+type Timeout struct {
+	limit atomic.Int64
+}
+
+func NewTimeout(limit time.Duration) *Timeout {
+	t := &Timeout{}
+	t.Set(limit)
+	return t
+}
+
+func (t *Timeout) Set(limit time.Duration) {
+	t.limit.Store(int64(limit))
+}
+
+func (t *Timeout) Get() time.Duration {
+	return time.Duration(t.limit.Load())
+}
+
+// Transport applies the limit in effect when each call starts.
+func (t *Timeout) Transport(base http.RoundTripper) http.RoundTripper {
+	return &timeoutTransport{timeout: t, base: base}
+}
+
+type timeoutTransport struct {
+	timeout *Timeout
+	base    http.RoundTripper
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limit := t.timeout.Get()
+	if limit <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), limit)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The deadline covers the body too, so it ends when the caller is done
+	// reading it.
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
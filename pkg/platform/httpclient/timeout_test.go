@@ -0,0 +1,65 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	// The headers come at once and the body after a pause, so the deadline
+	// must cover the read of the body.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		select {
+		case <-time.After(200 * time.Millisecond):
+			io.WriteString(w, "done")
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	timeout := NewTimeout(0)
+	client, err := New(Config{Timeout: timeout})
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func() error {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, err = io.ReadAll(resp.Body)
+		return err
+	}
+
+	tests := []struct {
+		name    string
+		limit   time.Duration
+		wantErr error
+	}{
+		{name: "no limit", limit: 0},
+		{name: "within the limit", limit: time.Second},
+		{name: "body past the limit", limit: 50 * time.Millisecond, wantErr: context.DeadlineExceeded},
+		{name: "limit raised again", limit: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeout.Set(tt.limit)
+			err := call()
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("call failed: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("call error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
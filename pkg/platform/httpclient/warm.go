@@ -0,0 +1,78 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// WarmConfig controls connection pre-warming of one upstream.
+type WarmConfig struct {
+	// Connections is how many connections are opened in parallel.
+	Connections int
+	// Timeout bounds each warm-up round.
+	Timeout time.Duration
+	// Interval re-warms the pool periodically so idle connections are not
+	// reaped between bursts of traffic; zero warms only at startup.
+	Interval time.Duration
+}
+
+// Warm opens cfg.Connections connections to baseURL in parallel, so TCP and
+// TLS handshakes happen before the first user request. Any HTTP response will
+// do: only the pooled connection matters. Failures are logged and never stop
+// the service, since a cold connection only costs latency.
+func Warm(ctx context.Context, client *http.Client, baseURL string, cfg WarmConfig) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	errs := make(chan error, cfg.Connections)
+	for i := 0; i < cfg.Connections; i++ {
+		go func() {
+			errs <- warmOne(ctx, client, baseURL)
+		}()
+	}
+
+	for i := 0; i < cfg.Connections; i++ {
+		if err := <-errs; err != nil {
+			log.Printf("failed to prewarm %s: %v", baseURL, err)
+		}
+	}
+}
+
+func warmOne(ctx context.Context, client *http.Client, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create warm-up request: %w", err)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	return nil
+}
+
+// KeepWarm re-warms baseURL every cfg.Interval until ctx is done.
+func KeepWarm(ctx context.Context, client *http.Client, baseURL string, cfg WarmConfig) {
+	if cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			Warm(ctx, client, baseURL, cfg)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package ipfilter
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+	"github.com/luis-olivetti/go-observability/pkg/platform/security"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type Config struct {
+	Allow          []string
+	Deny           []string
+	TrustedProxies []string
+}
+
+// Filter admits or rejects requests based on the client address. The deny
+// list wins over the allow list; an empty allow list admits everyone else.
+type Filter struct {
+	allow          []netip.Prefix
+	deny           []netip.Prefix
+	trustedProxies []netip.Prefix
+}
+
+func New(cfg Config) (*Filter, error) {
+	allow, err := parsePrefixes(cfg.Allow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowlist: %w", err)
+	}
+
+	deny, err := parsePrefixes(cfg.Deny)
+	if err != nil {
+		return nil, fmt.Errorf("invalid denylist: %w", err)
+	}
+
+	trustedProxies, err := parsePrefixes(cfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	return &Filter{allow: allow, deny: deny, trustedProxies: trustedProxies}, nil
+}
+
+// ParseList splits a comma-separated list of IPs/CIDRs as read from config.
+func ParseList(raw string) []string {
+	var entries []string
+	for _, entry := range strings.Split(raw, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+func (f *Filter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr, ok := f.ClientAddr(r)
+		if !ok {
+			security.Record(r, security.IPBlocked, "unparsable_address")
+			problem.Write(w, r, http.StatusForbidden, contracts.CodeForbidden, nil, nil)
+			return
+		}
+
+		if !f.Allowed(addr) {
+			security.Record(r, security.IPBlocked, f.reason(addr), attribute.String("client.address", addr.String()))
+			problem.Write(w, r, http.StatusForbidden, contracts.CodeForbidden, nil, nil)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (f *Filter) reason(addr netip.Addr) string {
+	if contains(f.deny, addr) {
+		return "denylisted"
+	}
+
+	return "not_allowlisted"
+}
+
+func (f *Filter) Allowed(addr netip.Addr) bool {
+	if contains(f.deny, addr) {
+		return false
+	}
+
+	return len(f.allow) == 0 || contains(f.allow, addr)
+}
+
+// ClientAddr returns the address of the client. X-Forwarded-For is only
+// honored when the direct peer is a trusted proxy, walking the chain from the
+// right and stopping at the first untrusted hop.
+func (f *Filter) ClientAddr(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	addr = addr.Unmap()
+
+	if !contains(f.trustedProxies, addr) {
+		return addr, true
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+
+		addr = hop.Unmap()
+		if !contains(f.trustedProxies, addr) {
+			break
+		}
+	}
+
+	return addr, true
+}
+
+func parsePrefixes(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, err
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return prefixes, nil
+}
+
+func contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,177 @@
+package ipfilter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientAddr(t *testing.T) {
+	filter, err := New(Config{TrustedProxies: []string{"10.0.0.0/8", "fd00::/8"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		forwardedFor   []string
+		want           string
+		wantUnparsable bool
+	}{
+		{
+			name:       "direct client",
+			remoteAddr: "203.0.113.7:51234",
+			want:       "203.0.113.7",
+		},
+		{
+			name:         "spoofed header from an untrusted client",
+			remoteAddr:   "203.0.113.7:51234",
+			forwardedFor: []string{"10.1.2.3"},
+			want:         "203.0.113.7",
+		},
+		{
+			name:         "one trusted proxy",
+			remoteAddr:   "10.0.0.1:443",
+			forwardedFor: []string{"198.51.100.9"},
+			want:         "198.51.100.9",
+		},
+		{
+			name:         "chain of trusted proxies",
+			remoteAddr:   "10.0.0.1:443",
+			forwardedFor: []string{"198.51.100.9, 10.0.0.3, 10.0.0.2"},
+			want:         "198.51.100.9",
+		},
+		{
+			name:         "chain split across headers",
+			remoteAddr:   "10.0.0.1:443",
+			forwardedFor: []string{"198.51.100.9", "10.0.0.2"},
+			want:         "198.51.100.9",
+		},
+		{
+			// The client may prepend anything; only the hop added by the
+			// first trusted proxy counts.
+			name:         "spoofed hop left of the client",
+			remoteAddr:   "10.0.0.1:443",
+			forwardedFor: []string{"10.9.9.9, 198.51.100.9, 10.0.0.2"},
+			want:         "198.51.100.9",
+		},
+		{
+			name:         "unparsable hop stops the walk",
+			remoteAddr:   "10.0.0.1:443",
+			forwardedFor: []string{"198.51.100.9, garbage"},
+			want:         "10.0.0.1",
+		},
+		{
+			name:       "trusted proxy without the header",
+			remoteAddr: "10.0.0.1:443",
+			want:       "10.0.0.1",
+		},
+		{
+			name:         "IPv6 trusted proxy",
+			remoteAddr:   "[fd00::1]:443",
+			forwardedFor: []string{"2001:db8::7"},
+			want:         "2001:db8::7",
+		},
+		{
+			name:       "IPv4-mapped IPv6 peer",
+			remoteAddr: "[::ffff:203.0.113.7]:51234",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "remote address without a port",
+			remoteAddr: "203.0.113.7",
+			want:       "203.0.113.7",
+		},
+		{
+			name:         "trusted proxy without a port",
+			remoteAddr:   "10.0.0.1",
+			forwardedFor: []string{"198.51.100.9"},
+			want:         "198.51.100.9",
+		},
+		{
+			name:           "unparsable remote address",
+			remoteAddr:     "pipe",
+			wantUnparsable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			addr, ok := filter.ClientAddr(req)
+			if tt.wantUnparsable {
+				if ok {
+					t.Fatalf("ClientAddr = %s, want no address", addr)
+				}
+				return
+			}
+			if !ok || addr.String() != tt.want {
+				t.Errorf("ClientAddr = %s (%v), want %s", addr, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	filter, err := New(Config{
+		Allow:          []string{"192.0.2.0/24", "2001:db8::/32"},
+		Deny:           []string{"192.0.2.66", "2001:db8:bad::/48"},
+		TrustedProxies: []string{"10.0.0.1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := filter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		wantStatus   int
+	}{
+		{name: "allowed IPv4", remoteAddr: "192.0.2.10:1234", wantStatus: http.StatusOK},
+		{name: "denied within the allowlist", remoteAddr: "192.0.2.66:1234", wantStatus: http.StatusForbidden},
+		{name: "outside the allowlist", remoteAddr: "198.51.100.9:1234", wantStatus: http.StatusForbidden},
+		{name: "allowed IPv6", remoteAddr: "[2001:db8:1::5]:1234", wantStatus: http.StatusOK},
+		{name: "denied IPv6 range", remoteAddr: "[2001:db8:bad::5]:1234", wantStatus: http.StatusForbidden},
+		{name: "IPv6 outside the allowlist", remoteAddr: "[2001:db9::5]:1234", wantStatus: http.StatusForbidden},
+		{name: "allowed behind the proxy", remoteAddr: "10.0.0.1:443", forwardedFor: "192.0.2.10", wantStatus: http.StatusOK},
+		{name: "proxy itself not allowlisted", remoteAddr: "10.0.0.1:443", wantStatus: http.StatusForbidden},
+		{name: "spoofed allowlisted address", remoteAddr: "198.51.100.9:1234", forwardedFor: "192.0.2.10", wantStatus: http.StatusForbidden},
+		{name: "unparsable address", remoteAddr: "pipe", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidEntries(t *testing.T) {
+	for _, cfg := range []Config{
+		{Allow: []string{"192.0.2.0/33"}},
+		{Deny: []string{"not-an-ip"}},
+		{TrustedProxies: []string{"2001:db8::/129"}},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v) succeeded, want an error", cfg)
+		}
+	}
+}
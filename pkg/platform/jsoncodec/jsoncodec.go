@@ -0,0 +1,49 @@
+package jsoncodec
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Encoder writes JSON values to a stream.
+type Encoder interface {
+	Encode(v any) error
+}
+
+// Decoder reads JSON values from a stream.
+type Decoder interface {
+	Decode(v any) error
+}
+
+// Codec is the JSON implementation used on the request hot path: response
+// writing and upstream response decoding.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Default is the codec used by the services. A faster library can replace it
+// from an init function in a file behind a build tag, without touching the
+// call sites.
+var Default Codec = Std{}
+
+// Std is backed by encoding/json.
+type Std struct{}
+
+func (Std) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (Std) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (Std) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+func (Std) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
@@ -0,0 +1,74 @@
+package jsoncodec
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+)
+
+// response is a /city-weather body with every optional section filled in.
+var response = contracts.TemperatureWithCity{
+	Celsius:    28.5,
+	Fahrenheit: 83.3,
+	Kelvin:     301.65,
+	CityName:   "Jaraguá do Sul",
+	Conditions: &contracts.Conditions{
+		Text:      "Parcialmente nublado",
+		Code:      1003,
+		Icon:      "//cdn.weatherapi.com/weather/64x64/day/116.png",
+		Humidity:  74,
+		WindKph:   11.2,
+		WindDir:   "SSE",
+		FeelsLike: 31.2,
+	},
+}
+
+func TestDefaultRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Default.NewEncoder(&buf).Encode(response); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	var decoded contracts.TemperatureWithCity
+	if err := Default.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, response) {
+		t.Errorf("round trip = %+v, want %+v", decoded, response)
+	}
+}
+
+// The benchmarks measure Default, so running them with and without a codec
+// build tag compares the libraries on the payloads of the hot path.
+
+func BenchmarkEncode(b *testing.B) {
+	encoded, _ := Default.Marshal(response)
+	b.SetBytes(int64(len(encoded)))
+	b.ReportAllocs()
+
+	encoder := Default.NewEncoder(io.Discard)
+	for i := 0; i < b.N; i++ {
+		if err := encoder.Encode(response); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	encoded, err := Default.Marshal(response)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(encoded)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		var decoded contracts.TemperatureWithCity
+		if err := Default.NewDecoder(bytes.NewReader(encoded)).Decode(&decoded); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
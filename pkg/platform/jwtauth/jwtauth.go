@@ -0,0 +1,431 @@
+// Package jwtauth authenticates requests by bearer tokens signed with the
+// keys of a JWKS endpoint.
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+	"github.com/luis-olivetti/go-observability/pkg/platform/httpclient"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+	"github.com/luis-olivetti/go-observability/pkg/platform/security"
+)
+
+var (
+	errMalformedToken   = errors.New("malformed token")
+	errUnknownKey       = errors.New("unknown signing key")
+	errInvalidSignature = errors.New("invalid signature")
+	errInvalidClaims    = errors.New("invalid token claims")
+)
+
+type claimsContextKey struct{}
+
+// Claims are the identity claims extracted from a validated token.
+type Claims struct {
+	Subject string
+	Tenant  string
+	Roles   []string
+}
+
+// ClaimsFromContext returns the claims of the token that authenticated the request.
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(Claims)
+	return claims, ok
+}
+
+type Config struct {
+	JWKSURL     string
+	Issuer      string
+	Audience    string
+	ClockSkew   time.Duration
+	TenantClaim string
+	RolesClaim  string
+	RefreshTTL  time.Duration
+	// Clock defaults to the wall clock when nil.
+	Clock clock.Clock
+}
+
+type Authenticator struct {
+	cfg    Config
+	client *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+func New(cfg Config) *Authenticator {
+	if cfg.TenantClaim == "" {
+		cfg.TenantClaim = "tenant"
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = "roles"
+	}
+	if cfg.RefreshTTL == 0 {
+		cfg.RefreshTTL = 5 * time.Minute
+	}
+
+	return &Authenticator{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 5 * time.Second},
+		keys:   map[string]crypto.PublicKey{},
+	}
+}
+
+// Middleware rejects requests without a valid "Authorization: Bearer" token.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			security.Record(r, security.AuthFailure, "missing_token")
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			problem.Write(w, r, http.StatusUnauthorized, contracts.CodeUnauthorized, nil, nil)
+			return
+		}
+
+		claims, err := a.Validate(r.Context(), token)
+		if err != nil {
+			security.Record(r, security.AuthFailure, tokenFailureReason(err))
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			problem.Write(w, r, http.StatusUnauthorized, contracts.CodeUnauthorized, nil, nil)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsContextKey{}, claims)))
+	})
+}
+
+func tokenFailureReason(err error) string {
+	switch {
+	case errors.Is(err, errMalformedToken):
+		return "malformed_token"
+	case errors.Is(err, errUnknownKey):
+		return "unknown_signing_key"
+	case errors.Is(err, errInvalidClaims):
+		return "invalid_claims"
+	default:
+		return "invalid_signature"
+	}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// payload is the claim set of a token, decoded once: the registered claims
+// are read from it, and so are the configurable tenant and roles claims.
+type payload map[string]interface{}
+
+func (p payload) str(name string) string {
+	value, _ := p[name].(string)
+	return value
+}
+
+// time returns the NumericDate claim name, reporting whether it is set.
+func (p payload) time(name string) (time.Time, bool) {
+	seconds, ok := p[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// Validate checks the token signature against the JWKS and its registered
+// claims against the configuration.
+func (a *Authenticator) Validate(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, errMalformedToken
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, errMalformedToken
+	}
+
+	key, err := a.key(ctx, header.Kid)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return Claims{}, err
+	}
+
+	var claims payload
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Claims{}, err
+	}
+
+	if err := a.validateClaims(claims); err != nil {
+		return Claims{}, err
+	}
+
+	return Claims{
+		Subject: claims.str("sub"),
+		Tenant:  claims.str(a.cfg.TenantClaim),
+		Roles:   stringList(claims[a.cfg.RolesClaim]),
+	}, nil
+}
+
+func (a *Authenticator) validateClaims(claims payload) error {
+	now := clock.Or(a.cfg.Clock).Now()
+
+	expiresAt, ok := claims.time("exp")
+	if !ok || now.After(expiresAt.Add(a.cfg.ClockSkew)) {
+		return fmt.Errorf("%w: token expired", errInvalidClaims)
+	}
+
+	if notBefore, ok := claims.time("nbf"); ok && now.Add(a.cfg.ClockSkew).Before(notBefore) {
+		return fmt.Errorf("%w: token not yet valid", errInvalidClaims)
+	}
+
+	if a.cfg.Issuer != "" && claims.str("iss") != a.cfg.Issuer {
+		return fmt.Errorf("%w: unexpected issuer", errInvalidClaims)
+	}
+
+	if a.cfg.Audience != "" && !hasAudience(claims["aud"], a.cfg.Audience) {
+		return fmt.Errorf("%w: unexpected audience", errInvalidClaims)
+	}
+
+	return nil
+}
+
+// hasAudience accepts the aud claim as a single string or as an array.
+func hasAudience(claim interface{}, expected string) bool {
+	switch v := claim.(type) {
+	case string:
+		return v == expected
+	case []interface{}:
+		for _, audience := range v {
+			if audience == expected {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: unsupported signing algorithm %s", errInvalidSignature, alg)
+	}
+
+	hasher := hash.New()
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("%w: algorithm %s does not match RSA key", errInvalidSignature, alg)
+		}
+		if err := rsa.VerifyPKCS1v15(pub, hash, digest, signature); err != nil {
+			return errInvalidSignature
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			return fmt.Errorf("%w: algorithm %s does not match EC key", errInvalidSignature, alg)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("%w: length %d", errInvalidSignature, len(signature))
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errInvalidSignature
+		}
+		return nil
+	default:
+		return errUnknownKey
+	}
+}
+
+// key returns the public key for kid, refreshing the JWKS when it is stale or
+// when an unknown kid shows up (keys were rotated).
+func (a *Authenticator) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	a.mu.RLock()
+	key, ok := a.keys[kid]
+	sinceFetch := clock.Or(a.cfg.Clock).Now().Sub(a.fetchedAt)
+	stale := sinceFetch > a.cfg.RefreshTTL
+	recentlyFetched := sinceFetch < 30*time.Second
+	a.mu.RUnlock()
+
+	if ok && !stale {
+		return key, nil
+	}
+
+	if !ok && recentlyFetched {
+		return nil, errUnknownKey
+	}
+
+	if err := a.refresh(ctx); err != nil {
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if key, ok := a.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, errUnknownKey
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// maxJWKSResponse caps a JWKS document; even large key sets are a few KiB.
+const maxJWKSResponse = 1 << 20
+
+func (a *Authenticator) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", a.cfg.JWKSURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request (jwks): %w", err)
+	}
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make HTTP request (jwks): %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code (jwks): %d", res.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(httpclient.LimitBody(res.Body, maxJWKSResponse)).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode response (jwks): %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	a.mu.Lock()
+	a.keys = keys
+	a.fetchedAt = clock.Or(a.cfg.Clock).Now()
+	a.mu.Unlock()
+
+	return nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errMalformedToken
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return errMalformedToken
+	}
+
+	return nil
+}
+
+// stringList accepts a claim encoded either as a JSON array of strings or as
+// a space-separated string (the OAuth2 "scope" convention).
+func stringList(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,374 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+)
+
+var now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// jwks serves a key set that the test can rotate, counting the fetches.
+type jwks struct {
+	mu      sync.Mutex
+	keys    []jwk
+	fetches atomic.Int32
+}
+
+func (s *jwks) set(keys ...jwk) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwks) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.fetches.Add(1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	json.NewEncoder(w).Encode(map[string][]jwk{"keys": s.keys})
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) jwk {
+	return jwk{
+		Kty: "RSA",
+		Kid: kid,
+		N:   b64(key.N.Bytes()),
+		E:   b64(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) jwk {
+	return jwk{Kty: "EC", Kid: kid, Crv: "P-256", X: b64(key.X.Bytes()), Y: b64(key.Y.Bytes())}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// token builds a JWT for header and claims; sign returns the signature of
+// the signing input.
+func token(t *testing.T, header map[string]string, claims map[string]interface{}, sign func(signed string) []byte) string {
+	t.Helper()
+
+	h, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := b64(h) + "." + b64(c)
+	return signed + "." + b64(sign(signed))
+}
+
+func signRS256(key *rsa.PrivateKey) func(string) []byte {
+	return func(signed string) []byte {
+		digest := sha256.Sum256([]byte(signed))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			panic(err)
+		}
+		return signature
+	}
+}
+
+func signES256(key *ecdsa.PrivateKey) func(string) []byte {
+	return func(signed string) []byte {
+		digest := sha256.Sum256([]byte(signed))
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			panic(err)
+		}
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signature
+	}
+}
+
+func signHS256(secret []byte) func(string) []byte {
+	return func(signed string) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signed))
+		return mac.Sum(nil)
+	}
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":    "https://issuer.example",
+		"aud":    []string{"other", "service-a"},
+		"sub":    "user-1",
+		"tenant": "acme",
+		"roles":  "viewer operator",
+		"exp":    now.Add(time.Minute).Unix(),
+		"nbf":    now.Add(-time.Minute).Unix(),
+	}
+}
+
+func withClaim(name string, value interface{}) map[string]interface{} {
+	claims := validClaims()
+	if value == nil {
+		delete(claims, name)
+	} else {
+		claims[name] = value
+	}
+	return claims
+}
+
+func newAuthenticator(t *testing.T, keys *jwks, fake *clock.Fake) *Authenticator {
+	t.Helper()
+
+	server := httptest.NewServer(keys)
+	t.Cleanup(server.Close)
+
+	return New(Config{
+		JWKSURL:   server.URL,
+		Issuer:    "https://issuer.example",
+		Audience:  "service-a",
+		ClockSkew: 30 * time.Second,
+		Clock:     fake,
+	})
+}
+
+func TestValidate(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := &jwks{}
+	keys.set(rsaJWK("rsa", rsaKey), ecJWK("ec", ecKey))
+	a := newAuthenticator(t, keys, clock.NewFake(now))
+
+	rs256 := map[string]string{"alg": "RS256", "kid": "rsa"}
+	es256 := map[string]string{"alg": "ES256", "kid": "ec"}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    Claims
+		wantErr error
+	}{
+		{
+			name:  "RS256",
+			token: token(t, rs256, validClaims(), signRS256(rsaKey)),
+			want:  Claims{Subject: "user-1", Tenant: "acme", Roles: []string{"viewer", "operator"}},
+		},
+		{
+			name:  "ES256 with a single audience and role list",
+			token: token(t, es256, withClaim("aud", "service-a"), signES256(ecKey)),
+			want:  Claims{Subject: "user-1", Tenant: "acme", Roles: []string{"viewer", "operator"}},
+		},
+		{
+			name:  "expired within the skew",
+			token: token(t, rs256, withClaim("exp", now.Add(-20*time.Second).Unix()), signRS256(rsaKey)),
+			want:  Claims{Subject: "user-1", Tenant: "acme", Roles: []string{"viewer", "operator"}},
+		},
+		{
+			name:    "expired",
+			token:   token(t, rs256, withClaim("exp", now.Add(-time.Minute).Unix()), signRS256(rsaKey)),
+			wantErr: errInvalidClaims,
+		},
+		{
+			name:    "without exp",
+			token:   token(t, rs256, withClaim("exp", nil), signRS256(rsaKey)),
+			wantErr: errInvalidClaims,
+		},
+		{
+			name:    "not yet valid",
+			token:   token(t, rs256, withClaim("nbf", now.Add(time.Minute).Unix()), signRS256(rsaKey)),
+			wantErr: errInvalidClaims,
+		},
+		{
+			name:    "wrong issuer",
+			token:   token(t, rs256, withClaim("iss", "https://attacker.example"), signRS256(rsaKey)),
+			wantErr: errInvalidClaims,
+		},
+		{
+			name:    "wrong audience",
+			token:   token(t, rs256, withClaim("aud", []string{"service-b"}), signRS256(rsaKey)),
+			wantErr: errInvalidClaims,
+		},
+		{
+			name:    "without audience",
+			token:   token(t, rs256, withClaim("aud", nil), signRS256(rsaKey)),
+			wantErr: errInvalidClaims,
+		},
+		{
+			name: "alg none",
+			token: token(t, map[string]string{"alg": "none", "kid": "rsa"}, validClaims(), func(string) []byte {
+				return nil
+			}),
+			wantErr: errInvalidSignature,
+		},
+		{
+			// The classic confusion: published key material used as an HMAC secret.
+			name:    "HS256 under an EC key",
+			token:   token(t, map[string]string{"alg": "HS256", "kid": "ec"}, validClaims(), signHS256([]byte(ecJWK("ec", ecKey).X))),
+			wantErr: errInvalidSignature,
+		},
+		{
+			name:    "ES256 under an RSA key",
+			token:   token(t, map[string]string{"alg": "ES256", "kid": "rsa"}, validClaims(), signES256(ecKey)),
+			wantErr: errInvalidSignature,
+		},
+		{
+			name:    "signed by another key",
+			token:   token(t, es256, validClaims(), signES256(mustECKey(t))),
+			wantErr: errInvalidSignature,
+		},
+		{
+			name:    "unknown kid",
+			token:   token(t, map[string]string{"alg": "RS256", "kid": "gone"}, validClaims(), signRS256(rsaKey)),
+			wantErr: errUnknownKey,
+		},
+		{
+			name:    "malformed",
+			token:   "not.a.jwt",
+			wantErr: errMalformedToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := a.Validate(context.Background(), tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Validate error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+			if !reflect.DeepEqual(claims, tt.want) {
+				t.Errorf("claims = %+v, want %+v", claims, tt.want)
+			}
+		})
+	}
+}
+
+func mustECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestValidateRefreshesRotatedKeys(t *testing.T) {
+	oldKey, newKey := mustECKey(t), mustECKey(t)
+	keys := &jwks{}
+	keys.set(ecJWK("old", oldKey))
+	fake := clock.NewFake(now)
+	a := newAuthenticator(t, keys, fake)
+
+	validate := func(kid string, key *ecdsa.PrivateKey) error {
+		claims := withClaim("exp", fake.Now().Add(time.Hour).Unix())
+		_, err := a.Validate(context.Background(), token(t, map[string]string{"alg": "ES256", "kid": kid}, claims, signES256(key)))
+		return err
+	}
+
+	if err := validate("old", oldKey); err != nil {
+		t.Fatalf("old key rejected: %v", err)
+	}
+
+	keys.set(ecJWK("new", newKey))
+
+	// A kid the set does not hold right after a fetch is not worth another.
+	if err := validate("new", newKey); !errors.Is(err, errUnknownKey) {
+		t.Fatalf("new key right after a fetch: error = %v, want %v", err, errUnknownKey)
+	}
+	if got := keys.fetches.Load(); got != 1 {
+		t.Fatalf("fetches = %d, want 1", got)
+	}
+
+	// Past that, an unknown kid refetches the set.
+	fake.Advance(time.Minute)
+	if err := validate("new", newKey); err != nil {
+		t.Fatalf("new key after rotation rejected: %v", err)
+	}
+	if got := keys.fetches.Load(); got != 2 {
+		t.Fatalf("fetches = %d, want 2", got)
+	}
+
+	// Once the set is stale, a known kid is dropped with the next refresh.
+	fake.Advance(10 * time.Minute)
+	if err := validate("old", oldKey); !errors.Is(err, errUnknownKey) {
+		t.Fatalf("removed key: error = %v, want %v", err, errUnknownKey)
+	}
+	if got := keys.fetches.Load(); got != 3 {
+		t.Fatalf("fetches = %d, want 3", got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	key := mustECKey(t)
+	keys := &jwks{}
+	keys.set(ecJWK("ec", key))
+	a := newAuthenticator(t, keys, clock.NewFake(now))
+
+	handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := ClaimsFromContext(r.Context())
+		if !ok || claims.Subject != "user-1" {
+			t.Errorf("claims = %+v, %v; want the token's", claims, ok)
+		}
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantChallenge string
+	}{
+		{name: "valid", authorization: "Bearer " + token(t, map[string]string{"alg": "ES256", "kid": "ec"}, validClaims(), signES256(key)), wantStatus: http.StatusOK},
+		{name: "missing", wantStatus: http.StatusUnauthorized, wantChallenge: "Bearer"},
+		{name: "not bearer", authorization: "Basic dXNlcjpwYXNz", wantStatus: http.StatusUnauthorized, wantChallenge: "Bearer"},
+		{name: "invalid", authorization: "Bearer not.a.jwt", wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer error="invalid_token"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+		})
+	}
+}
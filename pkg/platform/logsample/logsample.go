@@ -0,0 +1,128 @@
+package logsample
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"regexp"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// maxKeys bounds the distinct lines tracked in a window; past it lines are
+// logged unthrottled rather than growing the map without limit.
+const maxKeys = 1000
+
+// Config sets how many identical lines are logged per window.
+type Config struct {
+	// Burst is how many copies of a line are logged in each window; zero
+	// disables sampling and logs every line.
+	Burst int
+	// Window is counted from the first copy of a line.
+	Window time.Duration
+}
+
+// Limiter logs the first Burst copies of each line in a window and counts
+// the rest; when the window closes it logs one summary with the number of
+// copies suppressed. An outage of an upstream then costs a handful of lines
+// per minute instead of one per request.
+type Limiter struct {
+	cfg        Config
+	suppressed metric.Int64Counter
+
+	mu    sync.Mutex
+	lines map[string]*line
+}
+
+type line struct {
+	// first is the first copy, quoted in the summary.
+	first      string
+	logged     int
+	suppressed int
+}
+
+// Lines that differ only in numbers or URL query strings, such as the same
+// upstream failure for different zipcodes, are repeats of each other.
+var (
+	queryPattern  = regexp.MustCompile(`\?[^\s"]*`)
+	digitsPattern = regexp.MustCompile(`[0-9]+`)
+)
+
+func groupKey(msg string) string {
+	return digitsPattern.ReplaceAllString(queryPattern.ReplaceAllString(msg, "?"), "#")
+}
+
+func New(cfg Config) *Limiter {
+	meter := otel.Meter("microservice-meter")
+
+	suppressed, err := meter.Int64Counter("log.suppressed",
+		metric.WithDescription("Log lines dropped as repeats of a line already logged in the window"))
+	if err != nil {
+		log.Printf("failed to create suppressed log lines counter: %v", err)
+	}
+
+	return &Limiter{cfg: cfg, suppressed: suppressed, lines: make(map[string]*line)}
+}
+
+// Default is the limiter used by the request path. It is replaced from main
+// with the configured one before serving.
+var Default = New(Config{Burst: 10, Window: time.Minute})
+
+// Errorf logs through Default.
+func Errorf(ctx context.Context, format string, args ...any) {
+	Default.Errorf(ctx, format, args...)
+}
+
+// Errorf logs the formatted line at error level, with the trace of ctx,
+// unless it already reached Burst copies in the current window.
+func (l *Limiter) Errorf(ctx context.Context, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if l.cfg.Burst <= 0 {
+		slog.ErrorContext(ctx, msg)
+		return
+	}
+
+	key := groupKey(msg)
+
+	l.mu.Lock()
+	state, ok := l.lines[key]
+	switch {
+	case !ok && len(l.lines) >= maxKeys:
+		l.mu.Unlock()
+		slog.ErrorContext(ctx, msg)
+		return
+	case !ok:
+		state = &line{first: msg}
+		l.lines[key] = state
+		time.AfterFunc(l.cfg.Window, func() { l.expire(key) })
+	}
+
+	if state.logged >= l.cfg.Burst {
+		state.suppressed++
+		l.mu.Unlock()
+		if l.suppressed != nil {
+			l.suppressed.Add(ctx, 1)
+		}
+		return
+	}
+	state.logged++
+	l.mu.Unlock()
+
+	slog.ErrorContext(ctx, msg)
+}
+
+// expire closes the window of key, summarizing the copies it dropped.
+func (l *Limiter) expire(key string) {
+	l.mu.Lock()
+	state := l.lines[key]
+	delete(l.lines, key)
+	l.mu.Unlock()
+
+	if state != nil && state.suppressed > 0 {
+		slog.Warn(fmt.Sprintf("suppressed %d repeats in the last %s of: %s", state.suppressed, l.cfg.Window, state.first))
+	}
+}
@@ -0,0 +1,44 @@
+package messages
+
+import "github.com/luis-olivetti/go-observability/pkg/contracts"
+
+// builtin is the catalog shipped with the service. The English texts of the
+// zipcode codes are part of the API contract and must not change.
+var builtin = Texts{
+	"en": {
+		contracts.CodeBadRequest:         "malformed request",
+		contracts.CodeValidationFailed:   "invalid request",
+		contracts.CodePayloadTooLarge:    "request body too large",
+		contracts.CodeZipcodeInvalid:     "invalid zipcode",
+		contracts.CodeZipcodeNotFound:    "cannot find zipcode",
+		contracts.CodeNotFound:           "no such route",
+		contracts.CodeMethodNotAllowed:   "method not allowed on this route",
+		contracts.CodeUnauthorized:       "missing or invalid credentials",
+		contracts.CodeForbidden:          "access denied",
+		contracts.CodeQuotaExceeded:      "{{.period}} quota exceeded",
+		contracts.CodeClientBlocked:      "too many invalid requests",
+		contracts.CodeUpstreamError:      "failed to fetch weather data",
+		contracts.CodeUpstreamTimeout:    "timed out fetching weather data",
+		contracts.CodeProviderQuota:      "upstream provider quota exhausted",
+		contracts.CodeCallBudgetExceeded: "request needed too many upstream calls",
+		contracts.CodeInternal:           "internal error",
+	},
+	"pt-BR": {
+		contracts.CodeBadRequest:         "requisição malformada",
+		contracts.CodeValidationFailed:   "requisição inválida",
+		contracts.CodePayloadTooLarge:    "corpo da requisição grande demais",
+		contracts.CodeZipcodeInvalid:     "CEP inválido",
+		contracts.CodeZipcodeNotFound:    "CEP não encontrado",
+		contracts.CodeNotFound:           "rota inexistente",
+		contracts.CodeMethodNotAllowed:   "método não permitido nesta rota",
+		contracts.CodeUnauthorized:       "credenciais ausentes ou inválidas",
+		contracts.CodeForbidden:          "acesso negado",
+		contracts.CodeQuotaExceeded:      `cota {{if eq .period "daily"}}diária{{else}}mensal{{end}} esgotada`,
+		contracts.CodeClientBlocked:      "excesso de requisições inválidas",
+		contracts.CodeUpstreamError:      "falha ao obter os dados do clima",
+		contracts.CodeUpstreamTimeout:    "tempo esgotado ao obter os dados do clima",
+		contracts.CodeProviderQuota:      "cota do provedor de clima esgotada",
+		contracts.CodeCallBudgetExceeded: "a requisição precisou de chamadas externas demais",
+		contracts.CodeInternal:           "erro interno",
+	},
+}
@@ -0,0 +1,172 @@
+// Package messages holds the text clients read for every error code. Problem
+// documents take their detail from here, looked up by code and locale, and
+// never from the internal error: rewording or translating a message does not
+// change how the API behaves, and internal detail stays on spans and logs.
+package messages
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+)
+
+// Fallback is the locale answered when the caller accepts none of the
+// catalog's, and the one a missing translation falls back to.
+const Fallback = "en"
+
+// Params fills the placeholders of a message template, e.g. {{.period}}.
+type Params map[string]any
+
+// Texts are message templates keyed by locale and error code.
+type Texts map[string]map[contracts.ErrorCode]string
+
+// Catalog renders the message of an error code in a locale.
+type Catalog struct {
+	locales   []string
+	templates map[string]map[contracts.ErrorCode]*template.Template
+}
+
+// Default is the catalog problem documents are written with.
+var Default = NewCatalog(nil)
+
+// NewCatalog returns the built-in catalog with overrides laid over it:
+// overrides replace single messages and may add whole locales. Overrides must
+// have been checked with LoadFile.
+func NewCatalog(overrides Texts) *Catalog {
+	c := &Catalog{templates: make(map[string]map[contracts.ErrorCode]*template.Template)}
+	for _, texts := range []Texts{builtin, overrides} {
+		for locale, messages := range texts {
+			if c.templates[locale] == nil {
+				c.templates[locale] = make(map[contracts.ErrorCode]*template.Template)
+				c.locales = append(c.locales, locale)
+			}
+			for code, text := range messages {
+				c.templates[locale][code] = template.Must(parse(code, text))
+			}
+		}
+	}
+	sort.Strings(c.locales)
+
+	return c
+}
+
+// LoadFile reads catalog overrides from a JSON file shaped as
+// {"<locale>": {"<ERROR_CODE>": "<template>"}}. An empty path loads nothing.
+func LoadFile(path string) (Texts, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message catalog: %w", err)
+	}
+
+	var texts Texts
+	if err := json.Unmarshal(data, &texts); err != nil {
+		return nil, fmt.Errorf("failed to parse message catalog: %w", err)
+	}
+
+	for locale, messages := range texts {
+		for code, text := range messages {
+			if _, err := parse(code, text); err != nil {
+				return nil, fmt.Errorf("invalid message %s for %s: %w", code, locale, err)
+			}
+		}
+	}
+
+	return texts, nil
+}
+
+func parse(code contracts.ErrorCode, text string) (*template.Template, error) {
+	return template.New(string(code)).Option("missingkey=zero").Parse(text)
+}
+
+// Locale picks the catalog locale that best matches an Accept-Language
+// header: tags are tried by decreasing weight, first as they are and then by
+// their primary language, so "pt" is answered in "pt-BR".
+func (c *Catalog) Locale(acceptLanguage string) string {
+	for _, tag := range preferred(acceptLanguage) {
+		if tag == "*" {
+			break
+		}
+		for _, locale := range c.locales {
+			if strings.EqualFold(locale, tag) {
+				return locale
+			}
+		}
+		base, _, _ := strings.Cut(tag, "-")
+		for _, locale := range c.locales {
+			localeBase, _, _ := strings.Cut(locale, "-")
+			if strings.EqualFold(localeBase, base) {
+				return locale
+			}
+		}
+	}
+
+	return Fallback
+}
+
+// preferred lists the language tags of an Accept-Language header by
+// decreasing weight, leaving out the ones weighted zero.
+func preferred(acceptLanguage string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	out := make([]string, len(tags))
+	for i, t := range tags {
+		out[i] = t.tag
+	}
+	return out
+}
+
+// Message renders the message of code in locale, falling back to the
+// Fallback locale and, for codes the catalog does not know, to the code
+// itself.
+func (c *Catalog) Message(locale string, code contracts.ErrorCode, params Params) string {
+	tmpl, ok := c.templates[locale][code]
+	if !ok {
+		tmpl, ok = c.templates[Fallback][code]
+	}
+	if !ok {
+		return string(code)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, params); err != nil {
+		return string(code)
+	}
+	return b.String()
+}
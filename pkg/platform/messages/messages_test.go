@@ -0,0 +1,120 @@
+package messages
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+)
+
+func TestLocale(t *testing.T) {
+	catalog := NewCatalog(Texts{"es": {contracts.CodeInternal: "error interno"}})
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{acceptLanguage: "", want: Fallback},
+		{acceptLanguage: "pt-BR", want: "pt-BR"},
+		{acceptLanguage: "PT-br", want: "pt-BR"},
+		{acceptLanguage: "pt", want: "pt-BR"},
+		{acceptLanguage: "pt-PT", want: "pt-BR"},
+		{acceptLanguage: "fr-CA, es;q=0.5", want: "es"},
+		{acceptLanguage: "en;q=0.4, pt-BR;q=0.9", want: "pt-BR"},
+		{acceptLanguage: "pt-BR;q=0, en", want: "en"},
+		{acceptLanguage: "pt-BR;q=abc, es", want: "es"},
+		{acceptLanguage: "fr", want: Fallback},
+		{acceptLanguage: "*, pt-BR;q=0.5", want: Fallback},
+	}
+
+	for _, tt := range tests {
+		if got := catalog.Locale(tt.acceptLanguage); got != tt.want {
+			t.Errorf("Locale(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	catalog := NewCatalog(Texts{
+		"en":    {contracts.CodeInternal: "something broke"},
+		"es":    {contracts.CodeZipcodeInvalid: "código postal inválido"},
+		"pt-BR": {contracts.CodeForbidden: "{{.missing.field}}"},
+	})
+
+	tests := []struct {
+		name   string
+		locale string
+		code   contracts.ErrorCode
+		params Params
+		want   string
+	}{
+		{name: "built-in", locale: "pt-BR", code: contracts.CodeZipcodeInvalid, want: "CEP inválido"},
+		{name: "params", locale: "pt-BR", code: contracts.CodeQuotaExceeded, params: Params{"period": "daily"}, want: "cota diária esgotada"},
+		{name: "missing param", locale: "en", code: contracts.CodeQuotaExceeded, want: "<no value> quota exceeded"},
+		{name: "override", locale: "en", code: contracts.CodeInternal, want: "something broke"},
+		{name: "added locale", locale: "es", code: contracts.CodeZipcodeInvalid, want: "código postal inválido"},
+		{name: "missing translation", locale: "es", code: contracts.CodeForbidden, want: "access denied"},
+		{name: "unknown locale", locale: "fr", code: contracts.CodeZipcodeNotFound, want: "cannot find zipcode"},
+		{name: "unknown code", locale: "en", code: "NO_SUCH_CODE", want: "NO_SUCH_CODE"},
+		{name: "template failure", locale: "pt-BR", code: contracts.CodeForbidden, params: Params{"missing": "text"}, want: string(contracts.CodeForbidden)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := catalog.Message(tt.locale, tt.code, tt.params); got != tt.want {
+				t.Errorf("Message = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuiltinLocalesComplete(t *testing.T) {
+	for locale, messages := range builtin {
+		for code := range builtin[Fallback] {
+			if _, ok := messages[code]; !ok {
+				t.Errorf("%s has no message for %s", locale, code)
+			}
+		}
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    Texts
+		wantErr bool
+	}{
+		{name: "no file", path: ""},
+		{name: "valid", path: write("valid.json", `{"es": {"ZIPCODE_INVALID": "código postal inválido"}}`), want: Texts{"es": {contracts.CodeZipcodeInvalid: "código postal inválido"}}},
+		{name: "missing file", path: filepath.Join(dir, "missing.json"), wantErr: true},
+		{name: "malformed JSON", path: write("malformed.json", `{"es": `), wantErr: true},
+		{name: "invalid template", path: write("template.json", `{"es": {"INTERNAL": "{{.period"}}`), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadFile(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadFile error = %v, want error %v", err, tt.wantErr)
+			}
+			for locale, messages := range tt.want {
+				for code, text := range messages {
+					if got[locale][code] != text {
+						t.Errorf("%s %s = %q, want %q", locale, code, got[locale][code], text)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package metrictesting
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// Reader keeps recorded measurements in memory until they are collected, so
+// tests can check that counters and histograms are actually recorded with the
+// right attributes.
+type Reader struct {
+	reader   *sdkmetric.ManualReader
+	provider *sdkmetric.MeterProvider
+}
+
+// Install makes a MeterProvider backed by a ManualReader the global one.
+// Instruments obtained through otel.Meter, even before Install, record into it.
+func Install() *Reader {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	otel.SetMeterProvider(provider)
+
+	return &Reader{reader: reader, provider: provider}
+}
+
+func (r *Reader) Shutdown(ctx context.Context) error {
+	return r.provider.Shutdown(ctx)
+}
+
+// Collect returns everything recorded so far. Sums are cumulative, so each
+// call sees the totals since Install.
+func (r *Reader) Collect(ctx context.Context) (metricdata.ResourceMetrics, error) {
+	var rm metricdata.ResourceMetrics
+	err := r.reader.Collect(ctx, &rm)
+	return rm, err
+}
+
+// Sum adds up the data points of the counter named name whose attributes
+// include all of attrs. The second result is false when no such counter was
+// recorded.
+func Sum(rm metricdata.ResourceMetrics, name string, attrs ...attribute.KeyValue) (float64, bool) {
+	var total float64
+	found := false
+	for _, m := range metrics(rm, name) {
+		switch data := m.Data.(type) {
+		case metricdata.Sum[int64]:
+			for _, dp := range data.DataPoints {
+				if matches(dp.Attributes, attrs) {
+					total += float64(dp.Value)
+					found = true
+				}
+			}
+		case metricdata.Sum[float64]:
+			for _, dp := range data.DataPoints {
+				if matches(dp.Attributes, attrs) {
+					total += dp.Value
+					found = true
+				}
+			}
+		}
+	}
+	return total, found
+}
+
+// HistogramCount returns how many measurements the histogram named name
+// received with attributes including all of attrs.
+func HistogramCount(rm metricdata.ResourceMetrics, name string, attrs ...attribute.KeyValue) (uint64, bool) {
+	var count uint64
+	found := false
+	for _, m := range metrics(rm, name) {
+		switch data := m.Data.(type) {
+		case metricdata.Histogram[int64]:
+			for _, dp := range data.DataPoints {
+				if matches(dp.Attributes, attrs) {
+					count += dp.Count
+					found = true
+				}
+			}
+		case metricdata.Histogram[float64]:
+			for _, dp := range data.DataPoints {
+				if matches(dp.Attributes, attrs) {
+					count += dp.Count
+					found = true
+				}
+			}
+		}
+	}
+	return count, found
+}
+
+// AssertSum checks the total of a counter for data points including attrs.
+func (r *Reader) AssertSum(tb testing.TB, name string, want float64, attrs ...attribute.KeyValue) {
+	tb.Helper()
+
+	got, ok := Sum(r.collect(tb), name, attrs...)
+	if !ok {
+		tb.Errorf("counter %q has no data points with %v", name, attrs)
+		return
+	}
+	if got != want {
+		tb.Errorf("counter %q with %v = %v, want %v", name, attrs, got, want)
+	}
+}
+
+// AssertHistogramCount checks how many measurements a histogram received for
+// data points including attrs.
+func (r *Reader) AssertHistogramCount(tb testing.TB, name string, want uint64, attrs ...attribute.KeyValue) {
+	tb.Helper()
+
+	got, ok := HistogramCount(r.collect(tb), name, attrs...)
+	if !ok {
+		tb.Errorf("histogram %q has no data points with %v", name, attrs)
+		return
+	}
+	if got != want {
+		tb.Errorf("histogram %q with %v recorded %d measurements, want %d", name, attrs, got, want)
+	}
+}
+
+// AssertNotRecorded checks that nothing was recorded for name.
+func (r *Reader) AssertNotRecorded(tb testing.TB, name string) {
+	tb.Helper()
+
+	if found := metrics(r.collect(tb), name); len(found) > 0 {
+		tb.Errorf("metric %q was recorded", name)
+	}
+}
+
+func (r *Reader) collect(tb testing.TB) metricdata.ResourceMetrics {
+	tb.Helper()
+
+	rm, err := r.Collect(context.Background())
+	if err != nil {
+		tb.Fatalf("failed to collect metrics: %v", err)
+	}
+	return rm
+}
+
+func metrics(rm metricdata.ResourceMetrics, name string) []metricdata.Metrics {
+	var found []metricdata.Metrics
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name == name {
+				found = append(found, m)
+			}
+		}
+	}
+	return found
+}
+
+func matches(set attribute.Set, attrs []attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		value, ok := set.Value(attr.Key)
+		if !ok || value != attr.Value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,203 @@
+package otlptest
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+
+	collectorlogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	collectormetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// Collector is an in-process OTLP/gRPC receiver. It keeps every export
+// request it receives, together with the gRPC metadata that came with it, so
+// tests can check exporter configuration (endpoint, headers, TLS) over the
+// real wire protocol.
+type Collector struct {
+	// Endpoint is the host:port the collector listens on.
+	Endpoint string
+
+	server *grpc.Server
+
+	mu       sync.Mutex
+	changed  chan struct{}
+	spans    []*tracepb.ResourceSpans
+	metrics  []*metricpb.ResourceMetrics
+	logs     []*logspb.ResourceLogs
+	metadata []metadata.MD
+}
+
+type Option func(*options)
+
+type options struct {
+	tlsConfig *tls.Config
+}
+
+// WithTLS makes the collector only accept TLS connections using config.
+func WithTLS(config *tls.Config) Option {
+	return func(o *options) { o.tlsConfig = config }
+}
+
+// Start listens on a random local port and serves the OTLP trace, metrics
+// and logs services until Stop is called.
+func Start(opts ...Option) (*Collector, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	var serverOpts []grpc.ServerOption
+	if o.tlsConfig != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(o.tlsConfig)))
+	}
+
+	c := &Collector{
+		Endpoint: listener.Addr().String(),
+		server:   grpc.NewServer(serverOpts...),
+		changed:  make(chan struct{}),
+	}
+	collectortracepb.RegisterTraceServiceServer(c.server, &traceService{collector: c})
+	collectormetricpb.RegisterMetricsServiceServer(c.server, &metricsService{collector: c})
+	collectorlogspb.RegisterLogsServiceServer(c.server, &logsService{collector: c})
+
+	go c.server.Serve(listener)
+
+	return c, nil
+}
+
+// Stop closes the listener and every open connection.
+func (c *Collector) Stop() {
+	c.server.Stop()
+}
+
+// ResourceSpans returns every batch of spans received so far.
+func (c *Collector) ResourceSpans() []*tracepb.ResourceSpans {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*tracepb.ResourceSpans(nil), c.spans...)
+}
+
+// Spans flattens ResourceSpans into the individual spans received.
+func (c *Collector) Spans() []*tracepb.Span {
+	var spans []*tracepb.Span
+	for _, rs := range c.ResourceSpans() {
+		for _, ss := range rs.ScopeSpans {
+			spans = append(spans, ss.Spans...)
+		}
+	}
+	return spans
+}
+
+// ResourceMetrics returns every batch of metrics received so far.
+func (c *Collector) ResourceMetrics() []*metricpb.ResourceMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*metricpb.ResourceMetrics(nil), c.metrics...)
+}
+
+// ResourceLogs returns every batch of log records received so far.
+func (c *Collector) ResourceLogs() []*logspb.ResourceLogs {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*logspb.ResourceLogs(nil), c.logs...)
+}
+
+// LogRecords flattens ResourceLogs into the individual records received.
+func (c *Collector) LogRecords() []*logspb.LogRecord {
+	var records []*logspb.LogRecord
+	for _, rl := range c.ResourceLogs() {
+		for _, sl := range rl.ScopeLogs {
+			records = append(records, sl.LogRecords...)
+		}
+	}
+	return records
+}
+
+// Metadata returns the gRPC metadata of each export request, in the order
+// they arrived. Exporter headers show up here with lowercase keys.
+func (c *Collector) Metadata() []metadata.MD {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]metadata.MD(nil), c.metadata...)
+}
+
+// WaitForSpans blocks until at least n spans were received or ctx is done.
+func (c *Collector) WaitForSpans(ctx context.Context, n int) ([]*tracepb.Span, error) {
+	for {
+		c.mu.Lock()
+		changed := c.changed
+		c.mu.Unlock()
+
+		if spans := c.Spans(); len(spans) >= n {
+			return spans, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return c.Spans(), fmt.Errorf("received %d spans, want %d: %w", len(c.Spans()), n, ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
+func (c *Collector) record(ctx context.Context, record func()) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	record()
+	c.metadata = append(c.metadata, md)
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+type traceService struct {
+	collectortracepb.UnimplementedTraceServiceServer
+	collector *Collector
+}
+
+func (s *traceService) Export(ctx context.Context, req *collectortracepb.ExportTraceServiceRequest) (*collectortracepb.ExportTraceServiceResponse, error) {
+	s.collector.record(ctx, func() {
+		s.collector.spans = append(s.collector.spans, req.ResourceSpans...)
+	})
+	return &collectortracepb.ExportTraceServiceResponse{}, nil
+}
+
+type metricsService struct {
+	collectormetricpb.UnimplementedMetricsServiceServer
+	collector *Collector
+}
+
+func (s *metricsService) Export(ctx context.Context, req *collectormetricpb.ExportMetricsServiceRequest) (*collectormetricpb.ExportMetricsServiceResponse, error) {
+	s.collector.record(ctx, func() {
+		s.collector.metrics = append(s.collector.metrics, req.ResourceMetrics...)
+	})
+	return &collectormetricpb.ExportMetricsServiceResponse{}, nil
+}
+
+type logsService struct {
+	collectorlogspb.UnimplementedLogsServiceServer
+	collector *Collector
+}
+
+func (s *logsService) Export(ctx context.Context, req *collectorlogspb.ExportLogsServiceRequest) (*collectorlogspb.ExportLogsServiceResponse, error) {
+	s.collector.record(ctx, func() {
+		s.collector.logs = append(s.collector.logs, req.ResourceLogs...)
+	})
+	return &collectorlogspb.ExportLogsServiceResponse{}, nil
+}
@@ -0,0 +1,150 @@
+package otlptest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc/credentials"
+)
+
+// selfSigned returns a server certificate for 127.0.0.1 and a pool trusting it.
+func selfSigned(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "otlptest"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+// exportSpan sends one span named name through an OTLP/gRPC exporter built
+// with opts, returning the export error.
+func exportSpan(t *testing.T, name string, opts ...otlptracegrpc.Option) error {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exporter.Shutdown(ctx)
+
+	return exporter.ExportSpans(ctx, tracetest.SpanStubs{{Name: name}}.Snapshots())
+}
+
+func TestCollectorReceivesSpansAndHeaders(t *testing.T) {
+	collector, err := Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer collector.Stop()
+
+	err = exportSpan(t, "GET /alerts",
+		otlptracegrpc.WithEndpoint(collector.Endpoint),
+		otlptracegrpc.WithInsecure(),
+		otlptracegrpc.WithHeaders(map[string]string{"X-Tenant": "acme"}),
+	)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	spans, err := collector.WaitForSpans(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spans[0].Name != "GET /alerts" {
+		t.Errorf("span name = %q, want %q", spans[0].Name, "GET /alerts")
+	}
+
+	md := collector.Metadata()
+	if len(md) == 0 {
+		t.Fatal("no export metadata recorded")
+	}
+	if got := md[0].Get("x-tenant"); len(got) != 1 || got[0] != "acme" {
+		t.Errorf("x-tenant header = %v, want [acme]", got)
+	}
+}
+
+func TestCollectorWithTLS(t *testing.T) {
+	cert, pool := selfSigned(t)
+
+	collector, err := Start(WithTLS(&tls.Config{Certificates: []tls.Certificate{cert}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer collector.Stop()
+
+	tests := []struct {
+		name    string
+		opts    []otlptracegrpc.Option
+		wantErr bool
+	}{
+		{
+			name: "trusted certificate",
+			opts: []otlptracegrpc.Option{
+				otlptracegrpc.WithTLSCredentials(credentials.NewClientTLSFromCert(pool, "")),
+			},
+		},
+		{
+			name:    "plaintext client",
+			opts:    []otlptracegrpc.Option{otlptracegrpc.WithInsecure()},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(collector.Spans())
+			opts := append([]otlptracegrpc.Option{
+				otlptracegrpc.WithEndpoint(collector.Endpoint),
+				otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{Enabled: false}),
+			}, tt.opts...)
+
+			err := exportSpan(t, tt.name, opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("export error = %v, want error %v", err, tt.wantErr)
+			}
+
+			received := len(collector.Spans()) - before
+			if want := map[bool]int{false: 1, true: 0}[tt.wantErr]; received != want {
+				t.Errorf("collector received %d spans, want %d", received, want)
+			}
+		})
+	}
+}
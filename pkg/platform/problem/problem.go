@@ -0,0 +1,53 @@
+package problem
+
+import (
+	"net/http"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/bufpool"
+	"github.com/luis-olivetti/go-observability/pkg/platform/messages"
+)
+
+// FieldError points at the request field that failed validation.
+type FieldError = contracts.FieldError
+
+// Details is an RFC 7807 problem document.
+type Details = contracts.Problem
+
+// Write answers with a problem document for code. Its detail is the catalog
+// message of code, rendered with params in the locale r accepts.
+func Write(w http.ResponseWriter, r *http.Request, status int, code contracts.ErrorCode, params messages.Params, errors []FieldError) {
+	locale := messages.Default.Locale(r.Header.Get("Accept-Language"))
+	details := Details{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: messages.Default.Message(locale, code, params),
+		Errors: errors,
+	}
+	if dw, ok := w.(*debugWriter); ok {
+		details.Debug = dw.debug
+	}
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Language", locale)
+	w.Header().Add("Vary", "Accept-Language")
+	bufpool.WriteJSON(w, status, contracts.ProblemContentType, details)
+}
+
+// NotFound answers requests for a route the router does not have.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, http.StatusNotFound, contracts.CodeNotFound, nil, nil)
+}
+
+// WithDebug returns a writer whose problem documents carry debug, whichever
+// path ends up writing them. It must wrap the writer last.
+func WithDebug(w http.ResponseWriter, debug *contracts.Debug) http.ResponseWriter {
+	return &debugWriter{ResponseWriter: w, debug: debug}
+}
+
+type debugWriter struct {
+	http.ResponseWriter
+	debug *contracts.Debug
+}
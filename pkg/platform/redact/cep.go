@@ -0,0 +1,76 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// CEPMode is how CEPs are shown in a signal.
+type CEPMode string
+
+const (
+	// CEPFull leaves CEPs as they are.
+	CEPFull CEPMode = "full"
+	// CEPMasked keeps the first three digits, the region: 299*****.
+	CEPMasked CEPMode = "masked"
+	// CEPHashed replaces CEPs with a short hash, so occurrences of the same
+	// CEP can still be matched without showing it: cep:1be0967e0f7a.
+	CEPHashed CEPMode = "hashed"
+)
+
+// ParseCEPMode validates a mode read from configuration.
+func ParseCEPMode(value string) (CEPMode, error) {
+	switch mode := CEPMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case CEPFull, CEPMasked, CEPHashed:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("must be %s, %s or %s, got %q", CEPFull, CEPMasked, CEPHashed, value)
+	}
+}
+
+// CEPPolicy says how CEPs appear in each signal; a signal without a mode
+// gets them masked.
+type CEPPolicy struct {
+	Spans CEPMode
+	Logs  CEPMode
+	// History covers what the service stores of past requests, such as
+	// recorded upstream fixtures.
+	History CEPMode
+	// HashKey keys the hashes. Without it a hash is plain SHA-256, which
+	// anyone can reverse by hashing every CEP.
+	HashKey []byte
+}
+
+// DefaultCEPPolicy masks CEPs in spans and leaves them whole elsewhere.
+var DefaultCEPPolicy = CEPPolicy{Spans: CEPMasked, Logs: CEPFull, History: CEPFull}
+
+// Render rewrites the CEPs in value as mode says; an empty mode masks them.
+func (p CEPPolicy) Render(mode CEPMode, value string) string {
+	switch mode {
+	case CEPFull:
+		return value
+	case CEPHashed:
+		return cepPattern.ReplaceAllStringFunc(value, p.hash)
+	default:
+		return cepPattern.ReplaceAllString(value, "${1}*****")
+	}
+}
+
+// hash renders one CEP; 29902555 and 29902-555 hash alike.
+func (p CEPPolicy) hash(cep string) string {
+	digits := strings.ReplaceAll(cep, "-", "")
+
+	var sum []byte
+	if len(p.HashKey) > 0 {
+		mac := hmac.New(sha256.New, p.HashKey)
+		mac.Write([]byte(digits))
+		sum = mac.Sum(nil)
+	} else {
+		plain := sha256.Sum256([]byte(digits))
+		sum = plain[:]
+	}
+	return "cep:" + hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,166 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	cepPattern      = regexp.MustCompile(`\b(\d{3})\d{2}-?\d{3}\b`)
+	secretParameter = regexp.MustCompile(`(?i)([?&](?:key|api_key|apikey|token|access_token)=)[^&\s"]*`)
+)
+
+// Scrubber masks personal data and secrets in string values.
+type Scrubber struct {
+	keyPatterns []*regexp.Regexp
+	ceps        CEPPolicy
+}
+
+// NewScrubber creates a scrubber that, besides rendering CEPs as ceps says
+// and stripping URL secrets, fully redacts the values of span attributes
+// whose key matches any of patterns.
+func NewScrubber(patterns []string, ceps CEPPolicy) (*Scrubber, error) {
+	s := &Scrubber{ceps: ceps}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		s.keyPatterns = append(s.keyPatterns, re)
+	}
+
+	return s, nil
+}
+
+// Span strips secret query parameters and renders CEPs as spans show them.
+func (s *Scrubber) Span(value string) string {
+	return s.ceps.Render(s.ceps.Spans, stripSecrets(value))
+}
+
+// Log strips secret query parameters and renders CEPs as logs show them.
+func (s *Scrubber) Log(value string) string {
+	return s.ceps.Render(s.ceps.Logs, stripSecrets(value))
+}
+
+// History renders CEPs as stored records of past requests show them.
+func (s *Scrubber) History(value string) string {
+	return s.ceps.Render(s.ceps.History, value)
+}
+
+func stripSecrets(value string) string {
+	return secretParameter.ReplaceAllString(value, "${1}"+redacted)
+}
+
+func (s *Scrubber) Attributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	if len(attrs) == 0 {
+		return attrs
+	}
+
+	scrubbed := make([]attribute.KeyValue, len(attrs))
+	for i, attr := range attrs {
+		scrubbed[i] = s.attribute(attr)
+	}
+
+	return scrubbed
+}
+
+func (s *Scrubber) attribute(attr attribute.KeyValue) attribute.KeyValue {
+	for _, re := range s.keyPatterns {
+		if re.MatchString(string(attr.Key)) {
+			return attr.Key.String(redacted)
+		}
+	}
+
+	switch attr.Value.Type() {
+	case attribute.STRING:
+		return attr.Key.String(s.Span(attr.Value.AsString()))
+	case attribute.STRINGSLICE:
+		values := attr.Value.AsStringSlice()
+		for i, value := range values {
+			values[i] = s.Span(value)
+		}
+		return attr.Key.StringSlice(values)
+	default:
+		return attr
+	}
+}
+
+// Processor scrubs spans before handing them to the next processor, so
+// nothing reaches the exporter unredacted.
+type Processor struct {
+	next     sdktrace.SpanProcessor
+	scrubber *Scrubber
+}
+
+func NewProcessor(next sdktrace.SpanProcessor, scrubber *Scrubber) *Processor {
+	return &Processor{next: next, scrubber: scrubber}
+}
+
+func (p *Processor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+func (p *Processor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.next.OnEnd(&scrubbedSpan{ReadOnlySpan: s, scrubber: p.scrubber})
+}
+
+func (p *Processor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+func (p *Processor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
+
+type scrubbedSpan struct {
+	sdktrace.ReadOnlySpan
+	scrubber *Scrubber
+}
+
+func (s *scrubbedSpan) Name() string {
+	return s.scrubber.Span(s.ReadOnlySpan.Name())
+}
+
+func (s *scrubbedSpan) Attributes() []attribute.KeyValue {
+	return s.scrubber.Attributes(s.ReadOnlySpan.Attributes())
+}
+
+func (s *scrubbedSpan) Events() []sdktrace.Event {
+	events := s.ReadOnlySpan.Events()
+	scrubbed := make([]sdktrace.Event, len(events))
+	for i, event := range events {
+		event.Attributes = s.scrubber.Attributes(event.Attributes)
+		scrubbed[i] = event
+	}
+
+	return scrubbed
+}
+
+func (s *scrubbedSpan) Status() sdktrace.Status {
+	status := s.ReadOnlySpan.Status()
+	if status.Code == codes.Error {
+		status.Description = s.scrubber.Span(status.Description)
+	}
+
+	return status
+}
+
+// ParsePatterns splits a comma-separated list of attribute key patterns.
+func ParsePatterns(raw string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(raw, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	return patterns
+}
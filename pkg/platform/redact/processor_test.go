@@ -0,0 +1,117 @@
+package redact
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func attr(attrs []attribute.KeyValue, key string) (attribute.Value, bool) {
+	for _, a := range attrs {
+		if string(a.Key) == key {
+			return a.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestProcessorScrubsExportedSpans(t *testing.T) {
+	scrubber, err := NewScrubber([]string{`(?i)password`, `^enduser\.`}, DefaultCEPPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(NewProcessor(sdktrace.NewSimpleSpanProcessor(exporter), scrubber)))
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+
+	_, span := tp.Tracer("test").Start(context.Background(), "lookup 29902555", trace.WithAttributes(
+		attribute.String("url.full", "https://api.weatherapi.com/v1/current.json?key=s3cret&q=Linhares"),
+		attribute.String("db.password", "hunter2"),
+		attribute.String("enduser.id", "user-42"),
+		attribute.StringSlice("ceps", []string{"29902-555", "01001000"}),
+		attribute.Int("http.response.status_code", 200),
+		attribute.String("city", "Linhares"),
+	))
+	span.AddEvent("cache miss", trace.WithAttributes(attribute.String("cep", "29902555")))
+	span.RecordError(errors.New("no city for 29902555"))
+	span.SetStatus(codes.Error, "lookup of 29902555 failed")
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+	got := spans[0]
+
+	if got.Name != "lookup 299*****" {
+		t.Errorf("name = %q, want the CEP masked", got.Name)
+	}
+	if got.Status.Description != "lookup of 299***** failed" {
+		t.Errorf("status description = %q, want the CEP masked", got.Status.Description)
+	}
+
+	tests := []struct {
+		key  string
+		want attribute.Value
+	}{
+		{key: "url.full", want: attribute.StringValue("https://api.weatherapi.com/v1/current.json?key=[REDACTED]&q=Linhares")},
+		{key: "db.password", want: attribute.StringValue(redacted)},
+		{key: "enduser.id", want: attribute.StringValue(redacted)},
+		{key: "ceps", want: attribute.StringSliceValue([]string{"299*****", "010*****"})},
+		{key: "http.response.status_code", want: attribute.IntValue(200)},
+		{key: "city", want: attribute.StringValue("Linhares")},
+	}
+	for _, tt := range tests {
+		value, ok := attr(got.Attributes, tt.key)
+		if !ok || value != tt.want {
+			t.Errorf("attribute %s = %q, want %q", tt.key, value.Emit(), tt.want.Emit())
+		}
+	}
+
+	if len(got.Events) != 2 {
+		t.Fatalf("exported %d events, want 2", len(got.Events))
+	}
+	for _, event := range got.Events {
+		for _, a := range event.Attributes {
+			if a.Value.Type() == attribute.STRING && cepPattern.MatchString(a.Value.AsString()) {
+				t.Errorf("event %q attribute %s = %q, want the CEP masked", event.Name, a.Key, a.Value.AsString())
+			}
+		}
+	}
+}
+
+func TestProcessorHashesCEPs(t *testing.T) {
+	policy := CEPPolicy{Spans: CEPHashed, HashKey: []byte("k")}
+	scrubber, err := NewScrubber(nil, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(NewProcessor(sdktrace.NewSimpleSpanProcessor(exporter), scrubber)))
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+
+	_, span := tp.Tracer("test").Start(context.Background(), "lookup", trace.WithAttributes(
+		attribute.String("cep", "29902555"),
+		attribute.String("cep.formatted", "29902-555"),
+	))
+	span.End()
+
+	attrs := exporter.GetSpans()[0].Attributes
+	plain, _ := attr(attrs, "cep")
+	formatted, _ := attr(attrs, "cep.formatted")
+	if plain.AsString() != policy.hash("29902555") || formatted != plain {
+		t.Errorf("cep = %q and %q, want both hashed alike as %q", plain.AsString(), formatted.AsString(), policy.hash("29902555"))
+	}
+}
+
+func TestNewScrubberRejectsInvalidPattern(t *testing.T) {
+	if _, err := NewScrubber([]string{"("}, DefaultCEPPolicy); err == nil {
+		t.Error("NewScrubber accepted an invalid pattern")
+	}
+}
@@ -0,0 +1,219 @@
+// Package reqsign signs requests between the services with HMAC-SHA256 and
+// verifies them on the receiving side. Both ends share Sign, so the
+// canonical form is defined once.
+package reqsign
+
+import (
+	"bytes"
+	"container/heap"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+	"github.com/luis-olivetti/go-observability/pkg/platform/security"
+)
+
+// Sign computes the hex-encoded signature of a request's canonical form: the
+// method, request URI, timestamp, nonce and body hash, one per line.
+func Sign(secret []byte, method, requestURI, timestamp, nonce, bodyHash string) string {
+	mac := hmac.New(sha256.New, secret)
+	io.WriteString(mac, method+"\n"+requestURI+"\n"+timestamp+"\n"+nonce+"\n"+bodyHash)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// BodyHash is the hex-encoded SHA-256 of body, sent as X-Content-Sha256.
+func BodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// SigningTransport signs outgoing requests with HMAC-SHA256 over the method,
+// request URI, timestamp, a random nonce and body hash, as checked by
+// Verifier. The nonce keeps identical requests made within the same second
+// from sharing a signature.
+type SigningTransport struct {
+	Secret []byte
+	Base   http.RoundTripper
+	// Clock defaults to the wall clock when nil.
+	Clock clock.Clock
+}
+
+func (t *SigningTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	bodyHash := BodyHash(body)
+	timestamp := strconv.FormatInt(clock.Or(t.Clock).Now().Unix(), 10)
+
+	var nonceBytes [16]byte
+	if _, err := rand.Read(nonceBytes[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate signature nonce: %w", err)
+	}
+	nonce := hex.EncodeToString(nonceBytes[:])
+
+	req.Header.Set(contracts.SignatureTimestampHeader, timestamp)
+	req.Header.Set(contracts.SignatureNonceHeader, nonce)
+	req.Header.Set(contracts.ContentSHA256Header, bodyHash)
+	req.Header.Set(contracts.SignatureHeader, Sign(t.Secret, req.Method, req.URL.RequestURI(), timestamp, nonce, bodyHash))
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return base.RoundTrip(req)
+}
+
+const (
+	// maxSignedBody caps the body read to check its hash.
+	maxSignedBody = 1 << 20
+	// maxNonceLength bounds what a nonce may cost in memory; SigningTransport
+	// sends 32 hex characters.
+	maxNonceLength = 64
+)
+
+// Verifier checks request signatures produced by SigningTransport, rejecting
+// requests outside the replay window or whose nonce was already seen.
+type Verifier struct {
+	// Clock defaults to the wall clock when nil.
+	Clock clock.Clock
+
+	secret []byte
+	window time.Duration
+
+	mu   sync.Mutex
+	seen map[string]struct{}
+	// expiries orders the seen nonces by when they leave the replay window,
+	// so each request only forgets the ones already out of it.
+	expiries nonceExpiries
+}
+
+func NewVerifier(secret []byte, window time.Duration) *Verifier {
+	if window <= 0 {
+		window = 5 * time.Minute
+	}
+
+	return &Verifier{secret: secret, window: window, seen: map[string]struct{}{}}
+}
+
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSignedBody))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				problem.Write(w, r, http.StatusRequestEntityTooLarge, contracts.CodePayloadTooLarge, nil, nil)
+				return
+			}
+			problem.Write(w, r, http.StatusBadRequest, contracts.CodeBadRequest, nil, nil)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		if reason := v.verify(r, body); reason != "" {
+			security.Record(r, security.SignatureMismatch, reason)
+			problem.Write(w, r, http.StatusUnauthorized, contracts.CodeUnauthorized, nil, nil)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// verify returns the reason the signature was rejected, or "" if it is valid.
+func (v *Verifier) verify(r *http.Request, body []byte) string {
+	timestamp := r.Header.Get(contracts.SignatureTimestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "missing_timestamp"
+	}
+
+	signedAt := time.Unix(seconds, 0)
+	if age := clock.Or(v.Clock).Now().Sub(signedAt); age > v.window || age < -v.window {
+		return "outside_replay_window"
+	}
+
+	nonce := r.Header.Get(contracts.SignatureNonceHeader)
+	if nonce == "" || len(nonce) > maxNonceLength {
+		return "missing_nonce"
+	}
+
+	bodyHash := BodyHash(body)
+	if !hmac.Equal([]byte(bodyHash), []byte(r.Header.Get(contracts.ContentSHA256Header))) {
+		return "body_hash_mismatch"
+	}
+
+	signature := r.Header.Get(contracts.SignatureHeader)
+	expected := Sign(v.secret, r.Method, r.URL.RequestURI(), timestamp, nonce, bodyHash)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "signature_mismatch"
+	}
+
+	if !v.markSeen(nonce, signedAt) {
+		return "replayed"
+	}
+
+	return ""
+}
+
+// markSeen records a nonce until its timestamp leaves the replay window,
+// returning false if it was already used.
+func (v *Verifier) markSeen(nonce string, signedAt time.Time) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := clock.Or(v.Clock).Now()
+	for len(v.expiries) > 0 && !v.expiries[0].at.After(now) {
+		delete(v.seen, heap.Pop(&v.expiries).(nonceExpiry).nonce)
+	}
+
+	if _, ok := v.seen[nonce]; ok {
+		return false
+	}
+	v.seen[nonce] = struct{}{}
+	heap.Push(&v.expiries, nonceExpiry{nonce: nonce, at: signedAt.Add(v.window)})
+
+	return true
+}
+
+type nonceExpiry struct {
+	nonce string
+	at    time.Time
+}
+
+// nonceExpiries is a min-heap of seen nonces by expiry.
+type nonceExpiries []nonceExpiry
+
+func (h nonceExpiries) Len() int           { return len(h) }
+func (h nonceExpiries) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+func (h nonceExpiries) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *nonceExpiries) Push(x any) { *h = append(*h, x.(nonceExpiry)) }
+
+func (h *nonceExpiries) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
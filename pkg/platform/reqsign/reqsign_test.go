@@ -0,0 +1,267 @@
+package reqsign
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+)
+
+var secret = []byte("secret")
+
+// signedRequest builds a request to /city-weather signed with secret at now.
+func signedRequest(nonce string, now time.Time) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/city-weather?zipcode=29902555", nil)
+	bodyHash := BodyHash(nil)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req.Header.Set(contracts.SignatureTimestampHeader, timestamp)
+	req.Header.Set(contracts.SignatureNonceHeader, nonce)
+	req.Header.Set(contracts.ContentSHA256Header, bodyHash)
+	req.Header.Set(contracts.SignatureHeader, Sign(secret, req.Method, req.URL.RequestURI(), timestamp, nonce, bodyHash))
+	return req
+}
+
+// recordingTransport keeps the last request it sent, as signed.
+type recordingTransport struct {
+	mu   sync.Mutex
+	last *http.Request
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.last = req
+	t.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newSignedPair starts a server behind a Verifier and returns a client that
+// signs with clientSecret; both read the same fake clock.
+func newSignedPair(t *testing.T, clientSecret []byte, clk clock.Clock) (*http.Client, *recordingTransport, string) {
+	t.Helper()
+
+	verifier := NewVerifier(secret, time.Minute)
+	verifier.Clock = clk
+	server := httptest.NewServer(verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	})))
+	t.Cleanup(server.Close)
+
+	recorder := &recordingTransport{}
+	client := &http.Client{Transport: &SigningTransport{Secret: clientSecret, Base: recorder, Clock: clk}}
+	return client, recorder, server.URL
+}
+
+func TestSigningTransportRoundTrip(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	client, _, url := newSignedPair(t, secret, clk)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{name: "GET with a query", method: http.MethodGet, path: "/city-weather?zipcode=29902555"},
+		{name: "POST with a body", method: http.MethodPost, path: "/city-by-zipcode", body: `{"cep": "29902555"}`},
+		{name: "escaped path", method: http.MethodGet, path: "/a%2Fb?q=s%C3%A3o+paulo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, url+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+			if string(body) != tt.body {
+				t.Errorf("server read body %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestSigningTransportRejected(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	client, recorder, url := newSignedPair(t, secret, clk)
+
+	send := func(req *http.Request) int {
+		t.Helper()
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	resp, err := client.Post(url+"/city-by-zipcode", "application/json", strings.NewReader(`{"cep": "29902555"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("signed request status = %d, want 200", resp.StatusCode)
+	}
+
+	// resend rebuilds the request the client signed, with edit applied.
+	resend := func(body string, edit func(*http.Request)) int {
+		t.Helper()
+		recorder.mu.Lock()
+		signed := recorder.last
+		recorder.mu.Unlock()
+
+		req, err := http.NewRequest(signed.Method, signed.URL.String(), strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header = signed.Header.Clone()
+		if edit != nil {
+			edit(req)
+		}
+		return send(req)
+	}
+
+	if got := resend(`{"cep": "29902555"}`, nil); got != http.StatusUnauthorized {
+		t.Errorf("replayed request status = %d, want 401", got)
+	}
+	if got := resend(`{"cep": "01001000"}`, func(req *http.Request) {
+		req.Header.Set(contracts.SignatureNonceHeader, "fresh")
+	}); got != http.StatusUnauthorized {
+		t.Errorf("tampered body status = %d, want 401", got)
+	}
+	if got := resend(`{"cep": "29902555"}`, func(req *http.Request) {
+		req.URL.RawQuery = "admin=true"
+		req.Header.Set(contracts.SignatureNonceHeader, "fresh")
+	}); got != http.StatusUnauthorized {
+		t.Errorf("tampered URI status = %d, want 401", got)
+	}
+
+	other, _, _ := newSignedPair(t, []byte("other secret"), clk)
+	resp, err = other.Get(url + "/city-weather")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("wrong secret status = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestVerifierClockSkew(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := NewVerifier(secret, time.Minute)
+	verifier.Clock = clock.NewFake(now)
+	handler := verifier.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	tests := []struct {
+		name       string
+		signedAt   time.Time
+		wantStatus int
+	}{
+		{name: "now", signedAt: now, wantStatus: http.StatusOK},
+		{name: "at the window in the past", signedAt: now.Add(-time.Minute), wantStatus: http.StatusOK},
+		{name: "at the window in the future", signedAt: now.Add(time.Minute), wantStatus: http.StatusOK},
+		{name: "past the window", signedAt: now.Add(-time.Minute - time.Second), wantStatus: http.StatusUnauthorized},
+		{name: "ahead of the window", signedAt: now.Add(time.Minute + time.Second), wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, signedRequest(tt.name, tt.signedAt))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestVerifierReplay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	clk := clock.NewFake(now)
+	verifier := NewVerifier(secret, time.Minute)
+	verifier.Clock = clk
+	handler := verifier.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	serve := func(req *http.Request) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if got := serve(signedRequest("nonce-1", now)); got != http.StatusOK {
+		t.Fatalf("first request status = %d, want 200", got)
+	}
+	if got := serve(signedRequest("nonce-1", now)); got != http.StatusUnauthorized {
+		t.Errorf("replayed request status = %d, want 401", got)
+	}
+	if got := serve(signedRequest("nonce-2", now.Add(-2*time.Minute))); got != http.StatusUnauthorized {
+		t.Errorf("request outside the window status = %d, want 401", got)
+	}
+
+	// Once out of the window, the nonce is forgotten and its requests are
+	// rejected by timestamp instead.
+	clk.Advance(2 * time.Minute)
+	if got := serve(signedRequest("nonce-1", now)); got != http.StatusUnauthorized {
+		t.Errorf("replay after the window status = %d, want 401", got)
+	}
+	if got := serve(signedRequest("nonce-3", clk.Now())); got != http.StatusOK {
+		t.Errorf("fresh request status = %d, want 200", got)
+	}
+	verifier.mu.Lock()
+	seen := len(verifier.seen)
+	verifier.mu.Unlock()
+	if seen != 1 {
+		t.Errorf("%d nonces tracked, want only the fresh one", seen)
+	}
+}
+
+func TestVerifierConcurrentReplay(t *testing.T) {
+	now := time.Now()
+	handler := NewVerifier(secret, time.Minute).Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	const callers = 50
+	statuses := make(chan int, callers*2)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for _, nonce := range []string{"shared", "own-" + strconv.Itoa(i)} {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, signedRequest(nonce, now))
+				statuses <- rec.Code
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(statuses)
+
+	accepted := 0
+	for status := range statuses {
+		if status == http.StatusOK {
+			accepted++
+		}
+	}
+	// Every own nonce plus the shared one exactly once.
+	if accepted != callers+1 {
+		t.Errorf("%d requests accepted, want %d", accepted, callers+1)
+	}
+}
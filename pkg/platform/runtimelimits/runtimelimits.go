@@ -0,0 +1,70 @@
+package runtimelimits
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/KimMachineGun/automemlimit/memlimit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/automaxprocs/maxprocs"
+)
+
+// memoryLimitRatio leaves headroom below the container limit for memory the
+// Go runtime does not account for, so the GC kicks in before the OOM killer.
+const memoryLimitRatio = 0.9
+
+// Limits are the effective runtime settings after Apply.
+type Limits struct {
+	GOMAXPROCS int
+	// MemoryLimit is the runtime soft limit; math.MaxInt64 means none.
+	MemoryLimit int64
+}
+
+// Apply sizes GOMAXPROCS with automaxprocs and GOMEMLIMIT with automemlimit
+// from the cgroup limits of the container. Go 1.21 sizes GOMAXPROCS from the
+// host's CPUs, so a pod with a fractional CPU limit would otherwise run many
+// more threads than it has quota for and get throttled. Explicit GOMAXPROCS
+// and GOMEMLIMIT environment variables always win. Failures are logged and
+// leave the runtime defaults in place.
+func Apply() Limits {
+	if _, err := maxprocs.Set(maxprocs.Logger(func(string, ...any) {})); err != nil {
+		log.Printf("failed to set GOMAXPROCS from the cpu quota: %v", err)
+	}
+
+	_, err := memlimit.SetGoMemLimitWithOpts(memlimit.WithRatio(memoryLimitRatio))
+	if err != nil && !errors.Is(err, memlimit.ErrNoCgroup) && !errors.Is(err, memlimit.ErrCgroupsNotSupported) {
+		log.Printf("failed to set GOMEMLIMIT from the memory limit: %v", err)
+	}
+
+	return Limits{
+		GOMAXPROCS:  runtime.GOMAXPROCS(0),
+		MemoryLimit: debug.SetMemoryLimit(-1),
+	}
+}
+
+func (l Limits) String() string {
+	memoryLimit := "none"
+	if l.MemoryLimit != math.MaxInt64 {
+		memoryLimit = strconv.FormatInt(l.MemoryLimit, 10)
+	}
+
+	return fmt.Sprintf("GOMAXPROCS=%d GOMEMLIMIT=%s", l.GOMAXPROCS, memoryLimit)
+}
+
+// Attributes describes the limits as resource attributes, so dashboards can
+// tell throttled or memory-bound instances apart.
+func (l Limits) Attributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.Int("process.runtime.go.gomaxprocs", l.GOMAXPROCS),
+	}
+	if l.MemoryLimit != math.MaxInt64 {
+		attrs = append(attrs, attribute.Int64("process.runtime.go.gomemlimit", l.MemoryLimit))
+	}
+
+	return attrs
+}
@@ -0,0 +1,120 @@
+package sampling
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MaxOverride bounds how long an override lasts, so a forgotten one cannot
+// keep every trace for good.
+const MaxOverride = 24 * time.Hour
+
+// Override replaces the ratio of a Policy for a limited time, such as
+// keeping every trace during an incident, and then puts the configured ratio
+// back.
+type Override struct {
+	policy *Policy
+
+	mu         sync.Mutex
+	configured Config
+	until      time.Time
+	timer      *time.Timer
+}
+
+func NewOverride(policy *Policy) *Override {
+	return &Override{policy: policy, configured: policy.Get()}
+}
+
+// Configure sets the configured sampling, as read from the config file. It
+// takes effect at once, or when the override in progress ends.
+func (o *Override) Configure(cfg Config) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.configured = cfg
+	if o.timer == nil {
+		o.policy.Set(cfg)
+	}
+}
+
+// Start keeps ratio of the traces for d, replacing any override in progress.
+// onEnd is called if the override runs out rather than being stopped.
+func (o *Override) Start(ratio float64, d time.Duration, onEnd func()) error {
+	if d <= 0 || d > MaxOverride {
+		return fmt.Errorf("override duration must be above zero and at most %s, got %s", MaxOverride, d)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	cfg := o.configured
+	cfg.Ratio = ratio
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	if o.timer != nil {
+		o.timer.Stop()
+	}
+	o.policy.Set(cfg)
+	o.until = time.Now().Add(d)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		o.mu.Lock()
+		// A later Start or Stop replaced this override.
+		if o.timer != timer {
+			o.mu.Unlock()
+			return
+		}
+		o.end()
+		o.mu.Unlock()
+
+		if onEnd != nil {
+			onEnd()
+		}
+	})
+	o.timer = timer
+
+	return nil
+}
+
+// Stop ends the override in progress, reporting whether there was one.
+func (o *Override) Stop() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.timer == nil {
+		return false
+	}
+	o.timer.Stop()
+	o.end()
+	return true
+}
+
+func (o *Override) end() {
+	o.timer = nil
+	o.until = time.Time{}
+	o.policy.Set(o.configured)
+}
+
+// OverrideStatus is the sampling in effect.
+type OverrideStatus struct {
+	Ratio           float64 `json:"ratio"`
+	ConfiguredRatio float64 `json:"configured_ratio"`
+	// Until is when the override in progress ends; nil without one.
+	Until *time.Time `json:"until,omitempty"`
+}
+
+func (o *Override) Status() OverrideStatus {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	status := OverrideStatus{Ratio: o.policy.Get().Ratio, ConfiguredRatio: o.configured.Ratio}
+	if o.timer != nil {
+		until := o.until.UTC()
+		status.Until = &until
+	}
+	return status
+}
@@ -0,0 +1,120 @@
+package sampling
+
+import (
+	"testing"
+	"time"
+)
+
+// waitEnd waits for an override's onEnd to run.
+func waitEnd(t *testing.T, ended <-chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-ended:
+	case <-time.After(5 * time.Second):
+		t.Fatal("override did not end")
+	}
+}
+
+func TestOverrideRestoresConfiguredRatio(t *testing.T) {
+	policy := NewPolicy(Config{Ratio: 0.1})
+	override := NewOverride(policy)
+
+	ended := make(chan struct{})
+	if err := override.Start(1, 20*time.Millisecond, func() { close(ended) }); err != nil {
+		t.Fatal(err)
+	}
+	if got := override.Status(); got.Ratio != 1 || got.ConfiguredRatio != 0.1 || got.Until == nil {
+		t.Errorf("status during the override = %+v, want ratio 1 until a deadline", got)
+	}
+
+	waitEnd(t, ended)
+	if got := override.Status(); got.Ratio != 0.1 || got.Until != nil {
+		t.Errorf("status after the override = %+v, want the configured ratio 0.1", got)
+	}
+	if got := policy.Get().Ratio; got != 0.1 {
+		t.Errorf("policy ratio = %g, want 0.1", got)
+	}
+}
+
+func TestOverrideDefersConfigure(t *testing.T) {
+	policy := NewPolicy(Config{Ratio: 0.1})
+	override := NewOverride(policy)
+
+	ended := make(chan struct{})
+	if err := override.Start(1, 20*time.Millisecond, func() { close(ended) }); err != nil {
+		t.Fatal(err)
+	}
+	override.Configure(Config{Ratio: 0.3})
+	if got := policy.Get().Ratio; got != 1 {
+		t.Errorf("policy ratio after Configure = %g, want the override's 1 until it ends", got)
+	}
+
+	waitEnd(t, ended)
+	if got := policy.Get().Ratio; got != 0.3 {
+		t.Errorf("policy ratio after the override = %g, want the newly configured 0.3", got)
+	}
+
+	override.Configure(Config{Ratio: 0.5})
+	if got := policy.Get().Ratio; got != 0.5 {
+		t.Errorf("policy ratio without an override = %g, want 0.5 at once", got)
+	}
+}
+
+func TestOverrideStop(t *testing.T) {
+	policy := NewPolicy(Config{Ratio: 0.1})
+	override := NewOverride(policy)
+
+	ended := make(chan struct{})
+	if err := override.Start(1, 20*time.Millisecond, func() { close(ended) }); err != nil {
+		t.Fatal(err)
+	}
+	if !override.Stop() {
+		t.Fatal("Stop() = false, want an override in progress")
+	}
+	if got := policy.Get().Ratio; got != 0.1 {
+		t.Errorf("policy ratio after Stop = %g, want 0.1", got)
+	}
+	if override.Stop() {
+		t.Error("second Stop() = true, want nothing left to stop")
+	}
+
+	select {
+	case <-ended:
+		t.Error("onEnd ran for an override that was stopped")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestOverrideReplaced(t *testing.T) {
+	policy := NewPolicy(Config{Ratio: 0.1})
+	override := NewOverride(policy)
+
+	first := make(chan struct{})
+	if err := override.Start(1, 20*time.Millisecond, func() { close(first) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := override.Start(0.5, time.Hour, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-first:
+		t.Error("onEnd of a replaced override ran")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if got := policy.Get().Ratio; got != 0.5 {
+		t.Errorf("policy ratio = %g, want the replacing override's 0.5", got)
+	}
+	override.Stop()
+}
+
+func TestOverrideRejectsDuration(t *testing.T) {
+	override := NewOverride(NewPolicy(Config{Ratio: 0.1}))
+
+	for _, d := range []time.Duration{0, -time.Minute, MaxOverride + time.Second} {
+		if err := override.Start(1, d, nil); err == nil {
+			t.Errorf("Start(1, %s) error = nil, want the duration rejected", d)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package sampling
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/debugtrace"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// maxPendingTraces bounds the spans held while waiting for a decision;
+	// past it new traces are exported unconditionally.
+	maxPendingTraces = 10000
+	// decisionTTL is how long a decision is remembered for spans that end
+	// after their local root, and how long a trace whose root never ends is
+	// held before it is exported anyway.
+	decisionTTL = time.Minute
+)
+
+// Config drives which traces are exported.
+type Config struct {
+	// Ratio is the share of healthy, fast traces kept, between 0 and 1.
+	Ratio float64
+	// LatencyThreshold keeps every trace whose local root took at least this
+	// long; zero disables the latency rule.
+	LatencyThreshold time.Duration
+}
+
+func (c Config) Validate() error {
+	if c.Ratio < 0 || c.Ratio > 1 {
+		return fmt.Errorf("sampling ratio must be between 0 and 1, got %g", c.Ratio)
+	}
+	if c.LatencyThreshold < 0 {
+		return fmt.Errorf("sampling latency threshold must not be negative, got %s", c.LatencyThreshold)
+	}
+
+	return nil
+}
+
+// Policy holds the Config in effect, so it can be swapped at runtime while
+// spans keep flowing.
+type Policy struct {
+	cfg atomic.Pointer[Config]
+}
+
+func NewPolicy(cfg Config) *Policy {
+	p := &Policy{}
+	p.Set(cfg)
+	return p
+}
+
+func (p *Policy) Set(cfg Config) {
+	p.cfg.Store(&cfg)
+}
+
+func (p *Policy) Get() Config {
+	return *p.cfg.Load()
+}
+
+type pendingTrace struct {
+	spans     []sdktrace.ReadOnlySpan
+	errored   bool
+	forced    bool
+	firstSeen time.Time
+}
+
+type decision struct {
+	keep      bool
+	decidedAt time.Time
+}
+
+// Processor holds the spans of each trace until its local root span ends and
+// only then decides whether to export them: debug traces and traces with an
+// error or a slow root are always kept, the rest by the policy's Ratio. The
+// ratio is derived from the trace id, like TraceIDRatioBased, so every
+// service keeps the same share of the same traces.
+type Processor struct {
+	next      sdktrace.SpanProcessor
+	policy    *Policy
+	decisions metric.Int64Counter
+
+	mu        sync.Mutex
+	pending   map[trace.TraceID]*pendingTrace
+	decided   map[trace.TraceID]decision
+	lastPurge time.Time
+}
+
+func NewProcessor(next sdktrace.SpanProcessor, policy *Policy) *Processor {
+	decisions, err := otel.Meter("microservice-meter").Int64Counter("sampling.decisions",
+		metric.WithDescription("Local traces seen by the sampler, by decision"))
+	if err != nil {
+		log.Printf("failed to create sampling decisions counter: %v", err)
+	}
+
+	return &Processor{
+		next:      next,
+		policy:    policy,
+		decisions: decisions,
+		pending:   make(map[trace.TraceID]*pendingTrace),
+		decided:   make(map[trace.TraceID]decision),
+	}
+}
+
+func (p *Processor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+func (p *Processor) OnEnd(s sdktrace.ReadOnlySpan) {
+	id := s.SpanContext().TraceID()
+	now := time.Now()
+
+	p.mu.Lock()
+	if d, ok := p.decided[id]; ok {
+		p.mu.Unlock()
+		if d.keep {
+			p.next.OnEnd(s)
+		}
+		return
+	}
+
+	t, ok := p.pending[id]
+	if !ok {
+		if len(p.pending) >= maxPendingTraces {
+			p.mu.Unlock()
+			p.next.OnEnd(s)
+			return
+		}
+		t = &pendingTrace{firstSeen: now}
+		p.pending[id] = t
+	}
+	t.spans = append(t.spans, s)
+	if s.Status().Code == codes.Error {
+		t.errored = true
+	}
+	if debugtrace.Forced(s) {
+		t.forced = true
+	}
+
+	if parent := s.Parent(); parent.IsValid() && !parent.IsRemote() {
+		p.mu.Unlock()
+		return
+	}
+
+	delete(p.pending, id)
+	reason := p.decide(id, t, s)
+	keep := reason != "dropped"
+	p.decided[id] = decision{keep: keep, decidedAt: now}
+	stale := p.purge(now)
+	p.mu.Unlock()
+
+	if p.decisions != nil {
+		p.decisions.Add(context.Background(), 1, metric.WithAttributes(attribute.String("decision", reason)))
+	}
+
+	if keep {
+		for _, span := range t.spans {
+			p.next.OnEnd(span)
+		}
+	}
+	for _, span := range stale {
+		p.next.OnEnd(span)
+	}
+}
+
+func (p *Processor) decide(id trace.TraceID, t *pendingTrace, root sdktrace.ReadOnlySpan) string {
+	cfg := p.policy.Get()
+
+	switch {
+	case t.forced:
+		return "debug"
+	case t.errored:
+		return "error"
+	case cfg.LatencyThreshold > 0 && root.EndTime().Sub(root.StartTime()) >= cfg.LatencyThreshold:
+		return "slow"
+	case binary.BigEndian.Uint64(id[8:16])>>1 < uint64(cfg.Ratio*(1<<63)):
+		return "ratio"
+	default:
+		return "dropped"
+	}
+}
+
+// purge forgets expired decisions and returns the spans of traces whose
+// local root never ended, which are exported rather than lost. It runs at
+// most once per second. p.mu must be held.
+func (p *Processor) purge(now time.Time) []sdktrace.ReadOnlySpan {
+	if now.Sub(p.lastPurge) < time.Second {
+		return nil
+	}
+	p.lastPurge = now
+
+	for id, d := range p.decided {
+		if now.Sub(d.decidedAt) > decisionTTL {
+			delete(p.decided, id)
+		}
+	}
+
+	var stale []sdktrace.ReadOnlySpan
+	for id, t := range p.pending {
+		if now.Sub(t.firstSeen) > decisionTTL {
+			stale = append(stale, t.spans...)
+			delete(p.pending, id)
+		}
+	}
+
+	return stale
+}
+
+// flush exports every trace still waiting for a decision, so shutdown never
+// drops spans the sampler had not ruled on yet.
+func (p *Processor) flush() {
+	p.mu.Lock()
+	pending := p.pending
+	p.pending = make(map[trace.TraceID]*pendingTrace)
+	p.mu.Unlock()
+
+	for _, t := range pending {
+		for _, span := range t.spans {
+			p.next.OnEnd(span)
+		}
+	}
+}
+
+func (p *Processor) Shutdown(ctx context.Context) error {
+	p.flush()
+	return p.next.Shutdown(ctx)
+}
+
+func (p *Processor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
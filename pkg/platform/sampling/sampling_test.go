@@ -0,0 +1,126 @@
+package sampling
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTracer(policy *Policy) (trace.Tracer, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(NewProcessor(sdktrace.NewSimpleSpanProcessor(exporter), policy)))
+	return tp.Tracer(""), exporter
+}
+
+// startTrace records a root span with two children, failing the last one
+// when failed is set.
+func startTrace(tracer trace.Tracer, failed bool) trace.TraceID {
+	ctx, root := tracer.Start(context.Background(), "root")
+	for _, name := range []string{"getViaCep", "getWeather"} {
+		_, child := tracer.Start(ctx, name)
+		if failed && name == "getWeather" {
+			child.SetStatus(codes.Error, "boom")
+		}
+		child.End()
+	}
+	root.End()
+	return root.SpanContext().TraceID()
+}
+
+func TestProcessorKeepsWholeTraces(t *testing.T) {
+	tracer, exporter := newTracer(NewPolicy(Config{Ratio: 0}))
+
+	healthy := startTrace(tracer, false)
+	failed := startTrace(tracer, true)
+
+	counts := map[trace.TraceID]int{}
+	for _, span := range exporter.GetSpans() {
+		counts[span.SpanContext.TraceID()]++
+	}
+	if counts[healthy] != 0 {
+		t.Errorf("healthy trace exported %d spans at ratio 0, want none", counts[healthy])
+	}
+	if counts[failed] != 3 {
+		t.Errorf("failed trace exported %d spans, want all 3", counts[failed])
+	}
+}
+
+func TestProcessorConcurrent(t *testing.T) {
+	policy := NewPolicy(Config{Ratio: 1})
+	tracer, exporter := newTracer(policy)
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed = map[trace.TraceID]bool{}
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if j%10 == 0 {
+					policy.Set(Config{Ratio: float64(i % 2)})
+				}
+				id := startTrace(tracer, j%5 == 0)
+				mu.Lock()
+				failed[id] = j%5 == 0
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	counts := map[trace.TraceID]int{}
+	for _, span := range exporter.GetSpans() {
+		counts[span.SpanContext.TraceID()]++
+	}
+	for id, isFailed := range failed {
+		switch got := counts[id]; {
+		case got != 0 && got != 3:
+			t.Errorf("trace %s exported %d of its 3 spans", id, got)
+		case isFailed && got != 3:
+			t.Errorf("failed trace %s was dropped", id)
+		}
+	}
+}
+
+func TestOverrideConcurrent(t *testing.T) {
+	policy := NewPolicy(Config{Ratio: 0.1})
+	override := NewOverride(policy)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				switch (i + j) % 4 {
+				case 0:
+					override.Start(1, time.Millisecond, nil)
+				case 1:
+					override.Stop()
+				case 2:
+					override.Configure(Config{Ratio: 0.2})
+				default:
+					override.Status()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	override.Stop()
+
+	if got := override.Status(); got.Ratio != 0.2 || got.ConfiguredRatio != 0.2 || got.Until != nil {
+		t.Errorf("status after the overrides = %+v, want the configured ratio 0.2", got)
+	}
+	if got := policy.Get().Ratio; got != 0.2 {
+		t.Errorf("policy ratio = %g, want 0.2", got)
+	}
+}
@@ -0,0 +1,64 @@
+package security
+
+import (
+	"log"
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Event kinds reported to the security.events counter.
+const (
+	AuthFailure       = "auth_failure"
+	SignatureMismatch = "signature_mismatch"
+	RateLimited       = "rate_limited"
+	IPBlocked         = "ip_blocked"
+	ClientBlocked     = "client_blocked"
+)
+
+var (
+	counterOnce sync.Once
+	counter     metric.Int64Counter
+)
+
+func eventCounter() metric.Int64Counter {
+	counterOnce.Do(func() {
+		var err error
+		counter, err = otel.Meter("microservice-meter").Int64Counter("security.events",
+			metric.WithDescription("Requests rejected for security reasons, by event and reason"))
+		if err != nil {
+			log.Printf("failed to create security events counter: %v", err)
+		}
+	})
+
+	return counter
+}
+
+// Record counts a rejected request and attaches a span event describing it.
+// Rejections usually happen in middleware before the handler span exists, so
+// a short securityEvent span is started under the caller's trace instead.
+func Record(r *http.Request, event, reason string, attrs ...attribute.KeyValue) {
+	attrs = append([]attribute.KeyValue{
+		attribute.String("security.event", event),
+		attribute.String("security.reason", reason),
+	}, attrs...)
+
+	if c := eventCounter(); c != nil {
+		c.Add(r.Context(), 1, metric.WithAttributes(attrs[:2]...))
+	}
+
+	if span := trace.SpanFromContext(r.Context()); span.IsRecording() {
+		span.AddEvent(event, trace.WithAttributes(attrs...))
+		return
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	_, span := otel.Tracer("microservice-tracer").Start(ctx, "securityEvent")
+	span.AddEvent(event, trace.WithAttributes(attrs...))
+	span.End()
+}
@@ -0,0 +1,105 @@
+package servertiming
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const HeaderName = "Server-Timing"
+
+type stage struct {
+	name     string
+	duration time.Duration
+}
+
+// Timings collects how long each stage of a request took, so callers can see
+// where the time goes without access to the tracing backend.
+type Timings struct {
+	start time.Time
+
+	mu     sync.Mutex
+	stages []stage
+}
+
+func New() *Timings {
+	return &Timings{start: time.Now()}
+}
+
+// Start times the named stage until the returned function is called.
+func (t *Timings) Start(name string) func() {
+	start := time.Now()
+
+	return func() {
+		t.mu.Lock()
+		t.stages = append(t.stages, stage{name: name, duration: time.Since(start)})
+		t.mu.Unlock()
+	}
+}
+
+// Header formats the stages, plus the total so far, as a Server-Timing value:
+// "validation;dur=0.05, cep;dur=41.2, total;dur=80.3".
+func (t *Timings) Header() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var b strings.Builder
+	for _, s := range t.stages {
+		writeMetric(&b, s.name, s.duration)
+		b.WriteString(", ")
+	}
+	writeMetric(&b, "total", time.Since(t.start))
+
+	return b.String()
+}
+
+func writeMetric(b *strings.Builder, name string, d time.Duration) {
+	b.WriteString(name)
+	b.WriteString(";dur=")
+	b.WriteString(strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 2, 64))
+}
+
+// Attributes reports the stages as server_timing.<stage>_ms span attributes.
+func (t *Timings) Attributes() []attribute.KeyValue {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	attrs := make([]attribute.KeyValue, 0, len(t.stages))
+	for _, s := range t.stages {
+		attrs = append(attrs, attribute.Float64("server_timing."+s.name+"_ms", float64(s.duration)/float64(time.Millisecond)))
+	}
+
+	return attrs
+}
+
+// Wrap returns a writer that adds the Server-Timing header right before the
+// response headers are sent, whichever path ends up writing the response.
+func (t *Timings) Wrap(w http.ResponseWriter) http.ResponseWriter {
+	return &writer{ResponseWriter: w, timings: t}
+}
+
+type writer struct {
+	http.ResponseWriter
+	timings     *Timings
+	wroteHeader bool
+}
+
+func (w *writer) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Set(HeaderName, w.timings.Header())
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,157 @@
+package slo
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/bufpool"
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+)
+
+// bucketWidth is the resolution of the in-memory counts: windows are summed
+// from whole buckets, the current one included.
+const bucketWidth = time.Minute
+
+type bucket struct {
+	// minute is the bucket start, in minutes since the Unix epoch.
+	minute int64
+	good   uint64
+	bad    uint64
+}
+
+// series counts the events of one route and SLI in a ring of buckets long
+// enough for the largest window.
+type series struct {
+	route   string
+	sli     string
+	buckets []bucket
+}
+
+// track returns the series of route and sli, creating it on first use.
+func (rec *Recorder) track(route, sli string) *series {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	for _, s := range rec.series {
+		if s.route == route && s.sli == sli {
+			return s
+		}
+	}
+
+	var longest time.Duration
+	for _, window := range rec.cfg.Windows {
+		longest = max(longest, window)
+	}
+
+	s := &series{route: route, sli: sli, buckets: make([]bucket, longest/bucketWidth+1)}
+	rec.series = append(rec.series, s)
+	return s
+}
+
+func (s *series) add(now time.Time, good bool) {
+	minute := now.Unix() / int64(bucketWidth/time.Second)
+	b := &s.buckets[minute%int64(len(s.buckets))]
+	if b.minute != minute {
+		*b = bucket{minute: minute}
+	}
+
+	if good {
+		b.good++
+	} else {
+		b.bad++
+	}
+}
+
+// sum adds up the buckets that started within window before now.
+func (s *series) sum(now time.Time, window time.Duration) (good, bad uint64) {
+	minute := now.Unix() / int64(bucketWidth/time.Second)
+	oldest := minute - int64(window/bucketWidth) + 1
+
+	for _, b := range s.buckets {
+		if b.minute >= oldest && b.minute <= minute {
+			good += b.good
+			bad += b.bad
+		}
+	}
+	return good, bad
+}
+
+type windowStatus struct {
+	Window string `json:"window"`
+	Good   uint64 `json:"good"`
+	Bad    uint64 `json:"bad"`
+	// Attainment is the share of good events, 1 when there were none.
+	Attainment float64 `json:"attainment"`
+	// BurnRate is how fast the budget is spent: 1 spends exactly the budget
+	// over the window, above 1 exhausts it early.
+	BurnRate float64 `json:"burn_rate"`
+	// ErrorBudgetRemaining is the share of the window's budget left; it goes
+	// negative once the objective is missed.
+	ErrorBudgetRemaining float64 `json:"error_budget_remaining"`
+	// Partial is set when the process has been up for less than the window,
+	// since the counts start over at every restart.
+	Partial bool `json:"partial"`
+}
+
+type objectiveStatus struct {
+	Route   string         `json:"route"`
+	SLI     string         `json:"sli"`
+	Target  float64        `json:"target"`
+	Windows []windowStatus `json:"windows"`
+}
+
+type statusResponse struct {
+	Objectives []objectiveStatus `json:"objectives"`
+}
+
+// StatusHandler reports the attainment and remaining error budget of every
+// objective over each configured window, from the counts of this instance.
+// It is meant for quick triage; the slo.events counter remains the source
+// for alerting and fleet-wide views.
+func (rec *Recorder) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	now := clock.Or(rec.Clock).Now()
+	uptime := now.Sub(rec.started)
+
+	rec.mu.Lock()
+	response := statusResponse{Objectives: make([]objectiveStatus, 0, len(rec.series))}
+	for _, s := range rec.series {
+		target := rec.cfg.target(s.sli)
+		objective := objectiveStatus{Route: s.route, SLI: s.sli, Target: target}
+
+		for _, window := range rec.cfg.Windows {
+			good, bad := s.sum(now, window)
+			objective.Windows = append(objective.Windows, status(window, good, bad, target, uptime < window))
+		}
+		response.Objectives = append(response.Objectives, objective)
+	}
+	rec.mu.Unlock()
+
+	if err := bufpool.WriteJSON(w, http.StatusOK, "application/json", response); err != nil {
+		log.Printf("failed to write slo status: %v", err)
+	}
+}
+
+func status(window time.Duration, good, bad uint64, target float64, partial bool) windowStatus {
+	ws := windowStatus{Window: formatWindow(window), Good: good, Bad: bad, Attainment: 1, ErrorBudgetRemaining: 1, Partial: partial}
+	if total := good + bad; total > 0 {
+		errorRate := float64(bad) / float64(total)
+		ws.Attainment = 1 - errorRate
+		ws.BurnRate = errorRate / (1 - target)
+		ws.ErrorBudgetRemaining = 1 - ws.BurnRate
+	}
+	return ws
+}
+
+// formatWindow drops the zero units time.Duration prints, so 1h0m0s reads 1h.
+func formatWindow(window time.Duration) string {
+	s := window.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
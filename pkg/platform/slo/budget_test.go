@@ -0,0 +1,235 @@
+package slo
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+)
+
+// start is aligned on a minute, so offsets map to buckets predictably.
+var start = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		good, bad     uint64
+		target        float64
+		wantAttain    float64
+		wantBurn      float64
+		wantRemaining float64
+	}{
+		{name: "no events", target: 0.99, wantAttain: 1, wantBurn: 0, wantRemaining: 1},
+		{name: "all good", good: 100, target: 0.99, wantAttain: 1, wantBurn: 0, wantRemaining: 1},
+		{name: "half the budget", good: 199, bad: 1, target: 0.99, wantAttain: 0.995, wantBurn: 0.5, wantRemaining: 0.5},
+		{name: "exactly the budget", good: 99, bad: 1, target: 0.99, wantAttain: 0.99, wantBurn: 1, wantRemaining: 0},
+		{name: "five times the budget", good: 95, bad: 5, target: 0.99, wantAttain: 0.95, wantBurn: 5, wantRemaining: -4},
+		{name: "three nines", good: 999, bad: 1, target: 0.999, wantAttain: 0.999, wantBurn: 1, wantRemaining: 0},
+		{name: "all bad", bad: 10, target: 0.9, wantAttain: 0, wantBurn: 10, wantRemaining: -9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := status(time.Hour, tt.good, tt.bad, tt.target, false)
+
+			if !approx(got.Attainment, tt.wantAttain) {
+				t.Errorf("attainment = %v, want %v", got.Attainment, tt.wantAttain)
+			}
+			if !approx(got.BurnRate, tt.wantBurn) {
+				t.Errorf("burn rate = %v, want %v", got.BurnRate, tt.wantBurn)
+			}
+			if !approx(got.ErrorBudgetRemaining, tt.wantRemaining) {
+				t.Errorf("error budget remaining = %v, want %v", got.ErrorBudgetRemaining, tt.wantRemaining)
+			}
+			if got.Window != "1h" || got.Good != tt.good || got.Bad != tt.bad {
+				t.Errorf("status = %+v, want the 1h window with the counts given", got)
+			}
+		})
+	}
+}
+
+func TestSeriesSum(t *testing.T) {
+	type event struct {
+		at   time.Duration
+		good bool
+	}
+
+	tests := []struct {
+		name     string
+		events   []event
+		now      time.Duration
+		window   time.Duration
+		wantGood uint64
+		wantBad  uint64
+	}{
+		{
+			name:     "current bucket counted",
+			events:   []event{{at: 0, good: true}, {at: 30 * time.Second, good: false}},
+			now:      59 * time.Second,
+			window:   time.Minute,
+			wantGood: 1,
+			wantBad:  1,
+		},
+		{
+			name:     "last bucket of the window",
+			events:   []event{{at: 0, good: true}},
+			now:      4*time.Minute + 59*time.Second,
+			window:   5 * time.Minute,
+			wantGood: 1,
+		},
+		{
+			name:   "bucket past the window",
+			events: []event{{at: 0, good: true}},
+			now:    5 * time.Minute,
+			window: 5 * time.Minute,
+		},
+		{
+			name:     "only the window's share",
+			events:   []event{{at: 0, good: false}, {at: 10 * time.Minute, good: true}, {at: 12 * time.Minute, good: false}},
+			now:      12 * time.Minute,
+			window:   5 * time.Minute,
+			wantGood: 1,
+			wantBad:  1,
+		},
+		{
+			// The ring holds 61 buckets for the hour: a minute later the
+			// first slot is reused and its old counts dropped.
+			name:     "ring slot reused",
+			events:   []event{{at: 0, good: false}, {at: 61 * time.Minute, good: true}},
+			now:      61 * time.Minute,
+			window:   2 * time.Hour,
+			wantGood: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := NewRecorder(Config{Windows: []time.Duration{5 * time.Minute, time.Hour}})
+			s := rec.track("/r", Availability)
+			for _, e := range tt.events {
+				s.add(start.Add(e.at), e.good)
+			}
+
+			good, bad := s.sum(start.Add(tt.now), tt.window)
+			if good != tt.wantGood || bad != tt.wantBad {
+				t.Errorf("sum = %d good, %d bad, want %d, %d", good, bad, tt.wantGood, tt.wantBad)
+			}
+		})
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	clk := clock.NewFake(start)
+	rec := NewRecorder(Config{
+		LatencyThreshold:   time.Hour,
+		AvailabilityTarget: 0.99,
+		LatencyTarget:      0.9,
+		Windows:            []time.Duration{5 * time.Minute, time.Hour},
+	})
+	rec.Clock = clk
+	rec.started = start
+
+	handler := rec.Middleware("/r", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(r.URL.Query().Get("status"))
+		w.WriteHeader(status)
+	}))
+	serve := func(status, n int) {
+		for i := 0; i < n; i++ {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/r?status="+strconv.Itoa(status), nil))
+		}
+	}
+	statusAt := func(offset time.Duration) map[string][]windowStatus {
+		t.Helper()
+
+		clk.Set(start.Add(offset))
+		w := httptest.NewRecorder()
+		rec.StatusHandler(w, httptest.NewRequest(http.MethodGet, "/admin/slo", nil))
+
+		var response statusResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		windows := map[string][]windowStatus{}
+		for _, objective := range response.Objectives {
+			windows[objective.SLI] = objective.Windows
+		}
+		return windows
+	}
+
+	serve(http.StatusOK, 90)
+	serve(http.StatusInternalServerError, 10)
+	clk.Set(start.Add(30 * time.Minute))
+	serve(http.StatusOK, 98)
+	serve(http.StatusBadGateway, 2)
+	// Client errors count as available.
+	serve(http.StatusNotFound, 100)
+
+	tests := []struct {
+		name   string
+		offset time.Duration
+		sli    string
+		want   []windowStatus
+	}{
+		{
+			name:   "availability burning both windows",
+			offset: 30 * time.Minute,
+			sli:    Availability,
+			want: []windowStatus{
+				{Window: "5m", Good: 198, Bad: 2, Attainment: 0.99, BurnRate: 1, ErrorBudgetRemaining: 0},
+				{Window: "1h", Good: 288, Bad: 12, Attainment: 0.96, BurnRate: 4, ErrorBudgetRemaining: -3, Partial: true},
+			},
+		},
+		{
+			name:   "latency leaves failed requests out",
+			offset: 30 * time.Minute,
+			sli:    Latency,
+			want: []windowStatus{
+				{Window: "5m", Good: 198, Attainment: 1, ErrorBudgetRemaining: 1},
+				{Window: "1h", Good: 288, Attainment: 1, ErrorBudgetRemaining: 1, Partial: true},
+			},
+		},
+		{
+			name:   "short window recovered",
+			offset: 40 * time.Minute,
+			sli:    Availability,
+			want: []windowStatus{
+				{Window: "5m", Attainment: 1, ErrorBudgetRemaining: 1},
+				{Window: "1h", Good: 288, Bad: 12, Attainment: 0.96, BurnRate: 4, ErrorBudgetRemaining: -3, Partial: true},
+			},
+		},
+		{
+			name:   "first events out of the hour",
+			offset: 60 * time.Minute,
+			sli:    Availability,
+			want: []windowStatus{
+				{Window: "5m", Attainment: 1, ErrorBudgetRemaining: 1},
+				{Window: "1h", Good: 198, Bad: 2, Attainment: 0.99, BurnRate: 1, ErrorBudgetRemaining: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := statusAt(tt.offset)[tt.sli]
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d windows, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				g := got[i]
+				if g.Window != want.Window || g.Good != want.Good || g.Bad != want.Bad || g.Partial != want.Partial ||
+					!approx(g.Attainment, want.Attainment) || !approx(g.BurnRate, want.BurnRate) || !approx(g.ErrorBudgetRemaining, want.ErrorBudgetRemaining) {
+					t.Errorf("window %d = %+v, want %+v", i, g, want)
+				}
+			}
+		})
+	}
+}
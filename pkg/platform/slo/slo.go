@@ -0,0 +1,183 @@
+package slo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Service level indicators recorded for every measured route.
+const (
+	// Availability counts a request as good unless it was answered with a
+	// 5xx status.
+	Availability = "availability"
+	// Latency counts a request as good when it was answered within the
+	// latency threshold of its route. Requests that already failed
+	// availability are left out, so a fast error never counts as fast.
+	Latency = "latency"
+)
+
+// Config sets the objectives of each route and the windows the error budget
+// is reported over.
+type Config struct {
+	// LatencyThreshold applies to routes without an entry in RouteThresholds.
+	LatencyThreshold time.Duration
+	// RouteThresholds maps a route template to its own threshold.
+	RouteThresholds map[string]time.Duration
+
+	// AvailabilityTarget and LatencyTarget are the share of good events each
+	// SLI aims for, such as 0.999.
+	AvailabilityTarget float64
+	LatencyTarget      float64
+	// Windows are the trailing periods reported by StatusHandler.
+	Windows []time.Duration
+}
+
+func (c Config) target(sli string) float64 {
+	if sli == Latency {
+		return c.LatencyTarget
+	}
+	return c.AvailabilityTarget
+}
+
+func (c Config) threshold(route string) time.Duration {
+	if threshold, ok := c.RouteThresholds[route]; ok {
+		return threshold
+	}
+	return c.LatencyThreshold
+}
+
+// ParseThresholds reads per-route thresholds such as
+// "/city-by-zipcode=300ms,/usage=100ms".
+func ParseThresholds(raw string) (map[string]time.Duration, error) {
+	thresholds := map[string]time.Duration{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, value, ok := strings.Cut(entry, "=")
+		if !ok || route == "" {
+			return nil, fmt.Errorf("invalid latency threshold %q, expected route=duration", entry)
+		}
+
+		threshold, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || threshold <= 0 {
+			return nil, fmt.Errorf("invalid latency threshold for %s: %q is not a positive duration", route, value)
+		}
+		thresholds[strings.TrimSpace(route)] = threshold
+	}
+
+	return thresholds, nil
+}
+
+// ParseWindows reads a comma-separated list of durations such as
+// "5m,1h,6h". Windows shorter than a minute cannot be told apart by the
+// in-memory counts and are rejected.
+func ParseWindows(raw string) ([]time.Duration, error) {
+	var windows []time.Duration
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		window, err := time.ParseDuration(entry)
+		if err != nil || window < bucketWidth {
+			return nil, fmt.Errorf("invalid window %q, expected a duration of at least %s", entry, bucketWidth)
+		}
+		windows = append(windows, window)
+	}
+
+	return windows, nil
+}
+
+// Recorder classifies requests as good or bad events of each SLI. Events are
+// exported as the slo.events counter, labelled by route, SLI and result, so an
+// SLO is the ratio of good to total events and its burn rate can be alerted on
+// over any pair of windows without deriving it from latency histograms. They
+// are also kept in memory over the configured windows, for StatusHandler.
+type Recorder struct {
+	// Clock defaults to the wall clock when nil.
+	Clock clock.Clock
+
+	cfg     Config
+	events  metric.Int64Counter
+	started time.Time
+
+	mu     sync.Mutex
+	series []*series
+}
+
+func NewRecorder(cfg Config) *Recorder {
+	meter := otel.Meter("microservice-meter")
+
+	events, err := meter.Int64Counter("slo.events",
+		metric.WithDescription("Requests classified as good or bad, by route and service level indicator"))
+	if err != nil {
+		log.Printf("failed to create slo events counter: %v", err)
+	}
+
+	return &Recorder{cfg: cfg, events: events, started: time.Now()}
+}
+
+// Middleware measures the requests of route, which should be the route
+// template rather than the request path to keep the series bounded.
+func (rec *Recorder) Middleware(route string, next http.Handler) http.Handler {
+	threshold := rec.cfg.threshold(route)
+	availability := rec.track(route, Availability)
+	latency := rec.track(route, Latency)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := clock.Or(rec.Clock).Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		available := recorder.status < http.StatusInternalServerError
+		rec.record(r.Context(), availability, available)
+		if available {
+			rec.record(r.Context(), latency, clock.Or(rec.Clock).Now().Sub(start) <= threshold)
+		}
+	})
+}
+
+func (rec *Recorder) record(ctx context.Context, s *series, good bool) {
+	rec.mu.Lock()
+	s.add(clock.Or(rec.Clock).Now(), good)
+	rec.mu.Unlock()
+
+	if rec.events == nil {
+		return
+	}
+
+	result := "bad"
+	if good {
+		result = "good"
+	}
+	rec.events.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("http.route", s.route),
+		attribute.String("slo.sli", s.sli),
+		attribute.String("slo.result", result),
+	))
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
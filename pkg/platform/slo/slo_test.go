@@ -0,0 +1,128 @@
+package slo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+	"github.com/luis-olivetti/go-observability/pkg/platform/metrictesting"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMiddleware(t *testing.T) {
+	cfg := Config{
+		LatencyThreshold: 300 * time.Millisecond,
+		RouteThresholds:  map[string]time.Duration{"/fast": 50 * time.Millisecond},
+		Windows:          []time.Duration{time.Hour},
+	}
+
+	tests := []struct {
+		name             string
+		route            string
+		status           int
+		took             time.Duration
+		wantAvailability string
+		// wantLatency is empty when the request is left out of the SLI.
+		wantLatency string
+	}{
+		{name: "fast and fine", route: "/slow", status: http.StatusOK, took: 100 * time.Millisecond, wantAvailability: "good", wantLatency: "good"},
+		{name: "at the threshold", route: "/slow", status: http.StatusOK, took: 300 * time.Millisecond, wantAvailability: "good", wantLatency: "good"},
+		{name: "past the threshold", route: "/slow", status: http.StatusOK, took: 301 * time.Millisecond, wantAvailability: "good", wantLatency: "bad"},
+		{name: "route threshold", route: "/fast", status: http.StatusOK, took: 100 * time.Millisecond, wantAvailability: "good", wantLatency: "bad"},
+		{name: "client error", route: "/slow", status: http.StatusUnprocessableEntity, wantAvailability: "good", wantLatency: "good"},
+		{name: "server error", route: "/slow", status: http.StatusBadGateway, wantAvailability: "bad"},
+		{name: "slow server error", route: "/slow", status: http.StatusInternalServerError, took: time.Second, wantAvailability: "bad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := metrictesting.Install()
+			t.Cleanup(func() { metrics.Shutdown(context.Background()) })
+
+			clk := clock.NewFake(start)
+			rec := NewRecorder(cfg)
+			rec.Clock = clk
+			handler := rec.Middleware(tt.route, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				clk.Advance(tt.took)
+				w.WriteHeader(tt.status)
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.route, nil))
+
+			route := attribute.String("http.route", tt.route)
+			metrics.AssertSum(t, "slo.events", 1, route,
+				attribute.String("slo.sli", Availability), attribute.String("slo.result", tt.wantAvailability))
+
+			latency := attribute.String("slo.sli", Latency)
+			if tt.wantLatency == "" {
+				if got, ok := metrictesting.Sum(collect(t, metrics), "slo.events", latency); ok {
+					t.Errorf("latency events = %v, want none for a failed request", got)
+				}
+				return
+			}
+			metrics.AssertSum(t, "slo.events", 1, route, latency, attribute.String("slo.result", tt.wantLatency))
+
+			// The same classification is kept in memory for StatusHandler.
+			good, bad := rec.track(tt.route, Latency).sum(clk.Now(), time.Hour)
+			if (tt.wantLatency == "good") != (good == 1 && bad == 0) {
+				t.Errorf("in-memory latency counts = %d good, %d bad, want one %s", good, bad, tt.wantLatency)
+			}
+		})
+	}
+}
+
+func collect(t *testing.T, metrics *metrictesting.Reader) metricdata.ResourceMetrics {
+	t.Helper()
+
+	rm, err := metrics.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rm
+}
+
+func TestParseThresholds(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{raw: "", want: map[string]time.Duration{}},
+		{raw: "/city-by-zipcode=300ms, /usage = 100ms", want: map[string]time.Duration{"/city-by-zipcode": 300 * time.Millisecond, "/usage": 100 * time.Millisecond}},
+		{raw: "/usage", wantErr: true},
+		{raw: "=100ms", wantErr: true},
+		{raw: "/usage=fast", wantErr: true},
+		{raw: "/usage=0s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseThresholds(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseThresholds(%q) error = %v, want error %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseThresholds(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+		for route, threshold := range tt.want {
+			if got[route] != threshold {
+				t.Errorf("ParseThresholds(%q)[%s] = %s, want %s", tt.raw, route, got[route], threshold)
+			}
+		}
+	}
+}
+
+func TestParseWindows(t *testing.T) {
+	got, err := ParseWindows("5m, 1h,6h")
+	if err != nil || len(got) != 3 || got[0] != 5*time.Minute || got[2] != 6*time.Hour {
+		t.Errorf("ParseWindows = %v, %v, want 5m, 1h and 6h", got, err)
+	}
+	for _, raw := range []string{"30s", "soon"} {
+		if _, err := ParseWindows(raw); err == nil {
+			t.Errorf("ParseWindows(%q) succeeded, want an error", raw)
+		}
+	}
+}
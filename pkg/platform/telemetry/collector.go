@@ -0,0 +1,72 @@
+package telemetry
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// watchCollector tracks the connection to the collector without ever
+// blocking the service on it: the state is exported as the
+// telemetry.collector.connected gauge (1 or 0) and logged when it flips, so
+// a degraded observability pipeline is visible while traffic keeps flowing.
+// Spans produced meanwhile are retried and, once the export queue is full,
+// dropped.
+func watchCollector(conn *grpc.ClientConn, target string) {
+	var connected atomic.Bool
+
+	meter := otel.Meter("microservice-meter")
+	_, err := meter.Int64ObservableGauge("telemetry.collector.connected",
+		metric.WithDescription("Whether the OTLP collector connection is ready (1) or degraded (0)"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			if connected.Load() {
+				o.Observe(1)
+			} else {
+				o.Observe(0)
+			}
+			return nil
+		}))
+	if err != nil {
+		log.Printf("failed to create collector connectivity gauge: %v", err)
+	}
+
+	conn.Connect()
+
+	go func() {
+		// gRPC retries with backoff, cycling through connecting and failure;
+		// only the first failure of an outage is logged.
+		degraded := false
+		state := conn.GetState()
+		for {
+			switch state {
+			case connectivity.Ready:
+				degraded = false
+				if !connected.Swap(true) {
+					log.Printf("connected to collector at %s", target)
+				}
+			case connectivity.TransientFailure:
+				connected.Store(false)
+				if !degraded {
+					degraded = true
+					log.Printf("collector at %s is unreachable; telemetry is degraded, serving continues", target)
+				}
+			case connectivity.Idle:
+				// A dropped connection goes idle until the next export;
+				// reconnect now so an outage shows up as a failure.
+				conn.Connect()
+			case connectivity.Shutdown:
+				return
+			}
+
+			if !conn.WaitForStateChange(context.Background(), state) {
+				return
+			}
+			state = conn.GetState()
+		}
+	}()
+}
@@ -0,0 +1,67 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// BuildSHAKey is the attribute holding the VCS revision the binary was built
+// from.
+const BuildSHAKey = attribute.Key("build.sha")
+
+// Deployment identifies the build running and where it is deployed. Empty
+// fields are left out of the attributes.
+type Deployment struct {
+	Environment string
+	Region      string
+	Version     string
+	Revision    string
+}
+
+// Attributes returns the deployment as span and resource attributes.
+func (d Deployment) Attributes() []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if d.Environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironment(d.Environment))
+	}
+	if d.Region != "" {
+		attrs = append(attrs, semconv.CloudRegion(d.Region))
+	}
+	if d.Version != "" {
+		attrs = append(attrs, semconv.ServiceVersion(d.Version))
+	}
+	if d.Revision != "" {
+		attrs = append(attrs, BuildSHAKey.String(d.Revision))
+	}
+
+	return attrs
+}
+
+// DeploymentProcessor stamps the deployment onto every span as it starts.
+// The resource carries it too, but backends that aggregate spans across
+// resources drop resource attributes, and a deploy should stay filterable
+// there.
+type DeploymentProcessor struct {
+	attrs []attribute.KeyValue
+}
+
+func NewDeploymentProcessor(d Deployment) DeploymentProcessor {
+	return DeploymentProcessor{attrs: d.Attributes()}
+}
+
+func (p DeploymentProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	s.SetAttributes(p.attrs...)
+}
+
+func (DeploymentProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+func (DeploymentProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+func (DeploymentProcessor) ForceFlush(context.Context) error {
+	return nil
+}
@@ -0,0 +1,27 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FollowUp returns the start options of a span for work a request triggers
+// but does not wait for, such as a queued job or a delivery retried later.
+// The span starts a new trace with a link back to the span active in ctx:
+// the request trace ends when the request does, and the follow-up work is
+// still one click away from it.
+func FollowUp(ctx context.Context) []trace.SpanStartOption {
+	return []trace.SpanStartOption{
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(ctx, attribute.String("link.type", "follows_from"))),
+	}
+}
+
+// Detach returns a context for follow-up work: it keeps the values of ctx,
+// including baggage and the span FollowUp links to, but not its deadline or
+// cancellation, which end with the request.
+func Detach(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
@@ -0,0 +1,327 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/redact"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+	collectorlogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// LoggerName is the instrumentation scope of the service's log records.
+const LoggerName = "microservice-logger"
+
+// Log records are exported in batches of up to logBatchSize, at least every
+// logExportInterval. At most logQueueSize records wait for export; past it
+// new records are dropped so logging never blocks a request.
+const (
+	logBatchSize      = 512
+	logExportInterval = time.Second
+	logExportTimeout  = 10 * time.Second
+	logQueueSize      = 4096
+)
+
+// logSender delivers one export request to the collector.
+type logSender interface {
+	send(ctx context.Context, req *collectorlogspb.ExportLogsServiceRequest) error
+}
+
+// grpcLogSender exports over the connection the span exporter uses.
+type grpcLogSender struct {
+	client collectorlogspb.LogsServiceClient
+}
+
+func (s grpcLogSender) send(ctx context.Context, req *collectorlogspb.ExportLogsServiceRequest) error {
+	_, err := s.client.Export(ctx, req)
+	return err
+}
+
+// httpLogSender posts the request as protobuf to the /v1/logs endpoint of
+// the collector.
+type httpLogSender struct {
+	url    string
+	client *http.Client
+}
+
+func (s *httpLogSender) send(ctx context.Context, req *collectorlogspb.ExportLogsServiceRequest) error {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode logs: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector answered %s", resp.Status)
+	}
+	return nil
+}
+
+// logExporter ships log records to the collector the span exporter uses,
+// under the same resource, so logs and traces of an instance line up in the
+// backend.
+type logExporter struct {
+	sender   logSender
+	resource *resourcepb.Resource
+
+	queue chan *logspb.LogRecord
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+
+	// failing is only touched by the export loop.
+	failing bool
+}
+
+func newLogExporter(sender logSender, res *resource.Resource) *logExporter {
+	e := &logExporter{
+		sender:   sender,
+		resource: &resourcepb.Resource{Attributes: keyValues(res.Attributes())},
+		queue:    make(chan *logspb.LogRecord, logQueueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+func (e *logExporter) enqueue(record *logspb.LogRecord) {
+	select {
+	case e.queue <- record:
+	default:
+	}
+}
+
+func (e *logExporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(logExportInterval)
+	defer ticker.Stop()
+
+	var batch []*logspb.LogRecord
+	for {
+		select {
+		case record := <-e.queue:
+			batch = append(batch, record)
+			if len(batch) >= logBatchSize {
+				e.export(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			e.export(batch)
+			batch = nil
+		case <-e.stop:
+			for {
+				select {
+				case record := <-e.queue:
+					batch = append(batch, record)
+				default:
+					e.export(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// export sends one batch. Failures are reported on stderr, once per outage:
+// logging them through slog would queue them for export in turn.
+func (e *logExporter) export(batch []*logspb.LogRecord) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), logExportTimeout)
+	defer cancel()
+
+	err := e.sender.send(ctx, &collectorlogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: e.resource,
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope:      &commonpb.InstrumentationScope{Name: LoggerName},
+				LogRecords: batch,
+			}},
+		}},
+	})
+	switch {
+	case err != nil && !e.failing:
+		e.failing = true
+		fmt.Fprintf(os.Stderr, "%s failed to export logs, dropping them until the collector accepts them: %v\n", time.Now().Format("2006/01/02 15:04:05"), err)
+	case err == nil && e.failing:
+		e.failing = false
+		fmt.Fprintf(os.Stderr, "%s log export recovered\n", time.Now().Format("2006/01/02 15:04:05"))
+	}
+}
+
+// shutdown exports the records still queued.
+func (e *logExporter) shutdown(ctx context.Context) error {
+	e.once.Do(func() { close(e.stop) })
+
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to flush logs: %w", ctx.Err())
+	}
+}
+
+// LogLevel is the lowest level logHandler prints and exports; info unless
+// set otherwise.
+var LogLevel = new(slog.LevelVar)
+
+// logHandler is the slog handler installed by Setup; the standard log
+// package writes through it too. Each record is printed to out in the format
+// of the standard logger, so the console output stays as it was, and queued
+// for export with the trace and span of its context when there is an
+// exporter. Secrets and CEPs in the message and string attributes are
+// scrubbed as the CEP policy says for logs.
+type logHandler struct {
+	out      io.Writer
+	mu       *sync.Mutex
+	exporter *logExporter
+	scrubber *redact.Scrubber
+
+	attrs  []slog.Attr
+	prefix string
+}
+
+func newLogHandler(out io.Writer, exporter *logExporter, scrubber *redact.Scrubber) *logHandler {
+	return &logHandler{out: out, mu: &sync.Mutex{}, exporter: exporter, scrubber: scrubber}
+}
+
+func (h *logHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= LogLevel.Level()
+}
+
+func (h *logHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := append([]slog.Attr(nil), h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, h.qualify(a))
+		return true
+	})
+	message := h.scrubber.Log(r.Message)
+
+	line := r.Time.Format("2006/01/02 15:04:05 ")
+	if r.Level != slog.LevelInfo {
+		line += r.Level.String() + " "
+	}
+	line += message
+	for _, a := range attrs {
+		line += " " + a.String()
+	}
+
+	h.mu.Lock()
+	_, err := io.WriteString(h.out, line+"\n")
+	h.mu.Unlock()
+
+	record := &logspb.LogRecord{
+		TimeUnixNano:         uint64(r.Time.UnixNano()),
+		ObservedTimeUnixNano: uint64(time.Now().UnixNano()),
+		SeverityNumber:       severity(r.Level),
+		SeverityText:         r.Level.String(),
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: message}},
+	}
+	for _, a := range attrs {
+		record.Attributes = append(record.Attributes, &commonpb.KeyValue{Key: a.Key, Value: anyValue(a.Value)})
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		traceID, spanID := sc.TraceID(), sc.SpanID()
+		record.TraceId = traceID[:]
+		record.SpanId = spanID[:]
+		record.Flags = uint32(sc.TraceFlags())
+	}
+	if h.exporter != nil {
+		h.exporter.enqueue(record)
+	}
+
+	return err
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		clone.attrs = append(clone.attrs, h.qualify(a))
+	}
+	return &clone
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+func (h *logHandler) qualify(a slog.Attr) slog.Attr {
+	value := a.Value.Resolve()
+	if value.Kind() == slog.KindString {
+		value = slog.StringValue(h.scrubber.Log(value.String()))
+	}
+	return slog.Attr{Key: h.prefix + a.Key, Value: value}
+}
+
+// severity maps slog levels onto OTLP severity numbers; both are spaced by
+// four between DEBUG, INFO, WARN and ERROR.
+func severity(level slog.Level) logspb.SeverityNumber {
+	n := int(logspb.SeverityNumber_SEVERITY_NUMBER_INFO) + int(level)
+	n = max(n, int(logspb.SeverityNumber_SEVERITY_NUMBER_TRACE))
+	n = min(n, int(logspb.SeverityNumber_SEVERITY_NUMBER_FATAL4))
+	return logspb.SeverityNumber(n)
+}
+
+func anyValue(v slog.Value) *commonpb.AnyValue {
+	switch v.Kind() {
+	case slog.KindBool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v.Bool()}}
+	case slog.KindInt64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v.Int64()}}
+	case slog.KindFloat64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v.Float64()}}
+	default:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v.String()}}
+	}
+}
+
+func keyValues(attrs []attribute.KeyValue) []*commonpb.KeyValue {
+	kvs := make([]*commonpb.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		var value *commonpb.AnyValue
+		switch a.Value.Type() {
+		case attribute.BOOL:
+			value = &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: a.Value.AsBool()}}
+		case attribute.INT64:
+			value = &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: a.Value.AsInt64()}}
+		case attribute.FLOAT64:
+			value = &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: a.Value.AsFloat64()}}
+		default:
+			value = &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: a.Value.Emit()}}
+		}
+		kvs = append(kvs, &commonpb.KeyValue{Key: string(a.Key), Value: value})
+	}
+	return kvs
+}
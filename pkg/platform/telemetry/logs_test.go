@@ -0,0 +1,86 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/redact"
+	"go.opentelemetry.io/otel/sdk/resource"
+	collectorlogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// memoryLogSender keeps the exported records in memory.
+type memoryLogSender struct {
+	mu      sync.Mutex
+	records []*logspb.LogRecord
+}
+
+func (s *memoryLogSender) send(_ context.Context, req *collectorlogspb.ExportLogsServiceRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rl := range req.ResourceLogs {
+		for _, sl := range rl.ScopeLogs {
+			s.records = append(s.records, sl.LogRecords...)
+		}
+	}
+	return nil
+}
+
+func TestLogHandlerScrubsExportedRecords(t *testing.T) {
+	scrubber, err := redact.NewScrubber(nil, redact.CEPPolicy{Logs: redact.CEPMasked})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := &memoryLogSender{}
+	exporter := newLogExporter(sender, resource.Empty())
+	var out bytes.Buffer
+	logger := slog.New(newLogHandler(&out, exporter, scrubber)).
+		With("cep", "29902-555").
+		WithGroup("upstream")
+
+	logger.Info("lookup of 29902555 failed",
+		"url", "https://api.weatherapi.com/v1/current.json?key=s3cret&q=Linhares",
+		"status", 502)
+
+	if err := exporter.shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.records) != 1 {
+		t.Fatalf("exported %d records, want 1", len(sender.records))
+	}
+	record := sender.records[0]
+
+	if got := record.Body.GetStringValue(); got != "lookup of 299***** failed" {
+		t.Errorf("body = %q, want the CEP masked", got)
+	}
+	want := map[string]string{
+		"cep":          "299*****",
+		"upstream.url": "https://api.weatherapi.com/v1/current.json?key=[REDACTED]&q=Linhares",
+	}
+	for _, kv := range record.Attributes {
+		if value, ok := want[kv.Key]; ok {
+			if got := kv.Value.GetStringValue(); got != value {
+				t.Errorf("attribute %s = %q, want %q", kv.Key, got, value)
+			}
+			delete(want, kv.Key)
+		}
+		if kv.Key == "upstream.status" && kv.Value.GetIntValue() != 502 {
+			t.Errorf("attribute upstream.status = %v, want 502 untouched", kv.Value)
+		}
+	}
+	for key := range want {
+		t.Errorf("exported record has no attribute %s", key)
+	}
+
+	for _, leaked := range []string{"29902555", "29902-555", "s3cret"} {
+		if strings.Contains(out.String(), leaked) {
+			t.Errorf("console line %q shows %q", out.String(), leaked)
+		}
+	}
+}
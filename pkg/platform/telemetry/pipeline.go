@@ -0,0 +1,137 @@
+package telemetry
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// spanQueueSize bounds the spans waiting for export, the batch being
+// exported included. Past it new spans are dropped, and counted, so an
+// exporter that cannot keep up never holds back a request.
+const spanQueueSize = 2048
+
+// exportStats counts the spans that went through the export pipeline. A span
+// is pending from the moment it ends until the exporter reports on it.
+type exportStats struct {
+	pending  atomic.Int64
+	exported atomic.Int64
+	failed   atomic.Int64
+	dropped  atomic.Int64
+}
+
+// exportCounts is a snapshot of exportStats.
+type exportCounts struct {
+	pending, exported, failed, dropped int64
+}
+
+func (s *exportStats) snapshot() exportCounts {
+	return exportCounts{
+		pending:  s.pending.Load(),
+		exported: s.exported.Load(),
+		failed:   s.failed.Load(),
+		dropped:  s.dropped.Load(),
+	}
+}
+
+// newExportPipeline batches spans into exporter while keeping stats of them:
+// the batch processor is never handed more spans than its queue holds, so
+// every span it would have dropped silently is dropped and counted here
+// instead.
+func newExportPipeline(exporter sdktrace.SpanExporter, stats *exportStats) sdktrace.SpanProcessor {
+	batcher := sdktrace.NewBatchSpanProcessor(countingExporter{SpanExporter: exporter, stats: stats},
+		sdktrace.WithMaxQueueSize(spanQueueSize))
+	return queueGuard{SpanProcessor: batcher, stats: stats}
+}
+
+// countingExporter reports the outcome of every batch to stats. A failed
+// batch is not retried by the batch processor: its spans are lost.
+type countingExporter struct {
+	sdktrace.SpanExporter
+	stats *exportStats
+}
+
+func (e countingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+
+	n := int64(len(spans))
+	if err != nil {
+		e.stats.failed.Add(n)
+	} else {
+		e.stats.exported.Add(n)
+	}
+	e.stats.pending.Add(-n)
+	return err
+}
+
+// queueGuard drops ended spans once spanQueueSize of them are pending.
+type queueGuard struct {
+	sdktrace.SpanProcessor
+	stats *exportStats
+}
+
+func (g queueGuard) OnEnd(s sdktrace.ReadOnlySpan) {
+	// The batch processor skips unsampled spans; they are not pending.
+	if !s.SpanContext().IsSampled() {
+		return
+	}
+
+	if g.stats.pending.Add(1) > spanQueueSize {
+		g.stats.pending.Add(-1)
+		g.stats.dropped.Add(1)
+		return
+	}
+	g.SpanProcessor.OnEnd(s)
+}
+
+// watchExport exports stats as the telemetry.spans.exported,
+// telemetry.spans.failed and telemetry.spans.dropped counters and the
+// telemetry.spans.queued gauge, so telemetry lost on the way to the
+// collector shows up instead of going missing silently.
+func watchExport(stats *exportStats) {
+	meter := otel.Meter("microservice-meter")
+
+	counters := []struct {
+		name, description string
+		value             *atomic.Int64
+	}{
+		{"telemetry.spans.exported", "Spans accepted by the collector", &stats.exported},
+		{"telemetry.spans.failed", "Spans lost to a failed export", &stats.failed},
+		{"telemetry.spans.dropped", "Spans dropped because the export queue was full", &stats.dropped},
+	}
+	for _, c := range counters {
+		value := c.value
+		_, err := meter.Int64ObservableCounter(c.name,
+			metric.WithDescription(c.description),
+			metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+				o.Observe(value.Load())
+				return nil
+			}))
+		if err != nil {
+			log.Printf("failed to create %s counter: %v", c.name, err)
+		}
+	}
+
+	_, err := meter.Int64ObservableGauge("telemetry.spans.queued",
+		metric.WithDescription("Spans waiting for export, the batch being exported included"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(stats.pending.Load())
+			return nil
+		}))
+	if err != nil {
+		log.Printf("failed to create span queue gauge: %v", err)
+	}
+}
+
+// logFlush summarizes the final flush, from before as the stats stood when
+// shutdown began. Spans still pending afterwards never reached the collector.
+func logFlush(stats *exportStats, before exportCounts) {
+	after := stats.snapshot()
+	log.Printf("telemetry flushed on shutdown: %d spans exported, %d failed, %d lost unflushed; since start: %d exported, %d failed, %d dropped",
+		after.exported-before.exported, after.failed-before.failed, after.pending,
+		after.exported, after.failed, after.dropped)
+}
@@ -0,0 +1,40 @@
+package telemetry
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+)
+
+// PrometheusExporter serves the metrics of the service in the Prometheus
+// text format, collecting them when scraped. It is the OpenTelemetry
+// Prometheus exporter on a registry of its own, so only the instruments
+// created with otel.Meter are served, named the Prometheus way: units and
+// _total as suffixes, and the resource attributes as the labels of
+// target_info.
+type PrometheusExporter struct {
+	reader  *otelprom.Exporter
+	handler http.Handler
+}
+
+func NewPrometheusExporter() (*PrometheusExporter, error) {
+	registry := prometheus.NewRegistry()
+
+	reader, err := otelprom.New(otelprom.WithRegisterer(registry))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create prometheus exporter: %w", err)
+	}
+
+	return &PrometheusExporter{
+		reader:  reader,
+		handler: promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorLog: log.Default()}),
+	}, nil
+}
+
+func (p *PrometheusExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.handler.ServeHTTP(w, r)
+}
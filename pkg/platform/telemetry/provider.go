@@ -0,0 +1,225 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/chaos"
+	"github.com/luis-olivetti/go-observability/pkg/platform/debugtrace"
+	"github.com/luis-olivetti/go-observability/pkg/platform/redact"
+	"github.com/luis-olivetti/go-observability/pkg/platform/sampling"
+	"github.com/luis-olivetti/go-observability/pkg/platform/tenant"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	collectorlogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// TracerName is the instrumentation scope of the service's own spans.
+const TracerName = "microservice-tracer"
+
+// Span exporters Setup can ship spans with.
+const (
+	// ExporterOTLP sends spans, logs and metrics to the OTLP collector.
+	ExporterOTLP = "otlp"
+	// ExporterStdout prints spans to stdout, one JSON line each, and logs to
+	// stderr only, for running without a collector.
+	ExporterStdout = "stdout"
+)
+
+// Transports ExporterOTLP can reach the collector over, as named by
+// OTEL_EXPORTER_OTLP_PROTOCOL.
+const (
+	ProtocolGRPC = "grpc"
+	// ProtocolHTTP posts protobuf payloads to /v1/traces, /v1/logs and
+	// /v1/metrics, for collectors or gateways that only accept OTLP over
+	// HTTP.
+	ProtocolHTTP = "http/protobuf"
+)
+
+// Config is what Setup needs to start the telemetry of a service.
+type Config struct {
+	ServiceName string
+	// Exporter is ExporterOTLP or ExporterStdout.
+	Exporter string
+	// CollectorURL is the host:port of the collector.
+	CollectorURL string
+	// Protocol is ProtocolGRPC, the default, or ProtocolHTTP.
+	Protocol string
+	// Deployment is added to the service resource and to every span.
+	Deployment Deployment
+	// Attributes are added to the service resource.
+	Attributes []attribute.KeyValue
+	// LogLevel is the lowest level logged and exported.
+	LogLevel slog.Level
+
+	Scrubber *redact.Scrubber
+	// Injector drops spans in chaos mode; nil otherwise.
+	Injector *chaos.Injector
+	// Policy keeps or drops traces by its sampling rules; when nil every
+	// span is exported.
+	Policy *sampling.Policy
+	// Metrics serves the metrics of the service for scraping when set.
+	Metrics *PrometheusExporter
+}
+
+// metricsInterval is how often metrics are pushed to the collector.
+const metricsInterval = 15 * time.Second
+
+// Setup exports spans and logs as cfg says: to the OTLP collector, under one
+// resource and over one gRPC connection or plain HTTP, or to stdout. With the
+// collector, metrics are pushed over the same transport too, exemplars
+// included, which the Prometheus text format of cfg.Metrics cannot carry. It
+// installs the resulting tracer provider, meter provider, propagators and
+// slog handler globally, so a service is wired in with this one call. The
+// meter provider is installed whether or not cfg.Metrics is set: instruments
+// are never left on the no-op global one. The returned function flushes and
+// shuts them down, logging how many spans the final flush exported.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	LogLevel.Set(cfg.LogLevel)
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceName(cfg.ServiceName),
+		),
+		resource.WithAttributes(cfg.Deployment.Attributes()...),
+		resource.WithAttributes(cfg.Attributes...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	var (
+		traceExporter  sdktrace.SpanExporter
+		metricExporter sdkmetric.Exporter
+		logs           *logExporter
+	)
+	switch {
+	case cfg.Exporter == ExporterStdout:
+		traceExporter = newStdoutExporter(os.Stdout)
+	case cfg.Protocol == ProtocolHTTP:
+		traceExporter, err = otlptracehttp.New(ctx,
+			otlptracehttp.WithEndpoint(cfg.CollectorURL),
+			otlptracehttp.WithInsecure(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+		}
+		metricExporter, err = otlpmetrichttp.New(ctx,
+			otlpmetrichttp.WithEndpoint(cfg.CollectorURL),
+			otlpmetrichttp.WithInsecure(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+		}
+		logs = newLogExporter(&httpLogSender{url: "http://" + cfg.CollectorURL + "/v1/logs", client: &http.Client{}}, res)
+	default:
+		// The connection is established in the background: an unreachable
+		// collector must not hold back startup or readiness.
+		conn, err := grpc.Dial(cfg.CollectorURL,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create grpc connection to collector: %w", err)
+		}
+		watchCollector(conn, cfg.CollectorURL)
+
+		traceExporter, err = otlptracegrpc.New(ctx, otlptracegrpc.WithGRPCConn(conn))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+		}
+		metricExporter, err = otlpmetricgrpc.New(ctx, otlpmetricgrpc.WithGRPCConn(conn))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+		}
+		logs = newLogExporter(grpcLogSender{collectorlogspb.NewLogsServiceClient(conn)}, res)
+	}
+
+	stats := &exportStats{}
+	watchExport(stats)
+
+	export := newExportPipeline(traceExporter, stats)
+	if cfg.Policy != nil {
+		export = sampling.NewProcessor(export, cfg.Policy)
+	}
+
+	tp := NewTracerProvider(export, cfg.Scrubber, cfg.Injector,
+		sdktrace.WithResource(res),
+		sdktrace.WithSpanProcessor(NewDeploymentProcessor(cfg.Deployment)),
+	)
+	Install(tp)
+
+	slog.SetDefault(slog.New(newLogHandler(os.Stderr, logs, cfg.Scrubber)))
+
+	meterOpts := []sdkmetric.Option{
+		sdkmetric.WithResource(res),
+		sdkmetric.WithView(secondsBuckets),
+	}
+	if cfg.Metrics != nil {
+		meterOpts = append(meterOpts, sdkmetric.WithReader(cfg.Metrics.reader))
+	}
+	if metricExporter != nil {
+		meterOpts = append(meterOpts, sdkmetric.WithReader(
+			sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(metricsInterval)),
+		))
+	}
+	meters := sdkmetric.NewMeterProvider(meterOpts...)
+	otel.SetMeterProvider(meters)
+
+	return func(ctx context.Context) error {
+		before := stats.snapshot()
+		err := tp.Shutdown(ctx)
+		// Logged before the log exporter stops, so the summary is exported
+		// too when the collector is reachable.
+		logFlush(stats, before)
+		if logs != nil {
+			err = errors.Join(err, logs.shutdown(ctx))
+		}
+		return errors.Join(err, meters.Shutdown(ctx))
+	}, nil
+}
+
+// secondsBuckets gives histograms in seconds buckets that fit request
+// latencies; the SDK defaults are meant for milliseconds.
+var secondsBuckets = sdkmetric.NewView(
+	sdkmetric.Instrument{Kind: sdkmetric.InstrumentKindHistogram, Unit: "s"},
+	sdkmetric.Stream{Aggregation: sdkmetric.AggregationExplicitBucketHistogram{
+		Boundaries: []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10},
+	}},
+)
+
+// NewTracerProvider builds the service's span pipeline around export: spans
+// are stamped with tenant and debug baggage on start and scrubbed (and, in
+// chaos mode, possibly dropped) before reaching export.
+func NewTracerProvider(export sdktrace.SpanProcessor, scrubber *redact.Scrubber, injector *chaos.Injector, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	opts = append([]sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSpanProcessor(tenant.SpanProcessor{}),
+		sdktrace.WithSpanProcessor(debugtrace.SpanProcessor{}),
+		sdktrace.WithSpanProcessor(redact.NewProcessor(injector.SpanProcessor(export), scrubber)),
+	}, opts...)
+
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+// Install makes tp the global tracer provider and sets the W3C trace context
+// and baggage propagators.
+func Install(tp *sdktrace.TracerProvider) {
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
@@ -0,0 +1,124 @@
+package telemetry
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/otlptest"
+	"github.com/luis-olivetti/go-observability/pkg/platform/redact"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+func resourceAttr(attrs []*commonpb.KeyValue, key string) string {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value.GetStringValue()
+		}
+	}
+	return ""
+}
+
+// TestSetupExportsOverOTLP runs Setup against an in-process collector and
+// checks that spans, logs and metrics arrive under the service resource, with
+// the log record and the histogram exemplar carrying the trace of their
+// context.
+func TestSetupExportsOverOTLP(t *testing.T) {
+	t.Setenv("OTEL_GO_X_EXEMPLAR", "true")
+
+	collector, err := otlptest.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer collector.Stop()
+
+	scrubber, err := redact.NewScrubber(nil, redact.DefaultCEPPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defaultLogger := slog.Default()
+	defer slog.SetDefault(defaultLogger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	shutdown, err := Setup(ctx, Config{
+		ServiceName:  "go-service-test",
+		Exporter:     ExporterOTLP,
+		CollectorURL: collector.Endpoint,
+		Protocol:     ProtocolGRPC,
+		Attributes:   []attribute.KeyValue{attribute.String("region", "sa-east-1")},
+		Scrubber:     scrubber,
+	})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	duration, err := otel.Meter(TracerName).Float64Histogram("http.server.request.duration", metric.WithUnit("s"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spanCtx, span := otel.Tracer(TracerName).Start(ctx, "GET /alerts")
+	slog.InfoContext(spanCtx, "fetching alerts")
+	duration.Record(spanCtx, 0.042)
+	span.End()
+
+	if err := shutdown(ctx); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+
+	resourceSpans := collector.ResourceSpans()
+	if len(resourceSpans) == 0 {
+		t.Fatal("collector received no spans")
+	}
+	attrs := resourceSpans[0].Resource.Attributes
+	if got := resourceAttr(attrs, "service.name"); got != "go-service-test" {
+		t.Errorf("service.name = %q, want %q", got, "go-service-test")
+	}
+	if got := resourceAttr(attrs, "region"); got != "sa-east-1" {
+		t.Errorf("region = %q, want %q", got, "sa-east-1")
+	}
+
+	spans := collector.Spans()
+	if len(spans) != 1 || spans[0].Name != "GET /alerts" {
+		t.Fatalf("spans = %v, want one GET /alerts", spans)
+	}
+
+	var found bool
+	for _, record := range collector.LogRecords() {
+		if record.Body.GetStringValue() != "fetching alerts" {
+			continue
+		}
+		found = true
+		if string(record.TraceId) != string(spans[0].TraceId) {
+			t.Errorf("log record trace id = %x, want %x", record.TraceId, spans[0].TraceId)
+		}
+	}
+	if !found {
+		t.Error("collector received no \"fetching alerts\" log record")
+	}
+
+	var points []*metricpb.HistogramDataPoint
+	for _, rm := range collector.ResourceMetrics() {
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if m.Name == "http.server.request.duration" {
+					points = append(points, m.GetHistogram().GetDataPoints()...)
+				}
+			}
+		}
+	}
+	if len(points) != 1 || points[0].Count != 1 {
+		t.Fatalf("http.server.request.duration points = %v, want one with one measurement", points)
+	}
+	if exemplars := points[0].Exemplars; len(exemplars) != 1 || string(exemplars[0].TraceId) != string(spans[0].TraceId) {
+		t.Errorf("exemplars = %v, want one from trace %x", exemplars, spans[0].TraceId)
+	}
+}
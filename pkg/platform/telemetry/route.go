@@ -0,0 +1,37 @@
+package telemetry
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServerSpan names the span of an inbound request after its method and mux
+// route template ("GET /city-weather"), so span names stay low-cardinality
+// whatever path parameters a route gains. The raw path goes into an
+// attribute instead. fallbackRoute is used when the request did not go
+// through the router, as with the smoke check and the prober.
+func ServerSpan(r *http.Request, fallbackRoute string) (string, []trace.SpanStartOption) {
+	route := route(r, fallbackRoute)
+
+	return r.Method + " " + route, []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.HTTPRoute(route),
+			semconv.URLPath(r.URL.Path),
+		),
+	}
+}
+
+// route returns the mux route template r matched, or fallbackRoute.
+func route(r *http.Request, fallbackRoute string) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return fallbackRoute
+}
@@ -0,0 +1,75 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// stdoutExporter prints each span as one JSON line, for running locally
+// without a collector.
+type stdoutExporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newStdoutExporter(out io.Writer) *stdoutExporter {
+	return &stdoutExporter{enc: json.NewEncoder(out)}
+}
+
+type stdoutSpan struct {
+	Name         string         `json:"name"`
+	Kind         string         `json:"kind"`
+	TraceID      string         `json:"trace_id"`
+	SpanID       string         `json:"span_id"`
+	ParentSpanID string         `json:"parent_span_id,omitempty"`
+	Start        time.Time      `json:"start"`
+	Duration     string         `json:"duration"`
+	Status       string         `json:"status,omitempty"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+}
+
+func (e *stdoutExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, s := range spans {
+		line := stdoutSpan{
+			Name:     s.Name(),
+			Kind:     s.SpanKind().String(),
+			TraceID:  s.SpanContext().TraceID().String(),
+			SpanID:   s.SpanContext().SpanID().String(),
+			Start:    s.StartTime(),
+			Duration: s.EndTime().Sub(s.StartTime()).String(),
+		}
+		if s.Parent().IsValid() {
+			line.ParentSpanID = s.Parent().SpanID().String()
+		}
+		if status := s.Status(); status.Code != codes.Unset {
+			line.Status = status.Code.String()
+			if status.Description != "" {
+				line.Status += ": " + status.Description
+			}
+		}
+		if attrs := s.Attributes(); len(attrs) > 0 {
+			line.Attributes = make(map[string]any, len(attrs))
+			for _, a := range attrs {
+				line.Attributes[string(a.Key)] = a.Value.AsInterface()
+			}
+		}
+
+		if err := e.enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *stdoutExporter) Shutdown(context.Context) error {
+	return nil
+}
@@ -0,0 +1,135 @@
+package tenant
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Baggage members set by service-a once a caller is authenticated.
+const (
+	TenantKey = contracts.TenantBaggageKey
+	ClientKey = contracts.ClientBaggageKey
+	// SyntheticKey marks requests made by the built-in prober.
+	SyntheticKey = contracts.SyntheticBaggageKey
+	// MirrorKey marks copies of production requests mirrored to staging.
+	MirrorKey = contracts.MirrorBaggageKey
+)
+
+var keys = []string{TenantKey, ClientKey, SyntheticKey, MirrorKey}
+
+type syntheticKey struct{}
+
+// WithSynthetic marks ctx as carrying a synthetic probe rather than real
+// traffic.
+func WithSynthetic(ctx context.Context) context.Context {
+	return context.WithValue(ctx, syntheticKey{}, true)
+}
+
+func IsSynthetic(ctx context.Context) bool {
+	synthetic, _ := ctx.Value(syntheticKey{}).(bool)
+	return synthetic
+}
+
+// ID returns the tenant carried in the context baggage, or "" for anonymous
+// callers.
+func ID(ctx context.Context) string {
+	return baggage.FromContext(ctx).Member(TenantKey).Value()
+}
+
+// Attributes returns the caller identity carried in the context baggage, for
+// use on spans and metrics.
+func Attributes(ctx context.Context) []attribute.KeyValue {
+	bag := baggage.FromContext(ctx)
+
+	var attrs []attribute.KeyValue
+	for _, key := range keys {
+		if value := bag.Member(key).Value(); value != "" {
+			attrs = append(attrs, attribute.String(key, value))
+		}
+	}
+
+	return attrs
+}
+
+// SpanProcessor stamps the caller identity from baggage onto every span as it
+// starts, so per-tenant analysis works without each span setting it.
+type SpanProcessor struct{}
+
+func (SpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	s.SetAttributes(Attributes(parent)...)
+}
+
+func (SpanProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+func (SpanProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+func (SpanProcessor) ForceFlush(context.Context) error {
+	return nil
+}
+
+// Metrics records request counts and durations per tenant/client, as
+// propagated by service-a in baggage.
+type Metrics struct {
+	requests metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+func NewMetrics() *Metrics {
+	meter := otel.Meter("microservice-meter")
+
+	requests, err := meter.Int64Counter("tenant.requests",
+		metric.WithDescription("Requests served, by tenant, client and status code"))
+	if err != nil {
+		log.Printf("failed to create tenant requests counter: %v", err)
+	}
+
+	duration, err := meter.Float64Histogram("tenant.request.duration",
+		metric.WithDescription("Request duration, by tenant, client and status code"),
+		metric.WithUnit("s"))
+	if err != nil {
+		log.Printf("failed to create tenant duration histogram: %v", err)
+	}
+
+	return &Metrics{requests: requests, duration: duration}
+}
+
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		attrs := append(Attributes(ctx), attribute.Int("http.status_code", recorder.status))
+
+		if m.requests != nil {
+			m.requests.Add(ctx, 1, metric.WithAttributes(attrs...))
+		}
+		if m.duration != nil {
+			m.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+		}
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,114 @@
+package tracetesting
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// FindSpan returns the single span named name, failing the test when there is
+// none or more than one.
+func FindSpan(tb testing.TB, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	tb.Helper()
+
+	var found []tracetest.SpanStub
+	for _, span := range spans {
+		if span.Name == name {
+			found = append(found, span)
+		}
+	}
+
+	switch len(found) {
+	case 1:
+		return found[0]
+	case 0:
+		tb.Fatalf("span %q not found; got %s", name, spanNames(spans))
+	default:
+		tb.Fatalf("expected one span %q, found %d", name, len(found))
+	}
+	return tracetest.SpanStub{}
+}
+
+// FindSpans returns every span named name, in the order they were exported.
+func FindSpans(spans tracetest.SpanStubs, name string) tracetest.SpanStubs {
+	var found tracetest.SpanStubs
+	for _, span := range spans {
+		if span.Name == name {
+			found = append(found, span)
+		}
+	}
+	return found
+}
+
+// AssertChildOf checks that child is a direct child of parent in the same trace.
+func AssertChildOf(tb testing.TB, child, parent tracetest.SpanStub) {
+	tb.Helper()
+
+	if child.SpanContext.TraceID() != parent.SpanContext.TraceID() {
+		tb.Errorf("span %q is in trace %s, parent %q is in trace %s",
+			child.Name, child.SpanContext.TraceID(), parent.Name, parent.SpanContext.TraceID())
+		return
+	}
+	if child.Parent.SpanID() != parent.SpanContext.SpanID() {
+		tb.Errorf("span %q has parent %s, want %q (%s)",
+			child.Name, child.Parent.SpanID(), parent.Name, parent.SpanContext.SpanID())
+	}
+}
+
+// AssertAttr checks that span carries attribute key with value want. want is
+// compared through attribute.Value.AsInterface, so plain Go values can be used.
+func AssertAttr(tb testing.TB, span tracetest.SpanStub, key string, want any) {
+	tb.Helper()
+
+	for _, kv := range span.Attributes {
+		if string(kv.Key) != key {
+			continue
+		}
+		if got := kv.Value.AsInterface(); fmt.Sprint(got) != fmt.Sprint(want) {
+			tb.Errorf("span %q attribute %q = %v, want %v", span.Name, key, got, want)
+		}
+		return
+	}
+	tb.Errorf("span %q has no attribute %q; got %s", span.Name, key, attrKeys(span.Attributes))
+}
+
+// AssertNoAttr checks that span does not carry attribute key.
+func AssertNoAttr(tb testing.TB, span tracetest.SpanStub, key string) {
+	tb.Helper()
+
+	for _, kv := range span.Attributes {
+		if string(kv.Key) == key {
+			tb.Errorf("span %q unexpectedly has attribute %q = %v", span.Name, key, kv.Value.AsInterface())
+			return
+		}
+	}
+}
+
+// AssertStatus checks the status code of span.
+func AssertStatus(tb testing.TB, span tracetest.SpanStub, want codes.Code) {
+	tb.Helper()
+
+	if span.Status.Code != want {
+		tb.Errorf("span %q status = %s (%q), want %s", span.Name, span.Status.Code, span.Status.Description, want)
+	}
+}
+
+func spanNames(spans tracetest.SpanStubs) string {
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name)
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+func attrKeys(attrs []attribute.KeyValue) string {
+	keys := make([]string, 0, len(attrs))
+	for _, kv := range attrs {
+		keys = append(keys, string(kv.Key))
+	}
+	return "[" + strings.Join(keys, ", ") + "]"
+}
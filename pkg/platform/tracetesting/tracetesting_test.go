@@ -0,0 +1,194 @@
+package tracetesting
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recorder is a testing.TB that records failures instead of reporting them,
+// so the helpers can be checked for failing when they should.
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+func (r *recorder) failed() bool { return len(r.errors) > 0 }
+
+// recordSpans runs a parent span with a child and an unrelated root span,
+// and returns what was exported.
+func recordSpans(t *testing.T) tracetest.SpanStubs {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracer := tp.Tracer("tracetesting")
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	_, child := tracer.Start(ctx, "child")
+	child.SetAttributes(attribute.String("city", "Jaraguá do Sul"), attribute.Int("status", 200))
+	child.SetStatus(codes.Error, "upstream failed")
+	child.End()
+	parent.End()
+
+	_, other := tracer.Start(context.Background(), "other")
+	other.End()
+
+	_, duplicate := tracer.Start(context.Background(), "other")
+	duplicate.End()
+
+	return exporter.GetSpans()
+}
+
+func TestFindSpan(t *testing.T) {
+	spans := recordSpans(t)
+
+	tests := []struct {
+		name      string
+		span      string
+		wantFatal bool
+	}{
+		{name: "single match", span: "child"},
+		{name: "missing", span: "absent", wantFatal: true},
+		{name: "ambiguous", span: "other", wantFatal: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			got := FindSpan(r, spans, tt.span)
+
+			if r.fatal != tt.wantFatal {
+				t.Fatalf("fatal = %v, want %v (errors: %v)", r.fatal, tt.wantFatal, r.errors)
+			}
+			if !tt.wantFatal && got.Name != tt.span {
+				t.Errorf("FindSpan returned %q, want %q", got.Name, tt.span)
+			}
+		})
+	}
+}
+
+func TestFindSpans(t *testing.T) {
+	spans := recordSpans(t)
+
+	if got := len(FindSpans(spans, "other")); got != 2 {
+		t.Errorf("FindSpans(other) returned %d spans, want 2", got)
+	}
+	if got := len(FindSpans(spans, "absent")); got != 0 {
+		t.Errorf("FindSpans(absent) returned %d spans, want 0", got)
+	}
+}
+
+func TestAssertChildOf(t *testing.T) {
+	spans := recordSpans(t)
+	parent := FindSpan(t, spans, "parent")
+	child := FindSpan(t, spans, "child")
+	other := FindSpans(spans, "other")[0]
+
+	tests := []struct {
+		name          string
+		child, parent tracetest.SpanStub
+		wantFail      bool
+	}{
+		{name: "direct child", child: child, parent: parent},
+		{name: "reversed", child: parent, parent: child, wantFail: true},
+		{name: "other trace", child: other, parent: parent, wantFail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			AssertChildOf(r, tt.child, tt.parent)
+
+			if r.failed() != tt.wantFail {
+				t.Errorf("failed = %v, want %v (errors: %v)", r.failed(), tt.wantFail, r.errors)
+			}
+		})
+	}
+}
+
+func TestAssertAttr(t *testing.T) {
+	child := FindSpan(t, recordSpans(t), "child")
+
+	tests := []struct {
+		name     string
+		key      string
+		want     any
+		wantFail bool
+	}{
+		{name: "string", key: "city", want: "Jaraguá do Sul"},
+		{name: "int compared as plain value", key: "status", want: 200},
+		{name: "wrong value", key: "city", want: "Curitiba", wantFail: true},
+		{name: "missing", key: "zipcode", want: "89253000", wantFail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			AssertAttr(r, child, tt.key, tt.want)
+
+			if r.failed() != tt.wantFail {
+				t.Errorf("failed = %v, want %v (errors: %v)", r.failed(), tt.wantFail, r.errors)
+			}
+		})
+	}
+}
+
+func TestAssertNoAttr(t *testing.T) {
+	child := FindSpan(t, recordSpans(t), "child")
+
+	r := &recorder{TB: t}
+	AssertNoAttr(r, child, "zipcode")
+	if r.failed() {
+		t.Errorf("AssertNoAttr failed on a missing attribute: %v", r.errors)
+	}
+
+	r = &recorder{TB: t}
+	AssertNoAttr(r, child, "city")
+	if !r.failed() {
+		t.Error("AssertNoAttr passed on a present attribute")
+	}
+}
+
+func TestAssertStatus(t *testing.T) {
+	spans := recordSpans(t)
+
+	tests := []struct {
+		name     string
+		span     string
+		want     codes.Code
+		wantFail bool
+	}{
+		{name: "error", span: "child", want: codes.Error},
+		{name: "unset", span: "parent", want: codes.Unset},
+		{name: "mismatch", span: "parent", want: codes.Error, wantFail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			AssertStatus(r, FindSpan(t, spans, tt.span), tt.want)
+
+			if r.failed() != tt.wantFail {
+				t.Errorf("failed = %v, want %v (errors: %v)", r.failed(), tt.wantFail, r.errors)
+			}
+		})
+	}
+}
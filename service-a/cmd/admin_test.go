@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/audit"
+	"github.com/luis-olivetti/go-observability/pkg/platform/health"
+	"github.com/luis-olivetti/go-observability/pkg/platform/sampling"
+	"github.com/luis-olivetti/go-observability/pkg/platform/slo"
+	"github.com/luis-olivetti/go-observability/service-a/internal/auth"
+	"github.com/luis-olivetti/go-observability/service-a/internal/config"
+)
+
+// The API keys of the admin tests, one per role; the "nobody" key has none.
+const (
+	viewerKey   = "viewer-secret"
+	operatorKey = "operator-secret"
+	nobodyKey   = "nobody-secret"
+)
+
+type auditRecorder struct {
+	mu      sync.Mutex
+	actions []string
+}
+
+func (a *auditRecorder) Write(_ context.Context, entry audit.Entry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.actions = append(a.actions, entry.Action)
+	return nil
+}
+
+func (a *auditRecorder) recorded() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return strings.Join(a.actions, ",")
+}
+
+// newTestAdminRouter wires the admin router the way serve does, with API
+// keys for each role and sampling at a configured ratio of 0.1.
+func newTestAdminRouter(t *testing.T) (http.Handler, *sampling.Override, *auditRecorder) {
+	t.Helper()
+
+	cfg := &config.Config{
+		APIKeys: []auth.APIKey{
+			{ID: "viewer", Secret: viewerKey},
+			{ID: "operator", Secret: operatorKey},
+			{ID: "nobody", Secret: nobodyKey},
+		},
+		KeyRoles: map[string]auth.Role{"viewer": auth.RoleViewer, "operator": auth.RoleOperator},
+	}
+	objectives := slo.NewRecorder(cfg.SLO)
+	chains := newMiddlewares(cfg, nil, nil, objectives, nil)
+	override := sampling.NewOverride(sampling.NewPolicy(sampling.Config{Ratio: 0.1}))
+	recorder := &auditRecorder{}
+
+	admin := newAdminRouter(cfg, chains, health.NewChecker(), http.NotFoundHandler(), objectives, override, audit.NewLogger([]byte("key"), recorder))
+	return admin, override, recorder
+}
+
+func adminRequest(handler http.Handler, method, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(auth.APIKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSamplingAdmin(t *testing.T) {
+	admin, override, recorder := newTestAdminRouter(t)
+
+	rec := adminRequest(admin, http.MethodPut, "/admin/sampling", operatorKey, `{"ratio": 1, "duration": "10m"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	var status sampling.OverrideStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+	if status.Ratio != 1 || status.ConfiguredRatio != 0.1 || status.Until == nil {
+		t.Errorf("status after PUT = %+v, want ratio 1 until a deadline, configured 0.1", status)
+	}
+
+	rec = adminRequest(admin, http.MethodGet, "/admin/sampling", operatorKey, "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("GET = %d %q, want 200 application/json", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	rec = adminRequest(admin, http.MethodPost, "/admin/sampling", operatorKey, `{"ratio": 0, "duration": "1m"}`)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", rec.Code)
+	}
+	if got := rec.Header().Get("Allow"); got != "GET, PUT, DELETE" {
+		t.Errorf("Allow = %q, want GET, PUT, DELETE", got)
+	}
+	if got := override.Status().Ratio; got != 1 {
+		t.Errorf("ratio after POST = %g, want the override to stand", got)
+	}
+
+	rec = adminRequest(admin, http.MethodPut, "/admin/sampling", operatorKey, `{"ratio": 2, "duration": "48h"}`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid PUT status = %d, want 422", rec.Code)
+	}
+
+	rec = adminRequest(admin, http.MethodDelete, "/admin/sampling", operatorKey, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("DELETE status = %d, want 200", rec.Code)
+	}
+	if got := override.Status(); got.Ratio != 0.1 || got.Until != nil {
+		t.Errorf("status after DELETE = %+v, want the configured ratio back", got)
+	}
+
+	if got, want := recorder.recorded(), "sampling.override.start,sampling.override.stop"; got != want {
+		t.Errorf("audited %s, want %s", got, want)
+	}
+}
+
+func TestSamplingAdminAuditsEnd(t *testing.T) {
+	admin, override, recorder := newTestAdminRouter(t)
+
+	rec := adminRequest(admin, http.MethodPut, "/admin/sampling", operatorKey, `{"ratio": 1, "duration": "20ms"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+
+	want := "sampling.override.start,sampling.override.end"
+	deadline := time.Now().Add(5 * time.Second)
+	for recorder.recorded() != want && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := recorder.recorded(); got != want {
+		t.Fatalf("audited %s, want %s", got, want)
+	}
+	if got := override.Status(); got.Ratio != 0.1 || got.Until != nil {
+		t.Errorf("status after the deadline = %+v, want the configured ratio back", got)
+	}
+}
+
+func TestAdminRoles(t *testing.T) {
+	admin, _, _ := newTestAdminRouter(t)
+
+	tests := []struct {
+		name       string
+		path       string
+		key        string
+		wantStatus int
+	}{
+		{name: "viewer reads the SLO status", path: "/admin/slo", key: viewerKey, wantStatus: http.StatusOK},
+		{name: "operator includes viewer", path: "/admin/slo", key: operatorKey, wantStatus: http.StatusOK},
+		{name: "operator reads the sampling", path: "/admin/sampling", key: operatorKey, wantStatus: http.StatusOK},
+		{name: "viewer denied the sampling", path: "/admin/sampling", key: viewerKey, wantStatus: http.StatusForbidden},
+		{name: "key without a role gets none", path: "/admin/slo", key: nobodyKey, wantStatus: http.StatusForbidden},
+		{name: "no key", path: "/admin/slo", wantStatus: http.StatusUnauthorized},
+		{name: "unknown key", path: "/admin/sampling", key: "guess", wantStatus: http.StatusUnauthorized},
+		{name: "health needs no role", path: "/healthz", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := adminRequest(admin, http.MethodGet, tt.path, tt.key, "")
+			if rec.Code != tt.wantStatus {
+				t.Errorf("GET %s = %d, want %d; body %s", tt.path, rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+}
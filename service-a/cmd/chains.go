@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/callbudget"
+	"github.com/luis-olivetti/go-observability/pkg/platform/ipfilter"
+	"github.com/luis-olivetti/go-observability/pkg/platform/jwtauth"
+	"github.com/luis-olivetti/go-observability/pkg/platform/slo"
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"github.com/luis-olivetti/go-observability/service-a/internal/abuse"
+	"github.com/luis-olivetti/go-observability/service-a/internal/auth"
+	"github.com/luis-olivetti/go-observability/service-a/internal/config"
+	"github.com/luis-olivetti/go-observability/service-a/internal/handlers"
+	"github.com/luis-olivetti/go-observability/service-a/internal/middleware"
+	"github.com/luis-olivetti/go-observability/service-a/internal/quota"
+)
+
+// The middleware chains of every router and route, outermost first. A router
+// chain runs before the chain of the route matched.
+var (
+	publicChain   = []string{middleware.Metrics, middleware.IPFilter, middleware.APIKey, middleware.JWT, middleware.DebugTrace}
+	internalChain = []string{middleware.Metrics, middleware.IPFilter, middleware.JWT, middleware.DebugTrace}
+	// adminChain filters by its own allowlist, so the admin port can be
+	// kept to internal ranges without closing the public API.
+	adminChain = []string{middleware.AdminIPFilter}
+	// adminAuthChain identifies the callers of the admin routes that check
+	// roles.
+	adminAuthChain = []string{middleware.APIKey, middleware.JWT}
+
+	// zipcodeChain protects the public zipcode route from its consumers;
+	// internal callers only go through measuredChain.
+	zipcodeChain  = append([]string{middleware.Abuse, middleware.Quota}, measuredChain...)
+	measuredChain = []string{middleware.SLO, middleware.CallBudget}
+	// alertsChain leaves out the SLO, whose objectives cover the zipcode
+	// lookup only.
+	alertsChain = []string{middleware.Abuse, middleware.Quota, middleware.CallBudget}
+)
+
+// newMiddlewares registers the middlewares configured in cfg. quotaMeter is
+// nil without API keys.
+func newMiddlewares(cfg *config.Config, ipFilter, adminIPFilter *ipfilter.Filter, objectives *slo.Recorder, quotaMeter *quota.Meter) *middleware.Registry {
+	chains := middleware.NewRegistry(cfg.DisabledMiddlewares)
+
+	chains.Register(middleware.Metrics, telemetry.ActiveRequests())
+	if cfg.FilterIPs {
+		chains.Register(middleware.IPFilter, ipFilter.Middleware)
+	}
+	if cfg.FilterAdminIPs {
+		chains.Register(middleware.AdminIPFilter, adminIPFilter.Middleware)
+	}
+	if len(cfg.APIKeys) > 0 {
+		chains.Register(middleware.APIKey, auth.NewAPIKeyAuthenticator(cfg.APIKeys).Middleware)
+	}
+	if cfg.JWT != nil {
+		chains.Register(middleware.JWT, jwtauth.New(*cfg.JWT).Middleware)
+	}
+	if len(cfg.DebugTraceAllowlist) > 0 {
+		chains.Register(middleware.DebugTrace, auth.NewDebugTracing(cfg.DebugTraceAllowlist).Middleware)
+	}
+
+	if cfg.Abuse != nil {
+		detector := abuse.NewDetector(*cfg.Abuse, func(r *http.Request) string {
+			if keyID, ok := auth.KeyIDFromContext(r.Context()); ok {
+				return "key:" + auth.HashKeyID(keyID)
+			}
+			if addr, ok := ipFilter.ClientAddr(r); ok {
+				return "ip:" + addr.String()
+			}
+			return "unknown"
+		})
+		chains.Register(middleware.Abuse, detector.Middleware)
+	}
+	if quotaMeter != nil {
+		chains.Register(middleware.Quota, quotaMeter.Middleware)
+	}
+	chains.Register(middleware.SLO, func(next http.Handler) http.Handler {
+		return objectives.Middleware(handlers.ZipcodeRoute, next)
+	})
+	chains.Register(middleware.CallBudget, callbudget.Middleware(cfg.OutboundCallBudget))
+
+	return chains
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+type runner struct {
+	client    *http.Client
+	targetURL string
+	stubURL   string
+	zipkinURL string
+	wait      time.Duration
+}
+
+func main() {
+	dir := flag.String("scenarios", "../e2e/scenarios", "directory with *.yaml scenarios")
+	target := flag.String("target", "http://localhost:8080", "base URL of service A")
+	stub := flag.String("stub", "http://localhost:8282", "base URL of the stub-upstreams admin API")
+	zipkin := flag.String("zipkin", "http://localhost:9411", "base URL of Zipkin; empty skips span checks")
+	wait := flag.Duration("trace-wait", 20*time.Second, "how long to wait for a trace to reach Zipkin")
+	only := flag.String("run", "", "only run scenarios whose name contains this text")
+	flag.Parse()
+
+	scenarios, err := loadScenarios(*dir)
+	if err != nil {
+		log.Fatalf("failed to load scenarios: %v", err)
+	}
+	if len(scenarios) == 0 {
+		log.Fatalf("no scenarios found in %s", *dir)
+	}
+
+	r := &runner{
+		client:    &http.Client{Timeout: 30 * time.Second},
+		targetURL: strings.TrimRight(*target, "/"),
+		stubURL:   strings.TrimRight(*stub, "/"),
+		zipkinURL: *zipkin,
+		wait:      *wait,
+	}
+
+	failed := 0
+	for _, scenario := range scenarios {
+		if *only != "" && !strings.Contains(scenario.Name, *only) {
+			continue
+		}
+
+		failures := r.run(scenario)
+		if len(failures) == 0 {
+			log.Printf("PASS %s", scenario.Name)
+			continue
+		}
+
+		failed++
+		log.Printf("FAIL %s (%s)", scenario.Name, scenario.file)
+		for _, failure := range failures {
+			log.Printf("     %s", failure)
+		}
+	}
+
+	if failed > 0 {
+		log.Printf("%d scenario(s) failed", failed)
+		os.Exit(1)
+	}
+}
+
+func (r *runner) run(scenario Scenario) []string {
+	if err := r.configureUpstreams(scenario.Upstreams); err != nil {
+		return []string{fmt.Sprintf("failed to configure stub upstreams: %v", err)}
+	}
+
+	traceID, traceparent := newTraceparent()
+
+	status, body, err := r.send(scenario.Request, traceparent)
+	if err != nil {
+		return []string{fmt.Sprintf("request failed: %v", err)}
+	}
+
+	var failures []string
+	if status != scenario.Expect.Status {
+		failures = append(failures, fmt.Sprintf("status = %d, want %d (body: %s)", status, scenario.Expect.Status, strings.TrimSpace(string(body))))
+	}
+	failures = append(failures, checkBody(body, scenario.Expect.Body)...)
+
+	if len(scenario.Expect.Spans) > 0 && r.zipkinURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), r.wait)
+		defer cancel()
+
+		spans, err := fetchTrace(ctx, r.client, r.zipkinURL, traceID, scenario.Expect.Spans)
+		if err != nil {
+			return append(failures, fmt.Sprintf("failed to fetch trace %s: %v", traceID, err))
+		}
+		for _, failure := range checkSpans(spans, scenario.Expect.Spans) {
+			failures = append(failures, fmt.Sprintf("trace %s: %s", traceID, failure))
+		}
+	}
+
+	return failures
+}
+
+// configureUpstreams resets the stub and applies the scenario behaviors, so
+// faults never leak from one scenario into the next.
+func (r *runner) configureUpstreams(behaviors map[string]Behavior) error {
+	resp, err := r.client.Post(r.stubURL+"/admin/reset", "application/json", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	for name, behavior := range behaviors {
+		payload, err := json.Marshal(behavior)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequest("PUT", r.stubURL+"/admin/behaviors/"+name, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNoContent {
+			return fmt.Errorf("stub rejected behavior for %s: status %d", name, resp.StatusCode)
+		}
+	}
+
+	return nil
+}
+
+func (r *runner) send(request Request, traceparent string) (int, []byte, error) {
+	payload, err := json.Marshal(map[string]string{"cep": request.Cep})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	url := r.targetURL + "/city-by-zipcode"
+	if len(request.Include) > 0 {
+		url += "?" + neturl.Values{"include": request.Include}.Encode()
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", traceparent)
+	for name, value := range request.Headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, body, err
+}
+
+// newTraceparent starts a sampled W3C trace so the scenario can look the
+// trace up in Zipkin afterwards.
+func newTraceparent() (string, string) {
+	ids := make([]byte, 24)
+	rand.Read(ids)
+
+	traceID := hex.EncodeToString(ids[:16])
+	return traceID, "00-" + traceID + "-" + hex.EncodeToString(ids[16:]) + "-01"
+}
+
+func checkBody(body []byte, want map[string]any) []string {
+	if len(want) == 0 {
+		return nil
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(body, &got); err != nil {
+		return []string{fmt.Sprintf("response is not a JSON object: %v", err)}
+	}
+
+	return compareFields("", got, want)
+}
+
+func compareFields(prefix string, got, want map[string]any) []string {
+	var failures []string
+	for key, wantValue := range want {
+		path := prefix + key
+		gotValue, ok := got[key]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("body field %q missing", path))
+			continue
+		}
+
+		if nested, ok := wantValue.(map[string]any); ok {
+			gotNested, ok := gotValue.(map[string]any)
+			if !ok {
+				failures = append(failures, fmt.Sprintf("body field %q is not an object", path))
+				continue
+			}
+			failures = append(failures, compareFields(path+".", gotNested, nested)...)
+			continue
+		}
+
+		if fmt.Sprint(gotValue) != fmt.Sprint(wantValue) {
+			failures = append(failures, fmt.Sprintf("body field %q = %v, want %v", path, gotValue, wantValue))
+		}
+	}
+	return failures
+}
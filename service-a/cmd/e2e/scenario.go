@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is one declarative end-to-end case.
+type Scenario struct {
+	Name      string              `yaml:"name"`
+	Request   Request             `yaml:"request"`
+	Upstreams map[string]Behavior `yaml:"upstreams"`
+	Expect    Expect              `yaml:"expect"`
+
+	file string
+}
+
+type Request struct {
+	Cep     string            `yaml:"cep"`
+	Include []string          `yaml:"include"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// Behavior mirrors the stub-upstreams admin API payload.
+type Behavior struct {
+	ErrorRate   float64 `yaml:"error_rate" json:"error_rate"`
+	ErrorStatus int     `yaml:"error_status" json:"error_status,omitempty"`
+	Latency     string  `yaml:"latency" json:"latency,omitempty"`
+	Jitter      string  `yaml:"jitter" json:"jitter,omitempty"`
+}
+
+type Expect struct {
+	Status int `yaml:"status"`
+	// Body lists fields the JSON response must contain; other fields are
+	// ignored.
+	Body  map[string]any `yaml:"body"`
+	Spans []SpanExpect   `yaml:"spans"`
+}
+
+type SpanExpect struct {
+	Name    string `yaml:"name"`
+	Service string `yaml:"service"`
+	Parent  string `yaml:"parent"`
+	Error   *bool  `yaml:"error"`
+	Absent  bool   `yaml:"absent"`
+}
+
+func loadScenarios(dir string) ([]Scenario, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var scenarios []Scenario
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read scenario: %w", err)
+		}
+
+		var scenario Scenario
+		if err := yaml.Unmarshal(data, &scenario); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		if scenario.Name == "" {
+			scenario.Name = filepath.Base(file)
+		}
+		if scenario.Expect.Status == 0 {
+			return nil, fmt.Errorf("%s: expect.status is required", file)
+		}
+		scenario.file = file
+		scenarios = append(scenarios, scenario)
+	}
+
+	return scenarios, nil
+}
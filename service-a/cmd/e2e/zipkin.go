@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type zipkinSpan struct {
+	TraceID       string                       `json:"traceId"`
+	ID            string                       `json:"id"`
+	ParentID      string                       `json:"parentId"`
+	Name          string                       `json:"name"`
+	LocalEndpoint struct{ ServiceName string } `json:"localEndpoint"`
+	Tags          map[string]string            `json:"tags"`
+}
+
+func (s zipkinSpan) failed() bool {
+	if s.Tags["otel.status_code"] == "ERROR" {
+		return true
+	}
+	_, ok := s.Tags["error"]
+	return ok
+}
+
+// fetchTrace polls Zipkin until the trace holds every span the scenario
+// expects to be present, or until ctx expires. Spans reach Zipkin in batches
+// through the collector, so the first reads are usually incomplete.
+func fetchTrace(ctx context.Context, client *http.Client, zipkinURL, traceID string, expected []SpanExpect) ([]zipkinSpan, error) {
+	var spans []zipkinSpan
+	for {
+		var err error
+		spans, err = getTrace(ctx, client, zipkinURL, traceID)
+		if err != nil && ctx.Err() == nil {
+			return nil, err
+		}
+		if err == nil && hasAll(spans, expected) {
+			return spans, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return spans, nil
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func getTrace(ctx context.Context, client *http.Client, zipkinURL, traceID string) ([]zipkinSpan, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(zipkinURL, "/")+"/api/v2/trace/"+traceID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code (zipkin): %d", resp.StatusCode)
+	}
+
+	var spans []zipkinSpan
+	if err := json.NewDecoder(resp.Body).Decode(&spans); err != nil {
+		return nil, fmt.Errorf("failed to decode response (zipkin): %w", err)
+	}
+	return spans, nil
+}
+
+func hasAll(spans []zipkinSpan, expected []SpanExpect) bool {
+	for _, want := range expected {
+		if !want.Absent && findSpan(spans, want) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// findSpan matches names case-insensitively because Zipkin lowercases them.
+func findSpan(spans []zipkinSpan, want SpanExpect) *zipkinSpan {
+	for i, span := range spans {
+		if !strings.EqualFold(span.Name, want.Name) {
+			continue
+		}
+		if want.Service != "" && !strings.EqualFold(span.LocalEndpoint.ServiceName, want.Service) {
+			continue
+		}
+		return &spans[i]
+	}
+	return nil
+}
+
+func checkSpans(spans []zipkinSpan, expected []SpanExpect) []string {
+	var failures []string
+	for _, want := range expected {
+		span := findSpan(spans, want)
+		switch {
+		case want.Absent && span != nil:
+			failures = append(failures, fmt.Sprintf("span %q should not exist", want.Name))
+		case want.Absent:
+		case span == nil:
+			failures = append(failures, fmt.Sprintf("span %q not found", want.Name))
+		default:
+			if want.Error != nil && span.failed() != *want.Error {
+				failures = append(failures, fmt.Sprintf("span %q error = %t, want %t", want.Name, span.failed(), *want.Error))
+			}
+			if want.Parent != "" {
+				parent := findSpan(spans, SpanExpect{Name: want.Parent})
+				if parent == nil || span.ParentID != parent.ID {
+					failures = append(failures, fmt.Sprintf("span %q is not a child of %q", want.Name, want.Parent))
+				}
+			}
+		}
+	}
+	return failures
+}
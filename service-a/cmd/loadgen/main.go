@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+type headerFlags []string
+
+func (h *headerFlags) String() string { return strings.Join(*h, ", ") }
+
+func (h *headerFlags) Set(value string) error {
+	if !strings.Contains(value, ":") {
+		return fmt.Errorf("header must be in the form 'Name: value': %s", value)
+	}
+	*h = append(*h, value)
+	return nil
+}
+
+type counters struct {
+	sent     atomic.Int64
+	failed   atomic.Int64
+	byStatus sync.Map
+}
+
+func (c *counters) record(status int) {
+	value, _ := c.byStatus.LoadOrStore(status, new(atomic.Int64))
+	value.(*atomic.Int64).Add(1)
+}
+
+func (c *counters) summary() string {
+	var parts []string
+	c.byStatus.Range(func(key, value any) bool {
+		parts = append(parts, fmt.Sprintf("%d=%d", key, value.(*atomic.Int64).Load()))
+		return true
+	})
+	return fmt.Sprintf("sent=%d failed=%d status[%s]", c.sent.Load(), c.failed.Load(), strings.Join(parts, " "))
+}
+
+func main() {
+	var headers headerFlags
+
+	target := flag.String("url", "http://localhost:8080/city-by-zipcode", "endpoint to load")
+	zipcode := flag.String("zipcode", "29902555", "zipcode sent in every request")
+	concurrency := flag.Int("concurrency", 10, "number of concurrent workers")
+	rate := flag.Int("rate", 0, "maximum requests per second across all workers (0 = unlimited)")
+	duration := flag.Duration("duration", 30*time.Second, "how long to run")
+	soak := flag.Bool("soak", false, "soak mode: run for -duration while snapshotting pprof")
+	pprofURL := flag.String("pprof-url", "http://localhost:9080/debug/pprof", "pprof base URL of the service under test")
+	snapshotEvery := flag.Duration("snapshot-interval", time.Minute, "interval between pprof snapshots in soak mode")
+	flag.Var(&headers, "H", "extra request header, e.g. -H 'X-API-Key: secret' (repeatable)")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	body := []byte(fmt.Sprintf(`{"cep":%q}`, *zipcode))
+
+	var ticks <-chan time.Time
+	if *rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(*rate))
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	var stats counters
+	var wg sync.WaitGroup
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if ticks != nil {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticks:
+					}
+				} else if ctx.Err() != nil {
+					return
+				}
+
+				status, err := send(ctx, client, *target, body, headers)
+				stats.sent.Add(1)
+				if err != nil {
+					if ctx.Err() != nil {
+						return
+					}
+					stats.failed.Add(1)
+					continue
+				}
+				stats.record(status)
+			}
+		}()
+	}
+
+	if *soak {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			snapshotLoop(ctx, client, *pprofURL, headers, *snapshotEvery, &stats)
+		}()
+	}
+
+	wg.Wait()
+	log.Printf("done: %s", stats.summary())
+}
+
+func send(ctx context.Context, client *http.Client, url string, body []byte, headers headerFlags) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setHeaders(req, headers)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, nil
+}
+
+// snapshotLoop logs the goroutine count and heap usage reported by the
+// service's pprof endpoints, so a steady climb over hours points at a leak.
+func snapshotLoop(ctx context.Context, client *http.Client, pprofURL string, headers headerFlags, every time.Duration, stats *counters) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		snapshot(client, pprofURL, headers, stats)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func snapshot(client *http.Client, pprofURL string, headers headerFlags, stats *counters) {
+	goroutines, err := goroutineCount(client, pprofURL, headers)
+	if err != nil {
+		log.Printf("failed to read goroutine profile: %v", err)
+		return
+	}
+
+	heap, err := heapStats(client, pprofURL, headers)
+	if err != nil {
+		log.Printf("failed to read heap profile: %v", err)
+		return
+	}
+
+	log.Printf("snapshot: goroutines=%d heap_inuse=%d heap_objects=%d %s",
+		goroutines, heap["HeapInuse"], heap["HeapObjects"], stats.summary())
+}
+
+func goroutineCount(client *http.Client, pprofURL string, headers headerFlags) (int, error) {
+	lines, err := fetchProfile(client, pprofURL+"/goroutine?debug=1", headers)
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range lines {
+		if rest, ok := strings.CutPrefix(line, "goroutine profile: total "); ok {
+			return strconv.Atoi(strings.TrimSpace(rest))
+		}
+	}
+	return 0, fmt.Errorf("goroutine total not found")
+}
+
+// heapStats parses the runtime.MemStats trailer ("# Name = value") of the
+// heap profile in debug mode.
+func heapStats(client *http.Client, pprofURL string, headers headerFlags) (map[string]int64, error) {
+	lines, err := fetchProfile(client, pprofURL+"/heap?debug=1", headers)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]int64)
+	for _, line := range lines {
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, "# "), " = ")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			stats[name] = n
+		}
+	}
+	return stats, nil
+}
+
+func fetchProfile(client *http.Client, url string, headers headerFlags) ([]string, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	setHeaders(req, headers)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+func setHeaders(req *http.Request, headers headerFlags) {
+	for _, header := range headers {
+		name, value, _ := strings.Cut(header, ":")
+		req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+}
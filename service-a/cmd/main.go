@@ -2,84 +2,226 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"log"
+	"log/slog"
 	"net/http"
+	"net/http/pprof"
 	"os"
 	"os/signal"
-	"regexp"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/spf13/viper"
+	"github.com/luis-olivetti/go-observability/pkg/platform/audit"
+	"github.com/luis-olivetti/go-observability/pkg/platform/chaos"
+	"github.com/luis-olivetti/go-observability/pkg/platform/errclass"
+	"github.com/luis-olivetti/go-observability/pkg/platform/featureflag"
+	"github.com/luis-olivetti/go-observability/pkg/platform/health"
+	"github.com/luis-olivetti/go-observability/pkg/platform/httpclient"
+	"github.com/luis-olivetti/go-observability/pkg/platform/ipfilter"
+	"github.com/luis-olivetti/go-observability/pkg/platform/logsample"
+	"github.com/luis-olivetti/go-observability/pkg/platform/messages"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+	"github.com/luis-olivetti/go-observability/pkg/platform/redact"
+	"github.com/luis-olivetti/go-observability/pkg/platform/reqsign"
+	"github.com/luis-olivetti/go-observability/pkg/platform/runtimelimits"
+	"github.com/luis-olivetti/go-observability/pkg/platform/sampling"
+	"github.com/luis-olivetti/go-observability/pkg/platform/slo"
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"github.com/luis-olivetti/go-observability/service-a/internal/auth"
+	"github.com/luis-olivetti/go-observability/service-a/internal/clients"
+	"github.com/luis-olivetti/go-observability/service-a/internal/config"
+	"github.com/luis-olivetti/go-observability/service-a/internal/handlers"
+	"github.com/luis-olivetti/go-observability/service-a/internal/middleware"
+	"github.com/luis-olivetti/go-observability/service-a/internal/mirror"
+	"github.com/luis-olivetti/go-observability/service-a/internal/prober"
+	"github.com/luis-olivetti/go-observability/service-a/internal/quota"
+	"github.com/luis-olivetti/go-observability/service-a/internal/server"
+	"github.com/luis-olivetti/go-observability/service-a/internal/workerpool"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type Message struct {
-	ZipCode string `json:"cep"`
-}
+func newQuotaMeter(cfg config.Quota) *quota.Meter {
+	var store quota.Store = quota.NewMemoryStore()
+	if cfg.RedisAddr != "" {
+		store = quota.NewRedisStore(cfg.RedisAddr, cfg.RedisPassword)
+	}
 
-type TemperatureWithCity struct {
-	Celsius    float64 `json:"temp_C"`
-	Fahrenheit float64 `json:"temp_F"`
-	Kelvin     float64 `json:"temp_K"`
-	CityName   string  `json:"city"`
+	return quota.NewMeter(store, cfg.Defaults, cfg.Overrides, auth.KeyIDFromContext)
 }
 
-var tracer = otel.Tracer("microservice-tracer")
+// newChaosInjector returns nil when chaos mode is off; it is meant for
+// staging game days only.
+func newChaosInjector(cfg *chaos.Config) (*chaos.Injector, error) {
+	if cfg == nil {
+		return nil, nil
+	}
 
-func initProvider(serviceName, collectorUrl string) (func(context.Context) error, error) {
-	ctx := context.Background()
+	injector, err := chaos.New(*cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceName(serviceName),
-		),
-	)
+	log.Println("WARNING: chaos mode is enabled; upstream calls and spans will be disrupted")
+	return injector, nil
+}
+
+// newServiceBHTTPClient builds the client of a service B instance, with the
+// authentication and signing configured for service B.
+func newServiceBHTTPClient(cfg *config.Config, name string, upstream config.Upstream, injector *chaos.Injector) (*http.Client, error) {
+	client, err := clients.NewHTTPClient(name, upstream, injector)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create resource: %w", err)
+		return nil, err
+	}
+
+	if cfg.OAuth != nil {
+		client.Transport = &auth.Transport{
+			Source: auth.NewClientCredentialsSource(*cfg.OAuth),
+			Base:   client.Transport,
+		}
+	}
+
+	if cfg.HMACSecret != "" {
+		client.Transport = &reqsign.SigningTransport{
+			Secret: []byte(cfg.HMACSecret),
+			Base:   client.Transport,
+		}
 	}
 
-	conn, err := grpc.Dial(collectorUrl,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(),
-	)
+	return client, nil
+}
+
+// newMirror copies a share of the calls of primary to the staging service B
+// of cfg.Mirror. Chaos faults are never injected into the copies. The
+// returned pool sends them and must be closed on shutdown.
+func newMirror(cfg *config.Config, primary handlers.WeatherService, tracer trace.Tracer) (*mirror.Weather, *workerpool.Pool, error) {
+	client, err := newServiceBHTTPClient(cfg, "service-b-mirror", cfg.Mirror.Staging, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create grpc connection to collector: %w", err)
+		return nil, nil, err
 	}
+	client.Transport = &mirror.Transport{Base: client.Transport}
 
-	traceExporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithGRPCConn(conn))
+	pool, err := workerpool.New(workerpool.Config{
+		Name:       "mirror",
+		Workers:    cfg.Mirror.Workers,
+		QueueDepth: cfg.Mirror.QueueDepth,
+	}, tracer)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+		return nil, nil, err
+	}
+
+	log.Printf("mirroring %g%% of the calls to service B to %s", cfg.Mirror.Percent, cfg.Mirror.Staging.BaseURL)
+	staging := clients.NewServiceBClient(client, cfg.Mirror.Staging.BaseURL, tracer)
+	return mirror.NewWeather(mirror.Config{Percent: cfg.Mirror.Percent, Timeout: cfg.Mirror.Timeout}, primary, staging, pool), pool, nil
+}
+
+// newRouter serves the public API. quotaMeter is nil without API keys.
+func newRouter(chains *middleware.Registry, quotaMeter *quota.Meter, zipcode, alerts http.Handler) *mux.Router {
+	r := mux.NewRouter()
+	r.NotFoundHandler = http.HandlerFunc(problem.NotFound)
+	r.Use(chains.Resolve(publicChain...)...)
+	if quotaMeter != nil {
+		r.HandleFunc("/usage", quotaMeter.UsageHandler)
 	}
+	// The prober calls the zipcode handler directly: synthetic traffic must
+	// not count towards the objectives.
+	r.Handle(handlers.ZipcodeRoute, chains.Chain(zipcode, zipcodeChain...))
+	r.Handle(handlers.AlertsRoute, chains.Chain(alerts, alertsChain...))
 
-	bsp := sdktrace.NewBatchSpanProcessor(traceExporter)
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		sdktrace.WithResource(res),
-		sdktrace.WithSpanProcessor(bsp),
-	)
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return r
+}
+
+// newAdminRouter serves the health checks, the metrics and, to
+// authenticated callers with the right role, the /admin routes and pprof.
+// override is nil without sampling.
+func newAdminRouter(cfg *config.Config, chains *middleware.Registry, checker *health.Checker, metrics http.Handler, objectives *slo.Recorder, override *sampling.Override, auditLog *audit.Logger) *mux.Router {
+	admin := mux.NewRouter()
+	admin.Use(chains.Resolve(adminChain...)...)
+	admin.HandleFunc("/healthz", checker.Liveness)
+	admin.HandleFunc("/readyz", checker.Readiness)
+	admin.HandleFunc("/startupz", checker.Startup)
+	admin.Handle("/metrics", metrics)
+
+	// The /admin routes check roles, so without authentication they are not
+	// served: anyone reaching the admin port could read the SLO status or
+	// change the sampling.
+	authenticated := len(chains.Resolve(adminAuthChain...)) > 0
+	if authenticated {
+		status := admin.PathPrefix("/admin/slo").Subrouter()
+		status.Use(chains.Resolve(adminAuthChain...)...)
+		status.Use(auth.NewRoleResolver(cfg.KeyRoles).Require(auth.RoleViewer))
+		status.HandleFunc("", objectives.StatusHandler)
+	}
+
+	if override != nil && authenticated {
+		overrides := admin.PathPrefix("/admin/sampling").Subrouter()
+		overrides.Use(chains.Resolve(adminAuthChain...)...)
+		overrides.Use(auth.NewRoleResolver(cfg.KeyRoles).Require(auth.RoleOperator))
+		overrides.Handle("", &samplingAdmin{override: override, audit: auditLog})
+	}
+
+	if cfg.EnablePprof {
+		debug := admin.PathPrefix("/debug/pprof").Subrouter()
+		debug.Use(chains.Resolve(adminAuthChain...)...)
+		debug.Use(auth.NewRoleResolver(cfg.KeyRoles).Require(auth.RoleAdmin))
+		debug.HandleFunc("/cmdline", pprof.Cmdline)
+		debug.HandleFunc("/profile", pprof.Profile)
+		debug.HandleFunc("/symbol", pprof.Symbol)
+		debug.HandleFunc("/trace", pprof.Trace)
+		debug.PathPrefix("/").HandlerFunc(pprof.Index)
+	}
+
+	return admin
+}
+
+// newInternalRouter serves the API to callers inside the cluster. They reach
+// it on its own port and authenticate with JWT when it is configured; API
+// keys, quotas and abuse detection are for external consumers only.
+func newInternalRouter(chains *middleware.Registry, zipcode http.Handler) http.Handler {
+	r := mux.NewRouter()
+	r.NotFoundHandler = http.HandlerFunc(problem.NotFound)
+	r.Use(chains.Resolve(internalChain...)...)
+	r.Handle(handlers.ZipcodeRoute, zipcode)
 
-	return tp.Shutdown, nil
+	return r
 }
 
-func init() {
-	viper.AutomaticEnv()
+// prewarm opens upstream connections before the instance reports ready, then
+// keeps them warm in the background.
+func prewarm(ctx context.Context, cfg httpclient.WarmConfig, client *http.Client, baseURL string) {
+	httpclient.Warm(ctx, client, baseURL, cfg)
+	go httpclient.KeepWarm(ctx, client, baseURL, cfg)
+}
+
+// applyTunables swaps in the sampling rules, feature flags and service B
+// timeout of a reloaded config file; new sampling rules wait for an override
+// in progress to end. The span pipeline is built at startup, so sampling
+// cannot be switched on at runtime; removing the rules keeps every trace.
+func applyTunables(cfg *config.Config, override *sampling.Override, flags *featureflag.StaticProvider, tunables config.Tunables) {
+	flags.Set(tunables.Features)
+	cfg.ServiceB.HTTP.Timeout.Set(tunables.ServiceBTimeout)
+
+	switch {
+	case override == nil && tunables.Sampling != nil:
+		log.Println("sampling can only be enabled at startup; restart to apply SAMPLING_RATIO")
+	case override != nil && tunables.Sampling == nil:
+		override.Configure(sampling.Config{Ratio: 1})
+	case override != nil:
+		override.Configure(*tunables.Sampling)
+	}
 }
 
 func main() {
+	runCLI(os.Args[1:])
+}
+
+// serve runs the service until SIGINT or SIGTERM.
+func serve() {
+	limits := runtimelimits.Apply()
+	log.Printf("runtime limits: %s", limits)
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
@@ -92,119 +234,200 @@ func main() {
 		cancel()
 	}()
 
-	shutdown, err := initProvider(viper.GetString("OTEL_SERVICE_NAME"), viper.GetString("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("failed to initialize provider: %v", err)
+		log.Fatalf("failed to load configuration: %v", err)
 	}
-	defer func() {
-		if err := shutdown(ctx); err != nil {
-			log.Fatalf("failed to shutdown TraceProvider: %v", err)
-		}
-	}()
+	logsample.Default = logsample.New(cfg.LogSampling)
+	errclass.Default = errclass.NewMapping(cfg.ErrorStatuses)
+	messages.Default = messages.NewCatalog(cfg.Messages)
 
-	r := mux.NewRouter()
-	r.HandleFunc("/city-by-zipcode", zipcodeHandler)
-
-	srv := &http.Server{
-		Addr:         ":" + viper.GetString("HTTP_PORT"),
-		Handler:      r,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 5 * time.Second,
+	upgrader, err := server.NewUpgrader(cfg.PIDFile)
+	if err != nil {
+		log.Fatalf("failed to set up binary upgrades: %v", err)
 	}
 
-	go func() {
-		log.Printf("Server started at http://localhost:%s\n", viper.GetString("HTTP_PORT"))
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Error starting server: %v\n", err)
-		}
-	}()
-
-	<-ctx.Done()
+	scrubber, err := redact.NewScrubber(cfg.RedactPatterns, cfg.CEPs)
+	if err != nil {
+		log.Fatalf("failed to create span scrubber: %v", err)
+	}
 
-	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancelShutdown()
+	injector, err := newChaosInjector(cfg.Chaos)
+	if err != nil {
+		log.Fatalf("failed to configure chaos mode: %v", err)
+	}
 
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Fatalf("Server shutdown failed: %v\n", err)
+	var policy *sampling.Policy
+	var override *sampling.Override
+	if cfg.Sampling != nil {
+		policy = sampling.NewPolicy(*cfg.Sampling)
+		override = sampling.NewOverride(policy)
 	}
 
-	log.Println("Server shutdown completed.")
-}
+	metrics, err := telemetry.NewPrometheusExporter()
+	if err != nil {
+		log.Fatalf("failed to create metrics exporter: %v", err)
+	}
+	shutdown, err := telemetry.Setup(ctx, telemetry.Config{
+		ServiceName:  cfg.ServiceName,
+		Exporter:     cfg.TracesExporter,
+		CollectorURL: cfg.CollectorURL,
+		Protocol:     cfg.OTLPProtocol,
+		Deployment: telemetry.Deployment{
+			Environment: cfg.DeploymentEnvironment,
+			Region:      cfg.Region,
+			Version:     version,
+			Revision:    revision(),
+		},
+		Attributes: limits.Attributes(),
+		LogLevel:   cfg.LogLevel,
+		Scrubber:   scrubber,
+		Injector:   injector,
+		Policy:     policy,
+		Metrics:    metrics,
+	})
+	if err != nil {
+		log.Fatalf("failed to initialize provider: %v", err)
+	}
+	defer func() {
+		// ctx is already cancelled here; flushing needs its own deadline.
+		flushCtx, cancel := context.WithTimeout(context.Background(), server.ShutdownTimeout)
+		defer cancel()
 
-func zipcodeHandler(w http.ResponseWriter, r *http.Request) {
-	carrier := propagation.HeaderCarrier(r.Header)
-	ctx := r.Context()
-	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+		if err := shutdown(flushCtx); err != nil {
+			log.Fatalf("failed to shutdown TraceProvider: %v", err)
+		}
+	}()
 
-	ctx, span := tracer.Start(ctx, "zipcodeHandler")
-	defer span.End()
+	tracer := otel.Tracer(telemetry.TracerName)
 
-	var msg Message
-	err := json.NewDecoder(r.Body).Decode(&msg)
+	flags := featureflag.NewStaticProvider(cfg.Features)
+	features, err := featureflag.NewClient(cfg.ServiceName, flags)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		span.RecordError(err)
-		return
+		log.Fatalf("failed to set up feature flags: %v", err)
 	}
 
-	zipCodeRegex := regexp.MustCompile(`^\d{8}$`)
-	if !zipCodeRegex.MatchString(msg.ZipCode) {
-		http.Error(w, "Invalid zipcode", http.StatusUnprocessableEntity)
-		span.RecordError(fmt.Errorf("invalid zipcode: %s", msg.ZipCode))
-		return
-	}
+	auditLog := audit.NewLogger(cfg.AuditKey, audit.NewLogSink(slog.Default().Handler()))
+	config.WatchFile(func(reload config.Reload) {
+		applyTunables(cfg, override, flags, reload.Tunables)
+
+		err := auditLog.Record(ctx, audit.Event{
+			Actor:  "config-watcher",
+			Action: "config.reload",
+			Target: reload.Path,
+			Details: map[string]string{
+				"version":  strconv.FormatInt(reload.Version, 10),
+				"checksum": reload.Checksum,
+			},
+		})
+		if err != nil {
+			log.Printf("failed to audit config reload: %v", err)
+		}
+	})
 
-	_, citySpan := tracer.Start(ctx, "SearchCityByZipCode")
-	defer citySpan.End()
+	externalClient, err := newServiceBHTTPClient(cfg, "service-b", cfg.ServiceB, injector)
+	if err != nil {
+		log.Fatalf("failed to create external call client: %v", err)
+	}
 
-	resp, err := makeHTTPRequestWithPropagation(ctx, viper.GetString("EXTERNAL_CALL_URL")+"/city-weather?zipcode="+msg.ZipCode)
+	ipFilter, err := ipfilter.New(cfg.IPFilter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		span.RecordError(err)
-		return
+		log.Fatalf("failed to create ip filter: %v", err)
+	}
+	adminIPFilter, err := ipfilter.New(cfg.AdminIPFilter)
+	if err != nil {
+		log.Fatalf("failed to create admin ip filter: %v", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
+	serviceB := clients.NewServiceBClient(externalClient, cfg.ServiceB.BaseURL, tracer)
+	var weather handlers.WeatherService = serviceB
+	if cfg.Mirror != nil {
+		mirrored, pool, err := newMirror(cfg, weather, tracer)
 		if err != nil {
-			http.Error(w, "Failed to read response body", http.StatusInternalServerError)
-			span.RecordError(err)
-			return
+			log.Fatalf("failed to set up mirroring: %v", err)
 		}
-
-		http.Error(w, string(body), resp.StatusCode)
-		span.RecordError(fmt.Errorf("service B returned non-OK status: %d", resp.StatusCode))
-		return
+		defer func() {
+			closeCtx, cancel := context.WithTimeout(context.Background(), server.ShutdownTimeout)
+			defer cancel()
+
+			if err := pool.Close(closeCtx); err != nil {
+				log.Printf("mirrored calls still running at shutdown were abandoned: %v", err)
+			}
+		}()
+		weather = mirrored
 	}
 
-	var cityWeatherResponse TemperatureWithCity
-	err = json.NewDecoder(resp.Body).Decode(&cityWeatherResponse)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		span.RecordError(err)
-		return
+	zipcodeHandler := handlers.NewZipcodeHandler(weather, features, tracer, cfg.DebugTraceURL)
+	alertsHandler := handlers.NewAlertsHandler(serviceB, features, tracer)
+
+	objectives := slo.NewRecorder(cfg.SLO)
+	var quotaMeter *quota.Meter
+	if len(cfg.APIKeys) > 0 {
+		quotaMeter = newQuotaMeter(cfg.Quota)
 	}
+	chains := newMiddlewares(cfg, ipFilter, adminIPFilter, objectives, quotaMeter)
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(cityWeatherResponse)
-}
+	r := newRouter(chains, quotaMeter, zipcodeHandler, alertsHandler)
 
-func makeHTTPRequestWithPropagation(ctx context.Context, url string) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
+	checker := health.NewChecker()
+
+	startupChecks := []health.StartupCheck{health.DNSCheck("service-b", cfg.ServiceB.BaseURL)}
+	if cfg.StartupUpstreamCheck {
+		startupChecks = append(startupChecks, health.UpstreamCheck("service-b", externalClient, cfg.ServiceB.BaseURL))
+	}
+	checker.RunStartupChecks(ctx, startupChecks...)
+
+	admin := newAdminRouter(cfg, chains, checker, metrics, objectives, override, auditLog)
+
+	listeners := []server.Listener{{
+		Name: server.Public,
+		Server: &http.Server{
+			Addr:         ":" + cfg.HTTPPort,
+			Handler:      r,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 5 * time.Second,
+		},
+		CertFile: cfg.PublicTLS.CertFile,
+		KeyFile:  cfg.PublicTLS.KeyFile,
+	}}
+
+	if cfg.InternalPort != "" {
+		listeners = append(listeners, server.Listener{
+			Name: server.Internal,
+			Server: &http.Server{
+				Addr:         ":" + cfg.InternalPort,
+				Handler:      newInternalRouter(chains, chains.Chain(zipcodeHandler, measuredChain...)),
+				ReadTimeout:  5 * time.Second,
+				WriteTimeout: 5 * time.Second,
+			},
+			CertFile: cfg.InternalTLS.CertFile,
+			KeyFile:  cfg.InternalTLS.KeyFile,
+		})
+	}
+
+	listeners = append(listeners, server.Listener{
+		Name: server.Admin,
+		Server: &http.Server{
+			Addr:         ":" + cfg.AdminPort,
+			Handler:      admin,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: cfg.AdminWriteTimeout,
+		},
+		CertFile: cfg.AdminTLS.CertFile,
+		KeyFile:  cfg.AdminTLS.KeyFile,
+	})
+
+	if cfg.Prewarm != nil {
+		prewarm(ctx, *cfg.Prewarm, externalClient, cfg.ServiceB.BaseURL)
 	}
 
-	propagator := otel.GetTextMapPropagator()
-	propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	if cfg.Prober != nil {
+		go prober.New(*cfg.Prober, zipcodeHandler, tracer).Run(ctx)
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	if err := server.Run(ctx, checker, upgrader, listeners...); err != nil {
+		log.Fatal(err)
 	}
 
-	return resp, nil
+	log.Println("Server shutdown completed.")
 }
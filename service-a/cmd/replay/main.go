@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type headerFlags []string
+
+func (h *headerFlags) String() string { return strings.Join(*h, ", ") }
+
+func (h *headerFlags) Set(value string) error {
+	if !strings.Contains(value, ":") {
+		return fmt.Errorf("header must be in the form 'Name: value': %s", value)
+	}
+	*h = append(*h, value)
+	return nil
+}
+
+// hopHeaders are tied to the original connection and must not be replayed.
+var hopHeaders = []string{"Connection", "Content-Length", "Host", "Keep-Alive", "Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+type results struct {
+	mu         sync.Mutex
+	sent       int
+	failed     int
+	mismatched int
+	byStatus   map[int]int
+	latencies  []time.Duration
+}
+
+func (r *results) record(rec record, status int, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sent++
+	if err != nil {
+		r.failed++
+		return
+	}
+
+	r.byStatus[status]++
+	r.latencies = append(r.latencies, latency)
+	if rec.Status != 0 && rec.Status != status {
+		r.mismatched++
+	}
+}
+
+func (r *results) summary() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var statuses []string
+	for status, count := range r.byStatus {
+		statuses = append(statuses, fmt.Sprintf("%d=%d", status, count))
+	}
+	sort.Strings(statuses)
+
+	sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+	return fmt.Sprintf("sent=%d failed=%d status[%s] status_mismatches=%d p50=%s p95=%s p99=%s",
+		r.sent, r.failed, strings.Join(statuses, " "), r.mismatched,
+		percentile(r.latencies, 0.50), percentile(r.latencies, 0.95), percentile(r.latencies, 0.99))
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(float64(len(sorted)-1)*p)].Round(time.Millisecond)
+}
+
+func main() {
+	var headers headerFlags
+
+	target := flag.String("target", "http://localhost:8080", "base URL requests are replayed against; the recorded scheme and host are replaced")
+	format := flag.String("format", "", "input format, har or jsonl (default: .har files are HAR, anything else is jsonl)")
+	speed := flag.Float64("speed", 1, "pace multiplier: 1 keeps the recorded pace, 2 replays twice as fast, 0 sends as fast as possible")
+	maxInFlight := flag.Int("max-inflight", 100, "maximum concurrent requests")
+	loop := flag.Bool("loop", false, "start over when the recording ends, until interrupted")
+	flag.Var(&headers, "H", "header overriding the recorded one, e.g. -H 'X-API-Key: staging-key' (repeatable)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: replay [flags] file.har|access.jsonl...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *speed < 0 {
+		log.Fatalf("-speed must not be negative")
+	}
+
+	base, err := neturl.Parse(*target)
+	if err != nil || base.Host == "" {
+		log.Fatalf("invalid -target %q", *target)
+	}
+
+	records, err := loadRecords(flag.Args(), *format)
+	if err != nil {
+		log.Fatalf("failed to load recordings: %v", err)
+	}
+	if len(records) == 0 {
+		log.Fatalf("no requests found in %s", strings.Join(flag.Args(), ", "))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	stats := &results{byStatus: map[int]int{}}
+	slots := make(chan struct{}, *maxInFlight)
+	var wg sync.WaitGroup
+
+	log.Printf("replaying %d requests against %s at %gx", len(records), base, *speed)
+	for ctx.Err() == nil {
+		replay(ctx, client, base, records, headers, *speed, slots, &wg, stats)
+		if !*loop {
+			break
+		}
+	}
+
+	wg.Wait()
+	log.Printf("done: %s", stats.summary())
+}
+
+// replay schedules each record at its offset from the first one, scaled by
+// speed. Requests run concurrently so a slow response does not delay the
+// ones recorded after it.
+func replay(ctx context.Context, client *http.Client, base *neturl.URL, records []record, headers headerFlags, speed float64, slots chan struct{}, wg *sync.WaitGroup, stats *results) {
+	start := time.Now()
+	first := records[0].Time
+
+	for _, rec := range records {
+		if speed > 0 && !rec.Time.IsZero() && !first.IsZero() {
+			offset := time.Duration(float64(rec.Time.Sub(first)) / speed)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(start.Add(offset))):
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case slots <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(rec record) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			began := time.Now()
+			status, err := send(ctx, client, base, rec, headers)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("%s %s: %v", rec.Method, rec.URL, err)
+			}
+			stats.record(rec, status, time.Since(began), err)
+		}(rec)
+	}
+}
+
+func send(ctx context.Context, client *http.Client, base *neturl.URL, rec record, headers headerFlags) (int, error) {
+	recorded, err := neturl.Parse(rec.URL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid recorded url: %w", err)
+	}
+
+	url := *base
+	url.Path = strings.TrimRight(base.Path, "/") + recorded.Path
+	url.RawQuery = recorded.RawQuery
+
+	req, err := http.NewRequestWithContext(ctx, rec.Method, url.String(), strings.NewReader(rec.Body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header = rec.Header.Clone()
+	for _, name := range hopHeaders {
+		req.Header.Del(name)
+	}
+	for _, header := range headers {
+		name, value, _ := strings.Cut(header, ":")
+		req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, nil
+}
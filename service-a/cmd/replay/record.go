@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// record is one captured request, whatever the source format.
+type record struct {
+	Time   time.Time
+	Method string
+	URL    string
+	Header http.Header
+	Body   string
+	Status int
+	Source string
+}
+
+// accessLogEntry is one line of a JSON lines access log. Neither service
+// writes access logs itself; this is the shape a proxy or gateway in front of
+// service A is expected to log in, one request per line.
+type accessLogEntry struct {
+	Time    time.Time         `json:"time"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+	Status  int               `json:"status"`
+}
+
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			StartedDateTime time.Time `json:"startedDateTime"`
+			Request         struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				PostData *struct {
+					Text string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+			Response struct {
+				Status int `json:"status"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// loadRecords reads every file, picking the format from the extension unless
+// one is forced, and returns the requests sorted by capture time.
+func loadRecords(files []string, format string) ([]record, error) {
+	var records []record
+	for _, file := range files {
+		fileFormat := format
+		if fileFormat == "" {
+			fileFormat = "jsonl"
+			if strings.EqualFold(filepath.Ext(file), ".har") {
+				fileFormat = "har"
+			}
+		}
+
+		var parsed []record
+		var err error
+		switch fileFormat {
+		case "har":
+			parsed, err = readHAR(file)
+		case "jsonl":
+			parsed, err = readAccessLog(file)
+		default:
+			return nil, fmt.Errorf("unknown format %q, expected har or jsonl", fileFormat)
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, parsed...)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records, nil
+}
+
+func readHAR(file string) ([]record, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HAR file: %w", err)
+	}
+
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	records := make([]record, 0, len(har.Log.Entries))
+	for _, entry := range har.Log.Entries {
+		header := http.Header{}
+		for _, h := range entry.Request.Headers {
+			// HTTP/2 pseudo-headers such as :authority are not real headers.
+			if strings.HasPrefix(h.Name, ":") {
+				continue
+			}
+			header.Add(h.Name, h.Value)
+		}
+
+		rec := record{
+			Time:   entry.StartedDateTime,
+			Method: entry.Request.Method,
+			URL:    entry.Request.URL,
+			Header: header,
+			Status: entry.Response.Status,
+			Source: file,
+		}
+		if entry.Request.PostData != nil {
+			rec.Body = entry.Request.PostData.Text
+		}
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+func readAccessLog(file string) ([]record, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open access log: %w", err)
+	}
+	defer f.Close()
+
+	var records []record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var entry accessLogEntry
+		if err := json.Unmarshal([]byte(text), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse %s:%d: %w", file, line, err)
+		}
+
+		header := http.Header{}
+		for name, value := range entry.Headers {
+			header.Set(name, value)
+		}
+
+		method := entry.Method
+		if method == "" {
+			method = http.MethodGet
+		}
+
+		records = append(records, record{
+			Time:   entry.Time,
+			Method: method,
+			URL:    entry.URL,
+			Header: header,
+			Body:   entry.Body,
+			Status: entry.Status,
+			Source: file,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	return records, nil
+}
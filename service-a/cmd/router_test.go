@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/metrictesting"
+	"github.com/luis-olivetti/go-observability/pkg/platform/redact"
+	"github.com/luis-olivetti/go-observability/pkg/platform/slo"
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"github.com/luis-olivetti/go-observability/pkg/platform/tracetesting"
+	"github.com/luis-olivetti/go-observability/service-a/internal/auth"
+	"github.com/luis-olivetti/go-observability/service-a/internal/clients"
+	"github.com/luis-olivetti/go-observability/service-a/internal/config"
+	"github.com/luis-olivetti/go-observability/service-a/internal/handlers"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// serviceBServer stands in for service B, answering /city-weather with
+// status and recording the traceparent header it was called with.
+func serviceBServer(t *testing.T, status int, traceparent *string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*traceparent = r.Header.Get("traceparent")
+		if status != http.StatusOK {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(status)
+			fmt.Fprintf(w, `{"status": %d, "code": "UPSTREAM_ERROR"}`, status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"temp_C": 25, "temp_F": 77, "temp_K": 298, "city": "Linhares"}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestRouter wires the real handlers, client and middleware chains the
+// way serve does, against serviceB, recording spans in memory. With keys, the
+// public routes require one of them.
+func newTestRouter(t *testing.T, serviceB string, keys ...auth.APIKey) (http.Handler, *tracetest.InMemoryExporter) {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	scrubber, err := redact.NewScrubber(nil, redact.DefaultCEPPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp := telemetry.NewTracerProvider(sdktrace.NewSimpleSpanProcessor(exporter), scrubber, nil)
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+	telemetry.Install(tp)
+	tracer := tp.Tracer(telemetry.TracerName)
+
+	cfg := &config.Config{ServiceB: config.Upstream{BaseURL: serviceB}, APIKeys: keys}
+	client, err := clients.NewHTTPClient("service-b", cfg.ServiceB, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weather := clients.NewServiceBClient(client, cfg.ServiceB.BaseURL, tracer)
+	chains := newMiddlewares(cfg, nil, nil, slo.NewRecorder(cfg.SLO), nil)
+
+	return newRouter(chains, nil,
+		handlers.NewZipcodeHandler(weather, nil, tracer, ""),
+		handlers.NewAlertsHandler(weather, nil, tracer),
+	), exporter
+}
+
+func TestZipcodeSpans(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceBStatus int
+		wantStatus     int
+		wantClientSpan bool
+		wantCode       codes.Code
+		wantErrType    string
+	}{
+		{
+			name:           "success",
+			body:           `{"cep": "29902555"}`,
+			serviceBStatus: http.StatusOK,
+			wantStatus:     http.StatusOK,
+			wantClientSpan: true,
+			wantCode:       codes.Unset,
+		},
+		{
+			name:           "service B failing",
+			body:           `{"cep": "29902555"}`,
+			serviceBStatus: http.StatusBadGateway,
+			wantStatus:     http.StatusBadGateway,
+			wantClientSpan: true,
+			wantCode:       codes.Error,
+			wantErrType:    "UPSTREAM_ERROR",
+		},
+		{
+			name:           "invalid zipcode",
+			body:           `{"cep": "123"}`,
+			serviceBStatus: http.StatusOK,
+			wantStatus:     http.StatusUnprocessableEntity,
+			// Client errors leave a server span's status unset.
+			wantCode:    codes.Unset,
+			wantErrType: "ZIPCODE_INVALID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := metrictesting.Install()
+			t.Cleanup(func() { metrics.Shutdown(context.Background()) })
+			var traceparent string
+			router, exporter := newTestRouter(t, serviceBServer(t, tt.serviceBStatus, &traceparent).URL)
+
+			req := httptest.NewRequest(http.MethodPost, handlers.ZipcodeRoute, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			metrics.AssertHistogramCount(t, "http.server.request.duration", 1,
+				attribute.String("http.route", handlers.ZipcodeRoute),
+				attribute.Int("http.response.status_code", tt.wantStatus))
+			metrics.AssertNotRecorded(t, "auth.api_key.requests")
+
+			spans := exporter.GetSpans()
+			server := tracetesting.FindSpan(t, spans, "POST "+handlers.ZipcodeRoute)
+			if server.SpanKind != trace.SpanKindServer {
+				t.Errorf("server span kind = %v, want server", server.SpanKind)
+			}
+			if server.Parent.IsValid() {
+				t.Errorf("server span has parent %s, want a root span", server.Parent.SpanID())
+			}
+			tracetesting.AssertAttr(t, server, "http.route", handlers.ZipcodeRoute)
+			tracetesting.AssertStatus(t, server, tt.wantCode)
+			if tt.wantErrType != "" {
+				tracetesting.AssertAttr(t, server, "error.type", tt.wantErrType)
+			} else {
+				tracetesting.AssertNoAttr(t, server, "error.type")
+			}
+
+			if !tt.wantClientSpan {
+				if len(spans) != 1 || traceparent != "" {
+					t.Errorf("got %d spans and service B called with %q, want only the server span", len(spans), traceparent)
+				}
+				return
+			}
+
+			client := tracetesting.FindSpan(t, spans, "SearchCityByZipCode")
+			tracetesting.AssertChildOf(t, client, server)
+			want := fmt.Sprintf("00-%s-%s-01", client.SpanContext.TraceID(), client.SpanContext.SpanID())
+			if traceparent != want {
+				t.Errorf("service B called with traceparent %q, want %q", traceparent, want)
+			}
+		})
+	}
+}
+
+func TestAPIKeyMetrics(t *testing.T) {
+	metrics := metrictesting.Install()
+	t.Cleanup(func() { metrics.Shutdown(context.Background()) })
+	var traceparent string
+	key := auth.APIKey{ID: "mobile", Secret: "s3cret"}
+	router, _ := newTestRouter(t, serviceBServer(t, http.StatusOK, &traceparent).URL, key)
+
+	call := func(secret string) int {
+		req := httptest.NewRequest(http.MethodPost, handlers.ZipcodeRoute, strings.NewReader(`{"cep": "29902555"}`))
+		if secret != "" {
+			req.Header.Set(auth.APIKeyHeader, secret)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for _, secret := range []string{"", "wrong"} {
+		if status := call(secret); status != http.StatusUnauthorized {
+			t.Fatalf("status with key %q = %d, want 401", secret, status)
+		}
+	}
+	metrics.AssertSum(t, "auth.api_key.requests", 2,
+		attribute.String("auth.key_id_hash", "unknown"),
+		attribute.String("auth.result", "rejected"))
+	// Rejected requests never reach the handler.
+	metrics.AssertNotRecorded(t, "http.server.request.duration")
+
+	if status := call(key.Secret); status != http.StatusOK {
+		t.Fatalf("status with a valid key = %d, want 200", status)
+	}
+	metrics.AssertSum(t, "auth.api_key.requests", 1,
+		attribute.String("auth.key_id_hash", auth.HashKeyID(key.ID)),
+		attribute.String("auth.result", "accepted"))
+	metrics.AssertHistogramCount(t, "http.server.request.duration", 1,
+		attribute.String("http.route", handlers.ZipcodeRoute),
+		attribute.Int("http.response.status_code", http.StatusOK))
+}
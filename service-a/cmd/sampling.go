@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/audit"
+	"github.com/luis-olivetti/go-observability/pkg/platform/bufpool"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+	"github.com/luis-olivetti/go-observability/pkg/platform/sampling"
+	"github.com/luis-olivetti/go-observability/service-a/internal/auth"
+	"github.com/luis-olivetti/go-observability/service-a/internal/validation"
+)
+
+// samplingOverrideRequest is the body of PUT /admin/sampling.
+type samplingOverrideRequest struct {
+	Ratio    *float64 `json:"ratio"`
+	Duration string   `json:"duration"`
+}
+
+// samplingAdmin serves /admin/sampling: GET reports the sampling in effect,
+// PUT overrides the ratio for a while and DELETE ends the override early.
+// Every change is audited.
+type samplingAdmin struct {
+	override *sampling.Override
+	audit    *audit.Logger
+}
+
+func (a *samplingAdmin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		if !a.start(w, r) {
+			return
+		}
+	case http.MethodDelete:
+		if a.override.Stop() {
+			a.record(r.Context(), auth.Actor(r.Context()), "sampling.override.stop", nil)
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		problem.Write(w, r, http.StatusMethodNotAllowed, contracts.CodeMethodNotAllowed, nil, nil)
+		return
+	}
+
+	if err := bufpool.WriteJSON(w, http.StatusOK, "application/json", a.override.Status()); err != nil {
+		log.Printf("failed to write sampling status: %v", err)
+	}
+}
+
+func (a *samplingAdmin) start(w http.ResponseWriter, r *http.Request) bool {
+	var req samplingOverrideRequest
+	if err := validation.Decode(r, &req, validation.DefaultMaxDepth); err != nil {
+		var decodeErr *validation.DecodeError
+		if errors.As(err, &decodeErr) {
+			problem.Write(w, r, decodeErr.Status, decodeErr.Code(), nil, decodeErr.Fields)
+		} else {
+			problem.Write(w, r, http.StatusBadRequest, contracts.CodeBadRequest, nil, nil)
+		}
+		return false
+	}
+
+	var fields []problem.FieldError
+	if req.Ratio == nil || *req.Ratio < 0 || *req.Ratio > 1 {
+		fields = append(fields, problem.FieldError{Field: "ratio", Message: "must be between 0 and 1"})
+	}
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 || duration > sampling.MaxOverride {
+		fields = append(fields, problem.FieldError{Field: "duration", Message: "must be a duration above zero and at most " + sampling.MaxOverride.String() + ", such as 10m"})
+	}
+	if len(fields) > 0 {
+		problem.Write(w, r, http.StatusUnprocessableEntity, contracts.CodeValidationFailed, nil, fields)
+		return false
+	}
+
+	actor := auth.Actor(r.Context())
+	err = a.override.Start(*req.Ratio, duration, func() {
+		a.record(context.Background(), "sampling-override", "sampling.override.end", map[string]string{"started_by": actor})
+	})
+	if err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, contracts.CodeInternal, nil, nil)
+		log.Printf("failed to override sampling: %v", err)
+		return false
+	}
+
+	a.record(r.Context(), actor, "sampling.override.start", map[string]string{
+		"ratio":    strconv.FormatFloat(*req.Ratio, 'g', -1, 64),
+		"duration": duration.String(),
+	})
+	return true
+}
+
+func (a *samplingAdmin) record(ctx context.Context, actor, action string, details map[string]string) {
+	err := a.audit.Record(ctx, audit.Event{
+		Actor:   actor,
+		Action:  action,
+		Target:  "/admin/sampling",
+		Details: details,
+	})
+	if err != nil {
+		log.Printf("failed to audit %s: %v", action, err)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/redact"
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"github.com/luis-olivetti/go-observability/service-a/internal/clients"
+	"github.com/luis-olivetti/go-observability/service-a/internal/handlers"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const smokeZipcode = "29902555"
+
+// smokeServiceB stands in for service B. It answers only when the trace
+// context was propagated, so a broken propagator fails the smoke check.
+func smokeServiceB() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/city-weather" || r.URL.Query().Get("zipcode") != smokeZipcode {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("traceparent") == "" {
+			http.Error(w, "missing traceparent", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"temp_C":25,"temp_F":77,"temp_K":298.15,"city":"Linhares"}`)
+	})
+}
+
+// runSmoke sends a canned request through the real handler stack, backed by
+// an in-process service B and an in-memory span exporter, and returns an
+// error when the response or the emitted spans are not what a healthy build
+// produces.
+func runSmoke() error {
+	exporter := tracetest.NewInMemoryExporter()
+	scrubber, err := redact.NewScrubber(nil, redact.DefaultCEPPolicy)
+	if err != nil {
+		return fmt.Errorf("failed to create span scrubber: %w", err)
+	}
+
+	tp := telemetry.NewTracerProvider(sdktrace.NewSimpleSpanProcessor(exporter), scrubber, nil)
+	defer tp.Shutdown(context.Background())
+	telemetry.Install(tp)
+	tracer := tp.Tracer(telemetry.TracerName)
+
+	serviceB := httptest.NewServer(smokeServiceB())
+	defer serviceB.Close()
+
+	handler := handlers.NewZipcodeHandler(clients.NewServiceBClient(serviceB.Client(), serviceB.URL, tracer), nil, tracer, "")
+
+	req := httptest.NewRequest("POST", "/city-by-zipcode", strings.NewReader(`{"cep":"`+smokeZipcode+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", rec.Code, strings.TrimSpace(rec.Body.String()))
+	}
+
+	var body contracts.TemperatureWithCity
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	want := contracts.TemperatureWithCity{Celsius: 25, Fahrenheit: 77, Kelvin: 298.15, CityName: "Linhares"}
+	if body != want {
+		return fmt.Errorf("unexpected response %+v, want %+v", body, want)
+	}
+
+	return checkSmokeSpans(exporter.GetSpans(), "POST "+handlers.ZipcodeRoute, "SearchCityByZipCode")
+}
+
+// checkSmokeSpans verifies that root and each of children were emitted once,
+// in a single trace, with children directly under root and no error status.
+func checkSmokeSpans(spans tracetest.SpanStubs, root string, children ...string) error {
+	byName := make(map[string]tracetest.SpanStub)
+	for _, span := range spans {
+		if _, dup := byName[span.Name]; dup {
+			return fmt.Errorf("span %q emitted more than once", span.Name)
+		}
+		byName[span.Name] = span
+	}
+
+	parent, ok := byName[root]
+	if !ok {
+		return fmt.Errorf("span %q not emitted", root)
+	}
+
+	for _, name := range append([]string{root}, children...) {
+		span, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("span %q not emitted", name)
+		}
+		if span.Status.Code == codes.Error {
+			return fmt.Errorf("span %q has error status: %s", name, span.Status.Description)
+		}
+		if name == root {
+			continue
+		}
+		if span.SpanContext.TraceID() != parent.SpanContext.TraceID() || span.Parent.SpanID() != parent.SpanContext.SpanID() {
+			return fmt.Errorf("span %q is not a child of %q", name, root)
+		}
+	}
+
+	return nil
+}
+
+func smoke() {
+	if err := runSmoke(); err != nil {
+		log.Fatalf("smoke check failed: %v", err)
+	}
+	log.Println("smoke check passed")
+}
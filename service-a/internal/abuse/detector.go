@@ -0,0 +1,162 @@
+package abuse
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+	"github.com/luis-olivetti/go-observability/pkg/platform/security"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type Config struct {
+	// Threshold is the number of invalid lookups tolerated per Window.
+	Threshold int
+	Window    time.Duration
+	BlockFor  time.Duration
+	// Clock defaults to the wall clock when nil.
+	Clock clock.Clock
+}
+
+type clientState struct {
+	windowStart  time.Time
+	invalid      int
+	blockedUntil time.Time
+}
+
+// Detector tracks invalid-CEP responses per client and temporarily blocks
+// clients that look like they are scanning the CEP space.
+type Detector struct {
+	cfg      Config
+	clientID func(*http.Request) string
+
+	mu      sync.Mutex
+	clients map[string]*clientState
+
+	invalidCounter metric.Int64Counter
+	blockedCounter metric.Int64Counter
+}
+
+func NewDetector(cfg Config, clientID func(*http.Request) string) *Detector {
+	meter := otel.Meter("microservice-meter")
+
+	invalidCounter, err := meter.Int64Counter("abuse.invalid_requests",
+		metric.WithDescription("Requests rejected as invalid or unknown CEPs"))
+	if err != nil {
+		log.Printf("failed to create invalid requests counter: %v", err)
+	}
+
+	blockedCounter, err := meter.Int64Counter("abuse.blocked_requests",
+		metric.WithDescription("Requests rejected because the client is blocked"))
+	if err != nil {
+		log.Printf("failed to create blocked requests counter: %v", err)
+	}
+
+	return &Detector{
+		cfg:            cfg,
+		clientID:       clientID,
+		clients:        map[string]*clientState{},
+		invalidCounter: invalidCounter,
+		blockedCounter: blockedCounter,
+	}
+}
+
+func (d *Detector) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := d.clientID(r)
+
+		if until, blocked := d.blocked(client); blocked {
+			d.reject(w, r, client, until)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status == http.StatusUnprocessableEntity || recorder.status == http.StatusNotFound {
+			d.recordInvalid(r.Context(), client)
+		}
+	})
+}
+
+func (d *Detector) reject(w http.ResponseWriter, r *http.Request, client string, until time.Time) {
+	security.Record(r, security.ClientBlocked, "invalid_lookup_rate",
+		attribute.String("abuse.client", client),
+		attribute.String("abuse.blocked_until", until.UTC().Format(time.RFC3339)),
+	)
+
+	if d.blockedCounter != nil {
+		d.blockedCounter.Add(r.Context(), 1)
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(until.Sub(clock.Or(d.cfg.Clock).Now()).Seconds())+1))
+	problem.Write(w, r, http.StatusTooManyRequests, contracts.CodeClientBlocked, nil, nil)
+}
+
+func (d *Detector) blocked(client string) (time.Time, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, ok := d.clients[client]
+	if !ok || clock.Or(d.cfg.Clock).Now().After(state.blockedUntil) {
+		return time.Time{}, false
+	}
+
+	return state.blockedUntil, true
+}
+
+func (d *Detector) recordInvalid(ctx context.Context, client string) {
+	if d.invalidCounter != nil {
+		d.invalidCounter.Add(ctx, 1)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := clock.Or(d.cfg.Clock).Now()
+	d.sweep(now)
+
+	state, ok := d.clients[client]
+	if !ok || now.Sub(state.windowStart) > d.cfg.Window {
+		state = &clientState{windowStart: now}
+		d.clients[client] = state
+	}
+
+	state.invalid++
+	if state.invalid > d.cfg.Threshold {
+		state.blockedUntil = now.Add(d.cfg.BlockFor)
+		log.Printf("Blocking client %s for %s after %d invalid lookups", client, d.cfg.BlockFor, state.invalid)
+	}
+}
+
+// sweep drops clients whose window and block have both expired, keeping
+// memory bounded by the number of recently active clients.
+func (d *Detector) sweep(now time.Time) {
+	if len(d.clients) < 1024 {
+		return
+	}
+
+	for client, state := range d.clients {
+		if now.Sub(state.windowStart) > d.cfg.Window && now.After(state.blockedUntil) {
+			delete(d.clients, client)
+		}
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
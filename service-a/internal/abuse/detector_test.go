@@ -0,0 +1,150 @@
+package abuse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+)
+
+// newTestDetector blocks a client after 3 invalid lookups within a minute,
+// for 5 minutes. The handler answers with the status in the X-Status header.
+func newTestDetector(clk clock.Clock) http.Handler {
+	detector := NewDetector(Config{Threshold: 3, Window: time.Minute, BlockFor: 5 * time.Minute, Clock: clk},
+		func(r *http.Request) string { return r.Header.Get("X-Client") })
+
+	return detector.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status, err := strconv.Atoi(r.Header.Get("X-Status")); err == nil {
+			w.WriteHeader(status)
+		}
+	}))
+}
+
+func lookup(handler http.Handler, client string, status int) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-Client", client)
+	req.Header.Set("X-Status", strconv.Itoa(status))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDetector(t *testing.T) {
+	type step struct {
+		advance time.Duration
+		client  string
+		status  int
+		// wantBlocked is whether the lookup is rejected before the handler.
+		wantBlocked bool
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "threshold tolerated",
+			steps: []step{
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusNotFound},
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusOK},
+			},
+		},
+		{
+			name: "blocked past the threshold",
+			steps: []step{
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusOK, wantBlocked: true},
+				{client: "b", status: http.StatusOK},
+			},
+		},
+		{
+			name: "valid lookups not counted",
+			steps: []step{
+				{client: "a", status: http.StatusOK},
+				{client: "a", status: http.StatusBadGateway},
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusOK},
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusOK},
+			},
+		},
+		{
+			name: "window expired",
+			steps: []step{
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{advance: time.Minute + time.Second, client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusOK},
+			},
+		},
+		{
+			name: "window still open at its end",
+			steps: []step{
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{advance: time.Minute, client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusOK, wantBlocked: true},
+			},
+		},
+		{
+			name: "block lasts its duration",
+			steps: []step{
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{advance: 5 * time.Minute, client: "a", status: http.StatusOK, wantBlocked: true},
+				{advance: time.Second, client: "a", status: http.StatusOK},
+				// The window expired with the block, so counting starts over.
+				{client: "a", status: http.StatusUnprocessableEntity},
+				{client: "a", status: http.StatusOK},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(time.Unix(1700000000, 0))
+			handler := newTestDetector(clk)
+
+			for i, step := range tt.steps {
+				clk.Advance(step.advance)
+				rec := lookup(handler, step.client, step.status)
+
+				blocked := rec.Code == http.StatusTooManyRequests
+				if blocked != step.wantBlocked {
+					t.Fatalf("step %d: status = %d, want blocked %v", i, rec.Code, step.wantBlocked)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectorRetryAfter(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	handler := newTestDetector(clk)
+
+	for i := 0; i < 4; i++ {
+		lookup(handler, "a", http.StatusUnprocessableEntity)
+	}
+	clk.Advance(2 * time.Minute)
+
+	rec := lookup(handler, "a", http.StatusOK)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "181" {
+		t.Errorf("Retry-After = %s, want the 3 minutes left of the block plus a second", got)
+	}
+}
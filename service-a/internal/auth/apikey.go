@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+	"github.com/luis-olivetti/go-observability/pkg/platform/security"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const APIKeyHeader = "X-Api-Key"
+
+type contextKey struct{}
+
+// APIKey is a credential accepted by the middleware; ID is the public
+// identifier used for metrics and tracing, Secret is what clients send.
+type APIKey struct {
+	ID     string
+	Secret string
+}
+
+// ParseAPIKeys reads keys in the "id:secret,id:secret" format.
+func ParseAPIKeys(raw string) ([]APIKey, error) {
+	var keys []APIKey
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, err := parseAPIKey(entry)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// LoadAPIKeysFile reads one "id:secret" pair per line, as mounted by the
+// secret store. Blank lines and lines starting with # are ignored.
+func LoadAPIKeysFile(path string) ([]APIKey, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open api keys file: %w", err)
+	}
+	defer file.Close()
+
+	var keys []APIKey
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, err := parseAPIKey(line)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read api keys file: %w", err)
+	}
+
+	return keys, nil
+}
+
+func parseAPIKey(entry string) (APIKey, error) {
+	id, secret, ok := strings.Cut(entry, ":")
+	if !ok || id == "" || secret == "" {
+		return APIKey{}, fmt.Errorf("invalid api key entry, expected id:secret")
+	}
+
+	return APIKey{ID: id, Secret: secret}, nil
+}
+
+// HashKeyID returns a short, stable digest of a key ID, safe to use as a
+// span or metric attribute.
+func HashKeyID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:8])
+}
+
+// KeyIDFromContext returns the ID of the API key that authenticated the request.
+func KeyIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok
+}
+
+type APIKeyAuthenticator struct {
+	keys     []APIKey
+	requests metric.Int64Counter
+}
+
+func NewAPIKeyAuthenticator(keys []APIKey) *APIKeyAuthenticator {
+	requests, err := otel.Meter("microservice-meter").Int64Counter(
+		"auth.api_key.requests",
+		metric.WithDescription("Requests authenticated by API key, by key and result"),
+	)
+	if err != nil {
+		log.Printf("failed to create api key counter: %v", err)
+	}
+
+	return &APIKeyAuthenticator{keys: keys, requests: requests}
+}
+
+// Middleware rejects requests without a valid X-Api-Key header.
+func (a *APIKeyAuthenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided := r.Header.Get(APIKeyHeader)
+		id, ok := a.authenticate(provided)
+		if !ok {
+			reason := "invalid_api_key"
+			if provided == "" {
+				reason = "missing_api_key"
+			}
+			security.Record(r, security.AuthFailure, reason)
+			a.record(r.Context(), "unknown", "rejected")
+			problem.Write(w, r, http.StatusUnauthorized, contracts.CodeUnauthorized, nil, nil)
+			return
+		}
+
+		a.record(r.Context(), HashKeyID(id), "accepted")
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, id)))
+	})
+}
+
+// authenticate compares against every configured key so the time taken does
+// not reveal which, if any, key matched.
+func (a *APIKeyAuthenticator) authenticate(provided string) (string, bool) {
+	if provided == "" {
+		return "", false
+	}
+
+	var matched string
+	found := 0
+	for _, key := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(key.Secret)) == 1 {
+			matched = key.ID
+			found = 1
+		}
+	}
+
+	return matched, found == 1
+}
+
+func (a *APIKeyAuthenticator) record(ctx context.Context, keyIDHash, result string) {
+	if a.requests == nil {
+		return
+	}
+
+	a.requests.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("auth.key_id_hash", keyIDHash),
+		attribute.String("auth.result", result),
+	))
+}
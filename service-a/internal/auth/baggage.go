@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/debugtrace"
+	"github.com/luis-olivetti/go-observability/pkg/platform/jwtauth"
+	"github.com/luis-olivetti/go-observability/pkg/platform/tenant"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+const enduserKey = "enduser.id"
+
+// ApplyBaggage replaces the identity members of the context baggage with the
+// authenticated caller, so service-b can attribute work to a tenant/client.
+// It must run after the incoming propagation headers are extracted: members
+// sent by the caller itself are always dropped, authenticated or not. The
+// synthetic marker is likewise only set for the in-process prober, and the
+// debug marker for callers DebugTracing let through; the mirror marker is
+// only ever added to the copies sent to staging.
+func ApplyBaggage(ctx context.Context) context.Context {
+	bag := baggage.FromContext(ctx)
+	for _, key := range []string{enduserKey, tenant.TenantKey, tenant.ClientKey, tenant.SyntheticKey, tenant.MirrorKey, debugtrace.Key} {
+		bag = bag.DeleteMember(key)
+	}
+
+	identity := map[string]string{}
+	if claims, ok := jwtauth.ClaimsFromContext(ctx); ok {
+		identity[enduserKey] = claims.Subject
+		identity[tenant.TenantKey] = claims.Tenant
+	}
+	if keyID, ok := KeyIDFromContext(ctx); ok {
+		identity[tenant.ClientKey] = HashKeyID(keyID)
+	}
+	if tenant.IsSynthetic(ctx) {
+		identity[tenant.SyntheticKey] = "true"
+	}
+	if DebugGranted(ctx) {
+		identity[debugtrace.Key] = "true"
+	}
+
+	for key, value := range identity {
+		if value == "" {
+			continue
+		}
+
+		member, err := baggage.NewMemberRaw(key, value)
+		if err != nil {
+			continue
+		}
+		if updated, err := bag.SetMember(member); err == nil {
+			bag = updated
+		}
+	}
+
+	return baggage.ContextWithBaggage(ctx, bag)
+}
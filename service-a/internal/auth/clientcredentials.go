@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+	"github.com/luis-olivetti/go-observability/pkg/platform/httpclient"
+)
+
+// refreshMargin renews tokens slightly before they expire so in-flight
+// requests never carry a token that expires on the way.
+const refreshMargin = 30 * time.Second
+
+// maxTokenResponse caps a token endpoint response.
+const maxTokenResponse = 64 << 10
+
+type ClientCredentialsConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	Audience     string
+	// Clock defaults to the wall clock when nil.
+	Clock clock.Clock
+}
+
+// ClientCredentialsSource fetches OAuth2 access tokens with the client
+// credentials grant and caches them until shortly before they expire.
+type ClientCredentialsSource struct {
+	cfg    ClientCredentialsConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func NewClientCredentialsSource(cfg ClientCredentialsConfig) *ClientCredentialsSource {
+	return &ClientCredentialsSource{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Token returns a cached access token, requesting a new one when needed.
+func (s *ClientCredentialsSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && clock.Or(s.cfg.Clock).Now().Add(refreshMargin).Before(s.expiresAt) {
+		return s.token, nil
+	}
+
+	token, expiresIn, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	s.token = token
+	s.expiresAt = clock.Or(s.cfg.Clock).Now().Add(expiresIn)
+
+	return s.token, nil
+}
+
+func (s *ClientCredentialsSource) fetch(ctx context.Context) (string, time.Duration, error) {
+	form := neturl.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(s.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.cfg.Scopes, " "))
+	}
+	if s.cfg.Audience != "" {
+		form.Set("audience", s.cfg.Audience)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create request (token): %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(neturl.QueryEscape(s.cfg.ClientID), neturl.QueryEscape(s.cfg.ClientSecret))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to make HTTP request (token): %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("unexpected status code (token): %d", res.StatusCode)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(httpclient.LimitBody(res.Body, maxTokenResponse)).Decode(&body); err != nil {
+		return "", 0, fmt.Errorf("failed to decode response (token): %w", err)
+	}
+
+	if body.AccessToken == "" {
+		return "", 0, fmt.Errorf("token response without access_token")
+	}
+
+	expiresIn := time.Duration(body.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = 5 * time.Minute
+	}
+
+	return body.AccessToken, expiresIn, nil
+}
+
+// Transport attaches a bearer token from the source to every request.
+type Transport struct {
+	Source *ClientCredentialsSource
+	Base   http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.Source.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/debugtrace"
+	"github.com/luis-olivetti/go-observability/pkg/platform/jwtauth"
+)
+
+type debugContextKey struct{}
+
+// DebugTracing grants the debug traces requested by allowlisted callers. An
+// entry matches an API key id, a JWT subject or a JWT tenant. Anyone else
+// asking for one is served as usual, without it.
+type DebugTracing struct {
+	allowed map[string]bool
+}
+
+// ParseDebugAllowlist reads the comma-separated allowlist entries.
+func ParseDebugAllowlist(raw string) []string {
+	var entries []string
+	for _, entry := range strings.Split(raw, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+func NewDebugTracing(allowlist []string) *DebugTracing {
+	allowed := make(map[string]bool, len(allowlist))
+	for _, entry := range allowlist {
+		allowed[entry] = true
+	}
+
+	return &DebugTracing{allowed: allowed}
+}
+
+// Middleware must run after the authenticators, since the allowlist is
+// matched against the identity they established.
+func (d *DebugTracing) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if debugtrace.Requested(r) && d.allows(r.Context()) {
+			r = r.WithContext(context.WithValue(r.Context(), debugContextKey{}, true))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (d *DebugTracing) allows(ctx context.Context) bool {
+	if keyID, ok := KeyIDFromContext(ctx); ok && d.allowed[keyID] {
+		return true
+	}
+	if claims, ok := jwtauth.ClaimsFromContext(ctx); ok {
+		return d.allowed[claims.Subject] || (claims.Tenant != "" && d.allowed[claims.Tenant])
+	}
+	return false
+}
+
+// DebugGranted reports whether the caller was granted a debug trace.
+func DebugGranted(ctx context.Context) bool {
+	granted, _ := ctx.Value(debugContextKey{}).(bool)
+	return granted
+}
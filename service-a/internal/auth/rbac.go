@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/jwtauth"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+	"github.com/luis-olivetti/go-observability/pkg/platform/security"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Role is ordered: each role includes the permissions of the ones below it.
+type Role int
+
+const (
+	RoleNone Role = iota
+	RoleViewer
+	RoleOperator
+	RoleAdmin
+)
+
+func ParseRole(name string) (Role, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "viewer":
+		return RoleViewer, nil
+	case "operator":
+		return RoleOperator, nil
+	case "admin":
+		return RoleAdmin, nil
+	default:
+		return RoleNone, fmt.Errorf("unknown role: %s", name)
+	}
+}
+
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleOperator:
+		return "operator"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+// ParseKeyRoles reads the static API key to role mapping, "id=role,...".
+func ParseKeyRoles(raw string) (map[string]Role, error) {
+	roles := map[string]Role{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, name, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid key role %q, expected id=role", entry)
+		}
+
+		role, err := ParseRole(name)
+		if err != nil {
+			return nil, err
+		}
+		roles[strings.TrimSpace(id)] = role
+	}
+
+	return roles, nil
+}
+
+// RoleResolver determines the highest role of the authenticated caller, from
+// JWT role claims or from the static API key mapping.
+type RoleResolver struct {
+	keyRoles map[string]Role
+}
+
+func NewRoleResolver(keyRoles map[string]Role) *RoleResolver {
+	return &RoleResolver{keyRoles: keyRoles}
+}
+
+func (rr *RoleResolver) Resolve(ctx context.Context) Role {
+	role := RoleNone
+
+	if claims, ok := jwtauth.ClaimsFromContext(ctx); ok {
+		for _, name := range claims.Roles {
+			if claimRole, err := ParseRole(name); err == nil && claimRole > role {
+				role = claimRole
+			}
+		}
+	}
+
+	if id, ok := KeyIDFromContext(ctx); ok {
+		if keyRole := rr.keyRoles[id]; keyRole > role {
+			role = keyRole
+		}
+	}
+
+	return role
+}
+
+// Require only lets callers holding at least the given role through.
+func (rr *RoleResolver) Require(required Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if role := rr.Resolve(r.Context()); role < required {
+				security.Record(r, security.AuthFailure, "insufficient_role",
+					attribute.String("auth.role", role.String()),
+					attribute.String("auth.required_role", required.String()))
+				problem.Write(w, r, http.StatusForbidden, contracts.CodeForbidden, nil, nil)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Actor names the authenticated caller in audit entries: its JWT subject, or
+// the hash of its API key id.
+func Actor(ctx context.Context) string {
+	if claims, ok := jwtauth.ClaimsFromContext(ctx); ok && claims.Subject != "" {
+		return "jwt:" + claims.Subject
+	}
+	if id, ok := KeyIDFromContext(ctx); ok {
+		return "key:" + HashKeyID(id)
+	}
+	return "unknown"
+}
@@ -0,0 +1,26 @@
+package clients
+
+import (
+	"net/http"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/callbudget"
+	"github.com/luis-olivetti/go-observability/pkg/platform/chaos"
+	"github.com/luis-olivetti/go-observability/pkg/platform/debugtrace"
+	"github.com/luis-olivetti/go-observability/pkg/platform/httpclient"
+	"github.com/luis-olivetti/go-observability/service-a/internal/config"
+)
+
+// NewHTTPClient builds the long-lived client of the upstream called name,
+// injecting chaos faults when injector is not nil. Calls made within a debug
+// trace record their connection events and headers, and every call counts
+// against the outbound call budget of its request.
+func NewHTTPClient(name string, upstream config.Upstream, injector *chaos.Injector) (*http.Client, error) {
+	client, err := httpclient.New(upstream.HTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	client.Transport = callbudget.Transport(httpclient.Measure(name, debugtrace.Transport(injector.Transport(client.Transport))))
+
+	return client, nil
+}
@@ -0,0 +1,123 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/apierror"
+	"github.com/luis-olivetti/go-observability/pkg/platform/errclass"
+	"github.com/luis-olivetti/go-observability/pkg/platform/httpclient"
+	"github.com/luis-olivetti/go-observability/pkg/platform/jsoncodec"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxServiceBResponse caps a service B body, success or problem document.
+const maxServiceBResponse = 64 << 10
+
+// ServiceBClient calls the city-weather and alerts endpoints of service B.
+type ServiceBClient struct {
+	client  *http.Client
+	baseURL string
+	tracer  trace.Tracer
+}
+
+func NewServiceBClient(client *http.Client, baseURL string, tracer trace.Tracer) *ServiceBClient {
+	return &ServiceBClient{client: client, baseURL: baseURL, tracer: tracer}
+}
+
+func (c *ServiceBClient) CityWeather(ctx context.Context, zipCode string, include []string, precision string) (*contracts.TemperatureWithCity, error) {
+	ctx, span := c.tracer.Start(ctx, "SearchCityByZipCode")
+	defer span.End()
+
+	query := neturl.Values{}
+	query.Set("zipcode", zipCode)
+	if len(include) > 0 {
+		query["include"] = include
+	}
+	if precision != "" {
+		query.Set("precision", precision)
+	}
+
+	resp, err := c.get(ctx, c.baseURL+"/city-weather?"+query.Encode())
+	if err != nil {
+		return nil, apierror.UpstreamFailure(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, upstreamError(resp)
+	}
+
+	var cityWeatherResponse contracts.TemperatureWithCity
+	if err := jsoncodec.Default.NewDecoder(httpclient.LimitBody(resp.Body, maxServiceBResponse)).Decode(&cityWeatherResponse); err != nil {
+		return nil, apierror.UpstreamFailure(fmt.Errorf("failed to decode response (service B): %w", err))
+	}
+
+	return &cityWeatherResponse, nil
+}
+
+func (c *ServiceBClient) Alerts(ctx context.Context, zipCode string) (*contracts.CityAlerts, error) {
+	ctx, span := c.tracer.Start(ctx, "SearchAlertsByZipCode")
+	defer span.End()
+
+	query := neturl.Values{}
+	query.Set("zipcode", zipCode)
+
+	resp, err := c.get(ctx, c.baseURL+"/alerts?"+query.Encode())
+	if err != nil {
+		return nil, apierror.UpstreamFailure(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, upstreamError(resp)
+	}
+
+	var alerts contracts.CityAlerts
+	if err := jsoncodec.Default.NewDecoder(httpclient.LimitBody(resp.Body, maxServiceBResponse)).Decode(&alerts); err != nil {
+		return nil, apierror.UpstreamFailure(fmt.Errorf("failed to decode alerts (service B): %w", err))
+	}
+
+	return &alerts, nil
+}
+
+// upstreamError relays the code of a service B error response, with the
+// status its class maps to in this service; the client reads this service's
+// catalog message for it, and B's detail is kept as the internal message. B's
+// validation and not-found answers keep their meaning, and any other failure
+// of B is a gateway error here. Anything that is not a problem document is
+// reported generically.
+func upstreamError(resp *http.Response) error {
+	cause := fmt.Errorf("service B returned non-OK status: %d", resp.StatusCode)
+	class := errclass.FromStatus(resp.StatusCode)
+
+	var details problem.Details
+	if err := jsoncodec.Default.NewDecoder(httpclient.LimitBody(resp.Body, maxServiceBResponse)).Decode(&details); err != nil || details.Code == "" {
+		return apierror.FromClass(class, contracts.CodeUpstreamError, "failed to fetch weather data", cause)
+	}
+
+	return apierror.FromClass(class, details.Code, details.Detail, cause)
+}
+
+func (c *ServiceBClient) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	propagator := otel.GetTextMapPropagator()
+	propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,77 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/luis-olivetti/go-observability/service-a/internal/config"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// serviceBServer answers /city-weather and counts the connections opened to
+// it.
+func serviceBServer(b *testing.B) (*httptest.Server, *atomic.Int64) {
+	var conns atomic.Int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.65, "city": "Linhares"}`)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	b.Cleanup(server.Close)
+	return server, &conns
+}
+
+// BenchmarkServiceBClient compares the long-lived client serve injects with
+// building one per request, which opens a new connection for every call.
+func BenchmarkServiceBClient(b *testing.B) {
+	tracer := noop.NewTracerProvider().Tracer("")
+	ctx := context.Background()
+
+	b.Run("long-lived", func(b *testing.B) {
+		server, conns := serviceBServer(b)
+		client, err := NewHTTPClient("service-b", config.Upstream{BaseURL: server.URL}, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		serviceB := NewServiceBClient(client, server.URL, tracer)
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := serviceB.CityWeather(ctx, "29902555", nil, ""); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+	})
+
+	b.Run("per-request", func(b *testing.B) {
+		server, conns := serviceBServer(b)
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			client, err := NewHTTPClient("service-b", config.Upstream{BaseURL: server.URL}, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := NewServiceBClient(client, server.URL, tracer).CityWeather(ctx, "29902555", nil, ""); err != nil {
+				b.Fatal(err)
+			}
+			// A discarded client's idle connection would otherwise stay open
+			// until the server times it out.
+			client.CloseIdleConnections()
+		}
+		b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+	})
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	t.Setenv("OTEL_SERVICE_NAME", "go-service-a")
+	t.Setenv("EXTERNAL_CALL_URL", "http://go-service-b:8181")
+	t.Setenv("HTTP_PORT", "http")
+	t.Setenv("HMAC_SECRET_FILE", missing)
+	t.Setenv("API_KEYS", "partner:s3cr3t")
+	t.Setenv("API_KEYS_FILE", missing)
+	t.Setenv("QUOTA_OVERRIDES", "partner=many")
+	t.Setenv("API_KEY_ROLES", "partner")
+	t.Setenv("CEP_HASH_KEY_FILE", missing)
+	t.Setenv("AUDIT_HMAC_KEY_FILE", missing)
+
+	_, err := Load()
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Load() error = %v, want a *ValidationError", err)
+	}
+	for _, key := range []string{
+		"HTTP_PORT",
+		"HMAC_SECRET_FILE",
+		"API_KEYS_FILE",
+		"QUOTA_OVERRIDES",
+		"API_KEY_ROLES",
+		"CEP_HASH_KEY_FILE",
+		"AUDIT_HMAC_KEY_FILE",
+	} {
+		if !hasProblem(validationErr, key) {
+			t.Errorf("no problem reported for %s in:\n%v", key, err)
+		}
+	}
+}
+
+func hasProblem(err *ValidationError, key string) bool {
+	for _, problem := range err.Problems {
+		if strings.HasPrefix(problem, key+" ") {
+			return true
+		}
+	}
+	return false
+}
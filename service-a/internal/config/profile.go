@@ -0,0 +1,125 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/redact"
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"github.com/spf13/viper"
+)
+
+// profiles are the preset bundles APP_PROFILE selects. They only replace the
+// built-in defaults: a setting given in the config file, the environment or
+// a -set flag still wins, so any single one can be overridden.
+var profiles = map[string]map[string]any{
+	// dev runs next to service B on localhost with no collector: spans go to
+	// stdout, debug lines are logged and nothing is rejected as unfit for
+	// production.
+	"dev": {
+		"OTEL_SERVICE_NAME":    "go-service-a",
+		"OTEL_TRACES_EXPORTER": telemetry.ExporterStdout,
+		"HTTP_PORT":            "8080",
+		"EXTERNAL_CALL_URL":    "http://localhost:8181",
+		"LOG_LEVEL":            "debug",
+		"STRICT_CONFIG":        false,
+		"CEP_PRIVACY_SPANS":    string(redact.CEPFull),
+		"CEP_PRIVACY_LOGS":     string(redact.CEPFull),
+		// A well-known key: a dev audit trail proves nothing.
+		"AUDIT_HMAC_KEY": "dev-audit-key",
+	},
+	// staging exports to the collector but, unlike prod, leaves chaos mode
+	// and local upstreams available for experiments.
+	"staging": {
+		"OTEL_TRACES_EXPORTER": telemetry.ExporterOTLP,
+		"LOG_LEVEL":            "info",
+		"STRICT_CONFIG":        false,
+	},
+	"prod": {
+		"OTEL_TRACES_EXPORTER": telemetry.ExporterOTLP,
+		"LOG_LEVEL":            "info",
+		"STRICT_CONFIG":        true,
+		"CEP_PRIVACY_SPANS":    string(redact.CEPMasked),
+		"CEP_PRIVACY_LOGS":     string(redact.CEPMasked),
+	},
+}
+
+// applyProfile lays the preset of APP_PROFILE over the built-in defaults.
+func applyProfile(p *problems) {
+	name := viper.GetString("APP_PROFILE")
+	if name == "" {
+		return
+	}
+
+	preset, ok := profiles[name]
+	if !ok {
+		known := make([]string, 0, len(profiles))
+		for profile := range profiles {
+			known = append(known, profile)
+		}
+		sort.Strings(known)
+		p.addf("APP_PROFILE", "must be one of %s, got %q", strings.Join(known, ", "), name)
+		return
+	}
+
+	for key, value := range preset {
+		viper.SetDefault(key, value)
+	}
+}
+
+// validateStrict rejects, under STRICT_CONFIG, the settings that only make
+// sense away from production.
+func (c *Config) validateStrict(p *problems) {
+	if c.TracesExporter == telemetry.ExporterStdout {
+		p.addf("OTEL_TRACES_EXPORTER", "must be %s with STRICT_CONFIG: spans on stdout never reach the collector", telemetry.ExporterOTLP)
+	}
+	if c.Chaos != nil {
+		p.addf("CHAOS_ENABLED", "must be off with STRICT_CONFIG")
+	}
+	requirePrivateCEPs(p, c.CEPs, "CEP_PRIVACY_SPANS", c.CEPs.Spans)
+	requirePrivateCEPs(p, c.CEPs, "CEP_PRIVACY_LOGS", c.CEPs.Logs)
+	if isLoopback(c.ServiceB.BaseURL) {
+		p.addf("EXTERNAL_CALL_URL", "must not point at this host with STRICT_CONFIG: %q", c.ServiceB.BaseURL)
+	}
+	if c.Mirror != nil && isLoopback(c.Mirror.Staging.BaseURL) {
+		p.addf("MIRROR_URL", "must not point at this host with STRICT_CONFIG: %q", c.Mirror.Staging.BaseURL)
+	}
+}
+
+// requirePrivateCEPs rejects CEPs shown whole, or hashed without a key, in
+// the signal of key.
+func requirePrivateCEPs(p *problems, policy redact.CEPPolicy, key string, mode redact.CEPMode) {
+	switch {
+	case mode == redact.CEPFull:
+		p.addf(key, "must be %s or %s with STRICT_CONFIG", redact.CEPMasked, redact.CEPHashed)
+	case mode == redact.CEPHashed && len(policy.HashKey) == 0:
+		p.addf(key, "needs CEP_HASH_KEY to be %s with STRICT_CONFIG: unkeyed hashes of CEPs are easily reversed", redact.CEPHashed)
+	}
+}
+
+// cepMode reads the CEP mode of a signal.
+func cepMode(p *problems, key string) redact.CEPMode {
+	mode, err := redact.ParseCEPMode(viper.GetString(key))
+	if err != nil {
+		p.addf(key, "%v", err)
+	}
+	return mode
+}
+
+// isLoopback reports whether rawURL points at this host, as stub upstreams
+// run locally do.
+func isLoopback(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/apierror"
+	"github.com/luis-olivetti/go-observability/pkg/platform/callbudget"
+	"github.com/luis-olivetti/go-observability/pkg/platform/cep"
+	"github.com/luis-olivetti/go-observability/pkg/platform/debugtrace"
+	"github.com/luis-olivetti/go-observability/pkg/platform/featureflag"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+	"github.com/luis-olivetti/go-observability/pkg/platform/servertiming"
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"github.com/luis-olivetti/go-observability/pkg/platform/tenant"
+	"github.com/luis-olivetti/go-observability/service-a/internal/auth"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AlertsService returns the severe-weather alerts in effect for the city a
+// zipcode belongs to.
+type AlertsService interface {
+	Alerts(ctx context.Context, zipCode string) (*contracts.CityAlerts, error)
+}
+
+// AlertsRoute is the path AlertsHandler is served on.
+const AlertsRoute = "/alerts"
+
+// AlertsHandler serves GET /alerts?zipcode=, relaying the alerts service B
+// finds for the zipcode's city.
+type AlertsHandler struct {
+	alerts   AlertsService
+	features *featureflag.Client
+	tracer   trace.Tracer
+	duration *telemetry.RequestDuration
+}
+
+func NewAlertsHandler(alerts AlertsService, features *featureflag.Client, tracer trace.Tracer) *AlertsHandler {
+	return &AlertsHandler{alerts: alerts, features: features, tracer: tracer, duration: telemetry.NewRequestDuration()}
+}
+
+func (h *AlertsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	carrier := propagation.HeaderCarrier(r.Header)
+	ctx := r.Context()
+	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+	ctx = auth.ApplyBaggage(ctx)
+
+	name, opts := telemetry.ServerSpan(r, AlertsRoute)
+	ctx, span := h.tracer.Start(ctx, name, opts...)
+	defer span.End()
+	debugtrace.Annotate(ctx, span, r)
+
+	w, measured := h.duration.Measure(ctx, w, r, AlertsRoute)
+	defer measured()
+
+	timings := servertiming.New()
+	if h.features.Boolean(ctx, ServerTimingFlag, true, tenant.ID(ctx)) {
+		w = timings.Wrap(w)
+	}
+	defer func() { span.SetAttributes(timings.Attributes()...) }()
+	defer func() { span.SetAttributes(callbudget.Attributes(ctx)...) }()
+
+	if keyID, ok := auth.KeyIDFromContext(ctx); ok {
+		span.SetAttributes(attribute.String("auth.key_id_hash", auth.HashKeyID(keyID)))
+	}
+
+	validated := timings.Start("validation")
+	raw := r.URL.Query().Get("zipcode")
+	zipCode, ok := cep.Normalize(raw)
+	validated()
+	if raw == "" {
+		rejectInput(w, r, span, http.StatusBadRequest, contracts.CodeBadRequest, []problem.FieldError{
+			{Field: "zipcode", Message: "is required"},
+		}, errors.New("missing zipcode parameter"))
+		return
+	}
+	if !ok {
+		rejectInput(w, r, span, http.StatusUnprocessableEntity, contracts.CodeZipcodeInvalid, []problem.FieldError{
+			{Field: "zipcode", Message: "must contain exactly 8 digits"},
+		}, errors.New("invalid zipcode"))
+		return
+	}
+
+	fetched := timings.Start("upstream")
+	alerts, err := h.alerts.Alerts(ctx, zipCode)
+	fetched()
+	if err != nil {
+		apierror.Write(w, r, span, err)
+		return
+	}
+	span.SetAttributes(attribute.Int("alerts.count", len(alerts.Alerts)))
+
+	writeJSON(w, r, span, timings, alerts)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/apierror"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// alertsFunc adapts a function to AlertsService.
+type alertsFunc func(ctx context.Context, zipCode string) (*contracts.CityAlerts, error)
+
+func (f alertsFunc) Alerts(ctx context.Context, zipCode string) (*contracts.CityAlerts, error) {
+	return f(ctx, zipCode)
+}
+
+func TestAlertsHandler(t *testing.T) {
+	found := contracts.CityAlerts{CityName: "Linhares", Alerts: []contracts.Alert{{Event: "Chuvas intensas", Severity: "Moderate"}}}
+
+	tests := []struct {
+		name       string
+		query      string
+		alertsErr  error
+		wantStatus int
+		wantCode   contracts.ErrorCode
+		wantCall   string
+	}{
+		{name: "valid", query: "?zipcode=29902555", wantStatus: http.StatusOK, wantCall: "29902555"},
+		{name: "hyphenated", query: "?zipcode=29902-555", wantStatus: http.StatusOK, wantCall: "29902555"},
+		{name: "missing", query: "", wantStatus: http.StatusBadRequest, wantCode: contracts.CodeBadRequest},
+		{name: "invalid", query: "?zipcode=abc", wantStatus: http.StatusUnprocessableEntity, wantCode: contracts.CodeZipcodeInvalid},
+		{
+			name:       "not found upstream",
+			query:      "?zipcode=29902555",
+			alertsErr:  apierror.ZipcodeNotFound(nil),
+			wantStatus: http.StatusNotFound,
+			wantCode:   contracts.CodeZipcodeNotFound,
+			wantCall:   "29902555",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called string
+			alerts := alertsFunc(func(_ context.Context, zipCode string) (*contracts.CityAlerts, error) {
+				called = zipCode
+				if tt.alertsErr != nil {
+					return nil, tt.alertsErr
+				}
+				response := found
+				return &response, nil
+			})
+			handler := NewAlertsHandler(alerts, nil, noop.NewTracerProvider().Tracer(""))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, AlertsRoute+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if called != tt.wantCall {
+				t.Errorf("upstream called with %q, want %q", called, tt.wantCall)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				if p := decodeProblem(t, rec); p.Code != tt.wantCode {
+					t.Errorf("problem code = %s, want %s", p.Code, tt.wantCode)
+				}
+				return
+			}
+
+			var got contracts.CityAlerts
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(got, found) {
+				t.Errorf("response = %+v, want %+v", got, found)
+			}
+		})
+	}
+}
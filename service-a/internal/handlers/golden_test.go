@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/apierror"
+	"github.com/luis-olivetti/go-observability/pkg/platform/errclass"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// assertGolden compares the body of rec with testdata/<name>.golden.json,
+// rewriting the file instead when -update is set.
+func assertGolden(t *testing.T, name string, rec *httptest.ResponseRecorder) {
+	t.Helper()
+
+	var got bytes.Buffer
+	if err := json.Indent(&got, bytes.TrimSpace(rec.Body.Bytes()), "", "  "); err != nil {
+		t.Fatalf("response is not JSON: %v; body %s", err, rec.Body)
+	}
+	got.WriteByte('\n')
+
+	path := filepath.Join("testdata", name+".golden.json")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("response differs from %s (run with -update if the change is intended)\ngot:\n%s\nwant:\n%s", path, got.Bytes(), want)
+	}
+}
+
+func TestZipcodeGolden(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		weather    *contracts.TemperatureWithCity
+		weatherErr error
+	}{
+		{name: "zipcode_ok", body: `{"cep": "29902555"}`, weather: &linhares},
+		{
+			name: "zipcode_ok_conditions",
+			body: `{"cep": "29902555"}`,
+			weather: &contracts.TemperatureWithCity{
+				Celsius: 28.5, Fahrenheit: 83.3, Kelvin: 301.65, CityName: "Linhares",
+				Conditions: &contracts.Conditions{Text: "Ensolarado", Code: 1000, Icon: "//cdn.weatherapi.com/weather/64x64/day/113.png", Humidity: 74, WindKph: 11.2, WindDir: "SSE", FeelsLike: 31.2},
+			},
+		},
+		{name: "zipcode_bad_request", body: `{"cep": `},
+		{name: "zipcode_validation_failed", body: `{}`},
+		{name: "zipcode_invalid", body: `{"cep": "2990255"}`},
+		{name: "zipcode_not_found", body: `{"cep": "29902555"}`, weatherErr: apierror.ZipcodeNotFound(nil)},
+		{name: "zipcode_upstream_error", body: `{"cep": "29902555"}`, weatherErr: apierror.UpstreamFailure(errors.New("boom"))},
+		{name: "zipcode_upstream_timeout", body: `{"cep": "29902555"}`, weatherErr: apierror.Upstream(errclass.UpstreamTimeout, context.DeadlineExceeded)},
+		{name: "zipcode_provider_quota", body: `{"cep": "29902555"}`, weatherErr: apierror.ProviderQuota(errors.New("quota"))},
+		{name: "zipcode_internal", body: `{"cep": "29902555"}`, weatherErr: errors.New("boom")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weather := weatherFunc(func(context.Context, string, []string, string) (*contracts.TemperatureWithCity, error) {
+				return tt.weather, tt.weatherErr
+			})
+			handler := NewZipcodeHandler(weather, nil, noop.NewTracerProvider().Tracer(""), "")
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ZipcodeRoute, strings.NewReader(tt.body)))
+
+			assertGolden(t, tt.name, rec)
+		})
+	}
+}
+
+func TestAlertsGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		alerts    *contracts.CityAlerts
+		alertsErr error
+	}{
+		{
+			name:  "alerts_ok",
+			query: "?zipcode=29902555",
+			alerts: &contracts.CityAlerts{CityName: "Linhares", Alerts: []contracts.Alert{{
+				Headline: "Alerta de chuvas", Event: "Chuvas intensas", Severity: "Moderate",
+				Description: "Acumulado de 50 mm", Instruction: "Evite áreas alagadas",
+			}}},
+		},
+		{name: "alerts_ok_none", query: "?zipcode=29902555", alerts: &contracts.CityAlerts{CityName: "Linhares", Alerts: []contracts.Alert{}}},
+		{name: "alerts_bad_request", query: ""},
+		{name: "alerts_invalid", query: "?zipcode=abc"},
+		{name: "alerts_not_found", query: "?zipcode=29902555", alertsErr: apierror.ZipcodeNotFound(nil)},
+		{name: "alerts_provider_quota", query: "?zipcode=29902555", alertsErr: apierror.ProviderQuota(errors.New("quota"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts := alertsFunc(func(context.Context, string) (*contracts.CityAlerts, error) {
+				return tt.alerts, tt.alertsErr
+			})
+			handler := NewAlertsHandler(alerts, nil, noop.NewTracerProvider().Tracer(""))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, AlertsRoute+tt.query, nil))
+
+			assertGolden(t, tt.name, rec)
+		})
+	}
+}
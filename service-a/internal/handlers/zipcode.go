@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/apierror"
+	"github.com/luis-olivetti/go-observability/pkg/platform/bufpool"
+	"github.com/luis-olivetti/go-observability/pkg/platform/callbudget"
+	"github.com/luis-olivetti/go-observability/pkg/platform/cep"
+	"github.com/luis-olivetti/go-observability/pkg/platform/debugtrace"
+	"github.com/luis-olivetti/go-observability/pkg/platform/featureflag"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+	"github.com/luis-olivetti/go-observability/pkg/platform/servertiming"
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"github.com/luis-olivetti/go-observability/pkg/platform/tenant"
+	"github.com/luis-olivetti/go-observability/service-a/internal/auth"
+	"github.com/luis-olivetti/go-observability/service-a/internal/validation"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Message struct {
+	ZipCode string `json:"cep" validate:"required"`
+}
+
+// WeatherService returns the temperature of the city a zipcode belongs to.
+// An empty precision keeps the upstream's default rounding.
+type WeatherService interface {
+	CityWeather(ctx context.Context, zipCode string, include []string, precision string) (*contracts.TemperatureWithCity, error)
+}
+
+// ZipcodeRoute is the path ZipcodeHandler is served on.
+const ZipcodeRoute = "/city-by-zipcode"
+
+// ServerTimingFlag gates the Server-Timing response header; it is on unless
+// a rule turns it off.
+const ServerTimingFlag = "server_timing"
+
+// ZipcodeHandler serves POST /city-by-zipcode.
+type ZipcodeHandler struct {
+	weather  WeatherService
+	features *featureflag.Client
+	tracer   trace.Tracer
+	duration *telemetry.RequestDuration
+	// traceURL links debug traces from the response; see
+	// debugtrace.TraceURL.
+	traceURL string
+}
+
+func NewZipcodeHandler(weather WeatherService, features *featureflag.Client, tracer trace.Tracer, traceURL string) *ZipcodeHandler {
+	return &ZipcodeHandler{weather: weather, features: features, tracer: tracer, duration: telemetry.NewRequestDuration(), traceURL: traceURL}
+}
+
+func (h *ZipcodeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	carrier := propagation.HeaderCarrier(r.Header)
+	ctx := r.Context()
+	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+	ctx = auth.ApplyBaggage(ctx)
+
+	name, opts := telemetry.ServerSpan(r, ZipcodeRoute)
+	ctx, span := h.tracer.Start(ctx, name, opts...)
+	defer span.End()
+	debugtrace.Annotate(ctx, span, r)
+
+	w, measured := h.duration.Measure(ctx, w, r, ZipcodeRoute)
+	defer measured()
+
+	timings := servertiming.New()
+	if h.features.Boolean(ctx, ServerTimingFlag, true, tenant.ID(ctx)) {
+		w = timings.Wrap(w)
+	}
+	defer func() { span.SetAttributes(timings.Attributes()...) }()
+	defer func() { span.SetAttributes(callbudget.Attributes(ctx)...) }()
+
+	var debug *contracts.Debug
+	if url := debugtrace.TraceURL(ctx, h.traceURL); url != "" {
+		debug = &contracts.Debug{TraceURL: url}
+		w = problem.WithDebug(w, debug)
+	}
+
+	if keyID, ok := auth.KeyIDFromContext(ctx); ok {
+		span.SetAttributes(attribute.String("auth.key_id_hash", auth.HashKeyID(keyID)))
+	}
+
+	validated := timings.Start("validation")
+	var msg Message
+	if err := validation.Decode(r, &msg, validation.DefaultMaxDepth); err != nil {
+		validated()
+		var decodeErr *validation.DecodeError
+		if errors.As(err, &decodeErr) {
+			rejectInput(w, r, span, decodeErr.Status, decodeErr.Code(), decodeErr.Fields, err)
+		} else {
+			rejectInput(w, r, span, http.StatusBadRequest, contracts.CodeBadRequest, nil, err)
+		}
+		return
+	}
+
+	zipCode, ok := cep.Normalize(msg.ZipCode)
+	validated()
+	if !ok {
+		rejectInput(w, r, span, http.StatusUnprocessableEntity, contracts.CodeZipcodeInvalid, []problem.FieldError{
+			{Field: "cep", Message: "must contain exactly 8 digits"},
+		}, errors.New("invalid zipcode"))
+		return
+	}
+
+	fetched := timings.Start("upstream")
+	cityWeatherResponse, err := h.weather.CityWeather(ctx, zipCode, r.URL.Query()["include"], r.URL.Query().Get("precision"))
+	fetched()
+	if err != nil {
+		apierror.Write(w, r, span, err)
+		return
+	}
+
+	cityWeatherResponse.Debug = debug
+	writeJSON(w, r, span, timings, cityWeatherResponse)
+}
+
+// rejectInput answers a request that failed validation. Unlike
+// apierror.Write it leaves the span status unset: the caller is at fault, not
+// the service. What exactly was wrong is only recorded from err on the span.
+func rejectInput(w http.ResponseWriter, r *http.Request, span trace.Span, status int, code contracts.ErrorCode, fields []problem.FieldError, err error) {
+	span.RecordError(err)
+	span.SetAttributes(semconv.ErrorTypeKey.String(string(code)))
+	problem.Write(w, r, status, code, nil, fields)
+}
+
+// writeJSON times the encoding separately from the write, so it shows up as
+// its own Server-Timing stage.
+func writeJSON(w http.ResponseWriter, r *http.Request, span trace.Span, timings *servertiming.Timings, v any) {
+	body := bufpool.Get()
+	defer bufpool.Put(body)
+
+	encoded := timings.Start("encode")
+	err := body.Encode(v)
+	encoded()
+	if err != nil {
+		apierror.Write(w, r, span, apierror.Internal(fmt.Errorf("failed to encode response: %w", err)))
+		return
+	}
+
+	if err := bufpool.Write(w, http.StatusOK, "application/json", body); err != nil {
+		span.RecordError(err)
+	}
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/apierror"
+	"github.com/luis-olivetti/go-observability/pkg/platform/errclass"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// weatherFunc adapts a function to WeatherService.
+type weatherFunc func(ctx context.Context, zipCode string, include []string, precision string) (*contracts.TemperatureWithCity, error)
+
+func (f weatherFunc) CityWeather(ctx context.Context, zipCode string, include []string, precision string) (*contracts.TemperatureWithCity, error) {
+	return f(ctx, zipCode, include, precision)
+}
+
+var linhares = contracts.TemperatureWithCity{Celsius: 28.5, Fahrenheit: 83.3, Kelvin: 301.65, CityName: "Linhares"}
+
+// decodeProblem fails the test unless rec holds a problem document.
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) contracts.Problem {
+	t.Helper()
+
+	if ct := rec.Header().Get("Content-Type"); ct != contracts.ProblemContentType {
+		t.Fatalf("Content-Type = %q, want %q", ct, contracts.ProblemContentType)
+	}
+	var p contracts.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatalf("failed to decode problem: %v", err)
+	}
+	return p
+}
+
+func TestZipcodeHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		query      string
+		weather    *contracts.TemperatureWithCity
+		weatherErr error
+		wantStatus int
+		wantCode   contracts.ErrorCode
+		wantFields []contracts.FieldError
+		// wantCall is the zipcode the upstream must be called with; empty
+		// when it must not be called.
+		wantCall      string
+		wantInclude   []string
+		wantPrecision string
+	}{
+		{
+			name:       "valid",
+			body:       `{"cep": "29902555"}`,
+			weather:    &linhares,
+			wantStatus: http.StatusOK,
+			wantCall:   "29902555",
+		},
+		{
+			name:       "hyphenated",
+			body:       `{"cep": "29902-555"}`,
+			weather:    &linhares,
+			wantStatus: http.StatusOK,
+			wantCall:   "29902555",
+		},
+		{
+			name:          "query forwarded",
+			body:          `{"cep": "29902555"}`,
+			query:         "?include=conditions&precision=1",
+			weather:       &linhares,
+			wantStatus:    http.StatusOK,
+			wantCall:      "29902555",
+			wantInclude:   []string{"conditions"},
+			wantPrecision: "1",
+		},
+		{
+			name:       "invalid zipcode",
+			body:       `{"cep": "2990255"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   contracts.CodeZipcodeInvalid,
+			wantFields: []contracts.FieldError{{Field: "cep", Message: "must contain exactly 8 digits"}},
+		},
+		{
+			name:       "missing zipcode",
+			body:       `{}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   contracts.CodeValidationFailed,
+		},
+		{
+			name:       "malformed json",
+			body:       `{"cep": `,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   contracts.CodeBadRequest,
+		},
+		{
+			name:       "unknown field",
+			body:       `{"cep": "29902555", "zip": "29902555"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   contracts.CodeBadRequest,
+		},
+		{
+			name:       "not found upstream",
+			body:       `{"cep": "29902555"}`,
+			weatherErr: apierror.ZipcodeNotFound(nil),
+			wantStatus: http.StatusNotFound,
+			wantCode:   contracts.CodeZipcodeNotFound,
+			wantCall:   "29902555",
+		},
+		{
+			name:       "upstream timeout",
+			body:       `{"cep": "29902555"}`,
+			weatherErr: apierror.Upstream(errclass.UpstreamTimeout, context.DeadlineExceeded),
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   contracts.CodeUpstreamTimeout,
+			wantCall:   "29902555",
+		},
+		{
+			name:       "unexpected error",
+			body:       `{"cep": "29902555"}`,
+			weatherErr: errors.New("boom"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   contracts.CodeInternal,
+			wantCall:   "29902555",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				called    string
+				include   []string
+				precision string
+			)
+			weather := weatherFunc(func(_ context.Context, zipCode string, inc []string, prec string) (*contracts.TemperatureWithCity, error) {
+				called, include, precision = zipCode, inc, prec
+				if tt.weatherErr != nil {
+					return nil, tt.weatherErr
+				}
+				response := *tt.weather
+				return &response, nil
+			})
+			handler := NewZipcodeHandler(weather, nil, noop.NewTracerProvider().Tracer(""), "")
+
+			req := httptest.NewRequest(http.MethodPost, ZipcodeRoute+tt.query, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if called != tt.wantCall {
+				t.Errorf("upstream called with %q, want %q", called, tt.wantCall)
+			}
+			if !reflect.DeepEqual(include, tt.wantInclude) || precision != tt.wantPrecision {
+				t.Errorf("upstream got include %v precision %q, want %v %q", include, precision, tt.wantInclude, tt.wantPrecision)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				p := decodeProblem(t, rec)
+				if p.Code != tt.wantCode || p.Status != tt.wantStatus {
+					t.Errorf("problem = %+v, want code %s status %d", p, tt.wantCode, tt.wantStatus)
+				}
+				if tt.wantFields != nil && !reflect.DeepEqual(p.Errors, tt.wantFields) {
+					t.Errorf("problem errors = %v, want %v", p.Errors, tt.wantFields)
+				}
+				return
+			}
+
+			var got contracts.TemperatureWithCity
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(got, *tt.weather) {
+				t.Errorf("response = %+v, want %+v", got, *tt.weather)
+			}
+			if rec.Header().Get("Server-Timing") == "" {
+				t.Error("Server-Timing header missing")
+			}
+		})
+	}
+}
+
+func BenchmarkZipcodeHandler(b *testing.B) {
+	weather := weatherFunc(func(context.Context, string, []string, string) (*contracts.TemperatureWithCity, error) {
+		response := linhares
+		return &response, nil
+	})
+	handler := NewZipcodeHandler(weather, nil, noop.NewTracerProvider().Tracer(""), "")
+	body := strings.NewReader(`{"cep": "29902555"}`)
+	req := httptest.NewRequest(http.MethodPost, ZipcodeRoute, body)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		body.Reset(`{"cep": "29902555"}`)
+		req.Body = io.NopCloser(body)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			b.Fatalf("status = %d; body %s", rec.Code, rec.Body)
+		}
+	}
+}
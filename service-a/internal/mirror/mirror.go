@@ -0,0 +1,94 @@
+package mirror
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/callbudget"
+	"github.com/luis-olivetti/go-observability/pkg/platform/tenant"
+	"github.com/luis-olivetti/go-observability/service-a/internal/workerpool"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// WeatherService is the service B call being mirrored.
+type WeatherService interface {
+	CityWeather(ctx context.Context, zipCode string, include []string, precision string) (*contracts.TemperatureWithCity, error)
+}
+
+type Config struct {
+	// Percent of the calls copied to staging, above 0 and up to 100.
+	Percent float64
+	// Timeout bounds each copy; it does not start until a worker picks the
+	// copy up.
+	Timeout time.Duration
+}
+
+// Weather answers from primary and copies a share of the calls to staging in
+// the background, so a new service B can be tried on the shape of production
+// traffic. The copies never hold back or change the answer: they wait in
+// pool and are dropped when it is full, and their responses are discarded.
+// Probes of the prober are not copied.
+type Weather struct {
+	cfg     Config
+	primary WeatherService
+	staging WeatherService
+	pool    *workerpool.Pool
+}
+
+func NewWeather(cfg Config, primary, staging WeatherService, pool *workerpool.Pool) *Weather {
+	return &Weather{cfg: cfg, primary: primary, staging: staging, pool: pool}
+}
+
+func (m *Weather) CityWeather(ctx context.Context, zipCode string, include []string, precision string) (*contracts.TemperatureWithCity, error) {
+	if m.sampled(ctx) {
+		// A full pool is already counted as a rejection by the pool; the
+		// copy is simply not sent. The copy is not made on behalf of the
+		// request, so it is left out of its outbound call budget.
+		_ = m.pool.SubmitDetached(callbudget.Exempt(Mark(ctx)), "mirror.city_weather", func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+			defer cancel()
+
+			_, err := m.staging.CityWeather(ctx, zipCode, include, precision)
+			return err
+		})
+	}
+
+	return m.primary.CityWeather(ctx, zipCode, include, precision)
+}
+
+func (m *Weather) sampled(ctx context.Context) bool {
+	if tenant.IsSynthetic(ctx) {
+		return false
+	}
+	return rand.Float64()*100 < m.cfg.Percent
+}
+
+// Mark adds the mirror member to the baggage of ctx, so staging, and the
+// spans of the copy here, tell mirrored calls from their own traffic.
+func Mark(ctx context.Context) context.Context {
+	member, err := baggage.NewMemberRaw(tenant.MirrorKey, "true")
+	if err != nil {
+		return ctx
+	}
+
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx
+	}
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+// Transport sets contracts.MirrorHeader on every request, for staging
+// components that do not read baggage, such as a gateway's access log.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(contracts.MirrorHeader, "true")
+	return t.Base.RoundTrip(req)
+}
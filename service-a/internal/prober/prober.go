@@ -0,0 +1,133 @@
+package prober
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/tenant"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultZipcode is a known-good CEP (Linhares/ES).
+const DefaultZipcode = "29902555"
+
+type Config struct {
+	Interval time.Duration
+	Timeout  time.Duration
+	Zipcode  string
+}
+
+// Prober periodically sends a known-good lookup through the zipcode handler,
+// and from there to service B and its upstreams, giving continuous black-box
+// monitoring from inside the deployment. Probes skip the auth, quota and
+// abuse middlewares and are marked with synthetic=true on every span of the
+// trace, in both services.
+type Prober struct {
+	cfg    Config
+	target http.Handler
+	tracer trace.Tracer
+
+	runs     metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+func New(cfg Config, target http.Handler, tracer trace.Tracer) *Prober {
+	meter := otel.Meter("microservice-meter")
+
+	runs, err := meter.Int64Counter("probe.runs",
+		metric.WithDescription("Synthetic probes executed, by result"))
+	if err != nil {
+		log.Printf("failed to create probe runs counter: %v", err)
+	}
+
+	duration, err := meter.Float64Histogram("probe.duration",
+		metric.WithDescription("Synthetic probe duration, by result"),
+		metric.WithUnit("s"))
+	if err != nil {
+		log.Printf("failed to create probe duration histogram: %v", err)
+	}
+
+	return &Prober{cfg: cfg, target: target, tracer: tracer, runs: runs, duration: duration}
+}
+
+// Run probes once right away and then every Interval until ctx is done.
+func (p *Prober) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.probe(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Prober) probe(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	ctx, span := p.tracer.Start(tenant.WithSynthetic(ctx), "syntheticProbe")
+	defer span.End()
+	span.SetAttributes(attribute.String(tenant.SyntheticKey, "true"))
+
+	start := time.Now()
+	err := p.lookup(ctx)
+	elapsed := time.Since(start)
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		log.Printf("synthetic probe failed: %v", err)
+	}
+	span.SetAttributes(attribute.String("probe.result", result))
+
+	// The probe context may already be cancelled; metrics must still count.
+	attrs := metric.WithAttributes(attribute.String("probe.result", result))
+	if p.runs != nil {
+		p.runs.Add(context.WithoutCancel(ctx), 1, attrs)
+	}
+	if p.duration != nil {
+		p.duration.Record(context.WithoutCancel(ctx), elapsed.Seconds(), attrs)
+	}
+}
+
+func (p *Prober) lookup(ctx context.Context) error {
+	body := fmt.Sprintf(`{"cep":%q}`, p.cfg.Zipcode)
+	req := httptest.NewRequest("POST", "/city-by-zipcode", strings.NewReader(body)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	p.target.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", rec.Code, strings.TrimSpace(rec.Body.String()))
+	}
+
+	var response struct {
+		City string `json:"city"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if response.City == "" {
+		return fmt.Errorf("response has no city")
+	}
+
+	return nil
+}
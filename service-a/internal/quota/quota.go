@@ -0,0 +1,205 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/bufpool"
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+	"github.com/luis-olivetti/go-observability/pkg/platform/logsample"
+	"github.com/luis-olivetti/go-observability/pkg/platform/messages"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+	"github.com/luis-olivetti/go-observability/pkg/platform/security"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Limits are the allowed requests per period; zero means unlimited.
+type Limits struct {
+	Daily   int64 `json:"daily"`
+	Monthly int64 `json:"monthly"`
+}
+
+// ParseOverrides reads per-key limits in the "id=daily/monthly,..." format.
+func ParseOverrides(raw string) (map[string]Limits, error) {
+	overrides := map[string]Limits{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, values, ok := strings.Cut(entry, "=")
+		daily, monthly, ok2 := strings.Cut(values, "/")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid quota override %q, expected id=daily/monthly", entry)
+		}
+
+		dailyLimit, err := strconv.ParseInt(daily, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid daily quota for %s: %w", id, err)
+		}
+		monthlyLimit, err := strconv.ParseInt(monthly, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid monthly quota for %s: %w", id, err)
+		}
+
+		overrides[id] = Limits{Daily: dailyLimit, Monthly: monthlyLimit}
+	}
+
+	return overrides, nil
+}
+
+// Identity resolves the caller a request is metered against.
+type Identity func(ctx context.Context) (string, bool)
+
+type Meter struct {
+	// Clock defaults to the wall clock when nil.
+	Clock clock.Clock
+
+	store     Store
+	defaults  Limits
+	overrides map[string]Limits
+	identity  Identity
+}
+
+func NewMeter(store Store, defaults Limits, overrides map[string]Limits, identity Identity) *Meter {
+	return &Meter{store: store, defaults: defaults, overrides: overrides, identity: identity}
+}
+
+func (m *Meter) limits(id string) Limits {
+	if limits, ok := m.overrides[id]; ok {
+		return limits
+	}
+
+	return m.defaults
+}
+
+type period struct {
+	name    string
+	key     string
+	resetAt time.Time
+	limit   int64
+}
+
+func (m *Meter) periods(id string, now time.Time) []period {
+	now = now.UTC()
+	limits := m.limits(id)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	return []period{
+		{name: "daily", key: "quota:" + id + ":daily:" + now.Format("20060102"), resetAt: startOfDay.AddDate(0, 0, 1), limit: limits.Daily},
+		{name: "monthly", key: "quota:" + id + ":monthly:" + now.Format("200601"), resetAt: startOfMonth.AddDate(0, 1, 0), limit: limits.Monthly},
+	}
+}
+
+// Middleware counts every request of an identified caller and answers 429
+// once any period is exhausted. Store failures fail open. Every response
+// carries X-RateLimit-* headers for the period closest to exhaustion.
+func (m *Meter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := m.identity(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		now := clock.Or(m.Clock).Now()
+		var tightest *period
+		var tightestRemaining int64
+		for _, p := range m.periods(id, now) {
+			if p.limit == 0 {
+				continue
+			}
+
+			count, err := m.store.Incr(r.Context(), p.key, p.resetAt.Sub(now))
+			if err != nil {
+				logsample.Errorf(r.Context(), "failed to meter request for %s: %v", id, err)
+				continue
+			}
+
+			remaining := p.limit - count
+			if remaining < 0 {
+				remaining = 0
+			}
+			if tightest == nil || remaining < tightestRemaining {
+				p := p
+				tightest, tightestRemaining = &p, remaining
+			}
+
+			if count > p.limit {
+				security.Record(r, security.RateLimited, p.name+"_quota_exhausted")
+				setRateLimitHeaders(w, p, 0)
+				w.Header().Set("Retry-After", strconv.Itoa(int(p.resetAt.Sub(now).Seconds())+1))
+				problem.Write(w, r, http.StatusTooManyRequests, contracts.CodeQuotaExceeded, messages.Params{"period": p.name}, nil)
+				return
+			}
+		}
+
+		if tightest != nil {
+			setRateLimitHeaders(w, *tightest, tightestRemaining)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, p period, remaining int64) {
+	w.Header().Set("X-RateLimit-Limit", strconv.FormatInt(p.limit, 10))
+	w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(p.resetAt.Unix(), 10))
+}
+
+type periodUsage struct {
+	Used    int64     `json:"used"`
+	Limit   int64     `json:"limit"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+type usageResponse struct {
+	KeyID   string      `json:"key_id"`
+	Daily   periodUsage `json:"daily"`
+	Monthly periodUsage `json:"monthly"`
+}
+
+// UsageHandler reports the caller's consumption in the current periods.
+func (m *Meter) UsageHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := m.identity(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, contracts.CodeUnauthorized, nil, nil)
+		return
+	}
+
+	response := usageResponse{KeyID: id}
+	for _, p := range m.periods(id, clock.Or(m.Clock).Now()) {
+		used, err := m.store.Get(r.Context(), p.key)
+		if err != nil {
+			problem.Write(w, r, http.StatusInternalServerError, contracts.CodeInternal, nil, nil)
+			return
+		}
+
+		usage := periodUsage{Used: used, Limit: p.limit, ResetAt: p.resetAt}
+		if p.name == "daily" {
+			response.Daily = usage
+		} else {
+			response.Monthly = usage
+		}
+	}
+
+	body := bufpool.Get()
+	defer bufpool.Put(body)
+
+	if err := body.Encode(response); err != nil {
+		logsample.Errorf(r.Context(), "failed to encode usage for %s: %v", id, err)
+		problem.Write(w, r, http.StatusInternalServerError, contracts.CodeInternal, nil, nil)
+		return
+	}
+	if err := bufpool.Write(w, http.StatusOK, "application/json", body); err != nil {
+		trace.SpanFromContext(r.Context()).RecordError(err)
+	}
+}
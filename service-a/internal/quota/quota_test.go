@@ -0,0 +1,48 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+)
+
+func TestUsageHandler(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryStore()
+	store.Clock = clk
+	meter := NewMeter(store, Limits{Daily: 10, Monthly: 100}, nil, func(context.Context) (string, bool) {
+		return "partner", true
+	})
+	meter.Clock = clk
+
+	handler := meter.Middleware(http.HandlerFunc(meter.UsageHandler))
+	for i := 0; i < 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/usage", nil))
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/usage", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Length"); got != strconv.Itoa(rec.Body.Len()) {
+		t.Errorf("Content-Length = %q, want %d", got, rec.Body.Len())
+	}
+
+	var usage usageResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &usage); err != nil {
+		t.Fatalf("failed to decode usage: %v", err)
+	}
+	if usage.KeyID != "partner" || usage.Daily.Used != 4 || usage.Monthly.Used != 4 {
+		t.Errorf("usage = %+v, want 4 requests used by partner in both periods", usage)
+	}
+	if want := time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC); !usage.Daily.ResetAt.Equal(want) {
+		t.Errorf("daily reset = %s, want %s", usage.Daily.ResetAt, want)
+	}
+}
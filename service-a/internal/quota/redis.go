@@ -0,0 +1,53 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// incrScript increments a counter and, on its first increment, sets the
+// expiry in the same step, so a failure between the two never leaves a
+// counter that lives forever.
+var incrScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// RedisStore persists counters in Redis, so quotas are shared across
+// replicas and survive restarts.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(addr, password string) *RedisStore {
+	return &RedisStore{client: redis.NewClient(&redis.Options{
+		Addr:         addr,
+		Password:     password,
+		DialTimeout:  2 * time.Second,
+		ReadTimeout:  2 * time.Second,
+		WriteTimeout: 2 * time.Second,
+	})}
+}
+
+func (s *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, s.client, []string{key}, ttl.Milliseconds()).Int64()
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (int64, error) {
+	count, err := s.client.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+
+	return count, err
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
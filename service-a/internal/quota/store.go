@@ -0,0 +1,81 @@
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+)
+
+// Store keeps usage counters. Implementations must make Incr atomic and let
+// counters expire after ttl so old periods clean themselves up.
+type Store interface {
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	Get(ctx context.Context, key string) (int64, error)
+}
+
+// sweepInterval is how often Incr drops expired counters. Keys carry their
+// period, so without the sweep every past day and month would stay in memory.
+const sweepInterval = time.Minute
+
+type memoryEntry struct {
+	value     int64
+	expiresAt time.Time
+}
+
+// MemoryStore is a process-local Store, suitable for single-instance
+// deployments and development.
+type MemoryStore struct {
+	// Clock defaults to the wall clock when nil.
+	Clock clock.Clock
+
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	nextSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]memoryEntry{}}
+}
+
+func (s *MemoryStore) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := clock.Or(s.Clock).Now()
+	if !now.Before(s.nextSweep) {
+		s.sweep(now)
+	}
+
+	entry, ok := s.entries[key]
+	if !ok || now.After(entry.expiresAt) {
+		entry = memoryEntry{expiresAt: now.Add(ttl)}
+	}
+	entry.value++
+	s.entries[key] = entry
+
+	return entry.value, nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || clock.Or(s.Clock).Now().After(entry.expiresAt) {
+		return 0, nil
+	}
+
+	return entry.value, nil
+}
+
+// sweep deletes the counters expired at now. s.mu must be held.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.nextSweep = now.Add(sweepInterval)
+}
@@ -0,0 +1,82 @@
+package quota
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+)
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	store := NewMemoryStore()
+	store.Clock = clk
+	ctx := context.Background()
+
+	store.Incr(ctx, "key", time.Minute)
+	if got, _ := store.Incr(ctx, "key", time.Minute); got != 2 {
+		t.Fatalf("Incr = %d, want 2", got)
+	}
+
+	clk.Advance(time.Minute + time.Second)
+	if got, _ := store.Get(ctx, "key"); got != 0 {
+		t.Errorf("Get after expiry = %d, want 0", got)
+	}
+	if got, _ := store.Incr(ctx, "key", time.Minute); got != 1 {
+		t.Errorf("Incr after expiry = %d, want a new counter at 1", got)
+	}
+}
+
+func TestMemoryStoreConcurrentIncr(t *testing.T) {
+	const (
+		callers = 50
+		incrs   = 200
+		keys    = 5
+	)
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < incrs; j++ {
+				key := "key-" + strconv.Itoa((i+j)%keys)
+				store.Incr(ctx, key, time.Hour)
+				store.Get(ctx, key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var total int64
+	for k := 0; k < keys; k++ {
+		got, _ := store.Get(ctx, "key-"+strconv.Itoa(k))
+		total += got
+	}
+	if total != callers*incrs {
+		t.Errorf("counters add up to %d, want %d", total, callers*incrs)
+	}
+}
+
+func TestMemoryStoreEvictsExpired(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	store := NewMemoryStore()
+	store.Clock = clk
+	ctx := context.Background()
+
+	for day := 0; day < 30; day++ {
+		store.Incr(ctx, "quota:partner:daily:"+strconv.Itoa(day), 24*time.Hour)
+		clk.Advance(24 * time.Hour)
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if got := len(store.entries); got > 2 {
+		t.Errorf("store holds %d counters after 30 daily periods, want the expired ones evicted", got)
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/health"
+)
+
+// ShutdownTimeout bounds how long in-flight requests get to finish.
+const ShutdownTimeout = 30 * time.Second
+
+// Listener names.
+const (
+	Public   = "public"
+	Internal = "internal"
+	Admin    = "admin"
+)
+
+// Listener is one HTTP server of the process. Each listener has its own
+// port, TLS certificate and handler, and so its own middleware chain.
+type Listener struct {
+	Name   string
+	Server *http.Server
+	// CertFile and KeyFile switch the listener to TLS when set.
+	CertFile string
+	KeyFile  string
+}
+
+func (l Listener) url() string {
+	if l.CertFile != "" {
+		return "https://localhost" + l.Server.Addr
+	}
+	return "http://localhost" + l.Server.Addr
+}
+
+// Run serves every listener until ctx is done, then marks the instance not
+// ready and shuts them all down gracefully. On SIGHUP the running binary is
+// replaced through upgrader: once the new process serves on the same
+// sockets, this one drains and Run returns.
+func Run(ctx context.Context, checker *health.Checker, upgrader *Upgrader, listeners ...Listener) error {
+	for _, l := range listeners {
+		ln, err := upgrader.Listen(l.Name, l.Server.Addr)
+		if err != nil {
+			return err
+		}
+
+		go func(l Listener) {
+			log.Printf("%s server started at %s\n", l.Name, l.url())
+
+			var err error
+			if l.CertFile != "" {
+				err = l.Server.ServeTLS(ln, l.CertFile, l.KeyFile)
+			} else {
+				err = l.Server.Serve(ln)
+			}
+			if err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Error starting %s server: %v\n", l.Name, err)
+			}
+		}(l)
+	}
+
+	checker.SetReady(true)
+	if err := upgrader.Ready(); err != nil {
+		log.Printf("failed to complete upgrade handoff: %v", err)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+wait:
+	for {
+		select {
+		case <-ctx.Done():
+			break wait
+		case <-upgrader.Exit():
+			log.Println("New process took over the listeners. Draining...")
+			break wait
+		case <-hup:
+			log.Println("Received upgrade signal. Starting new process...")
+			if err := upgrader.Upgrade(); err != nil {
+				log.Printf("upgrade failed, still serving: %v", err)
+			}
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	checker.SetReady(false)
+
+	var errs []error
+	for _, l := range listeners {
+		if err := l.Server.Shutdown(shutdownCtx); err != nil {
+			errs = append(errs, fmt.Errorf("%s server shutdown failed: %w", l.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment passed from a process to the binary that replaces it.
+const (
+	listenersEnv = "UPGRADE_LISTENERS"
+	readyFDEnv   = "UPGRADE_READY_FD"
+)
+
+// UpgradeTimeout bounds how long the new binary gets to report ready before
+// the upgrade is abandoned and the current process keeps serving.
+const UpgradeTimeout = time.Minute
+
+// ErrUpgradeInProgress is returned by Upgrade while a previous upgrade is
+// still waiting for the new process.
+var ErrUpgradeInProgress = errors.New("upgrade already in progress")
+
+// Upgrader hands the listening sockets to a freshly started copy of the
+// binary, so it can be replaced without refusing connections: the new process
+// accepts on the same sockets while the old one drains its in-flight requests.
+type Upgrader struct {
+	pidFile string
+
+	inherited map[string]*os.File
+	ready     *os.File
+
+	mu        sync.Mutex
+	listeners []namedListener
+	upgrading bool
+	exit      chan struct{}
+}
+
+type namedListener struct {
+	name string
+	file *os.File
+}
+
+// NewUpgrader picks up the sockets handed over by the previous process, if
+// any. pidFile, when set, is rewritten by whichever process is serving, for
+// supervisors that track the main PID through it.
+func NewUpgrader(pidFile string) (*Upgrader, error) {
+	u := &Upgrader{
+		pidFile:   pidFile,
+		inherited: make(map[string]*os.File),
+		exit:      make(chan struct{}),
+	}
+
+	if names := os.Getenv(listenersEnv); names != "" {
+		for i, name := range strings.Split(names, ",") {
+			u.inherited[name] = os.NewFile(uintptr(3+i), name)
+		}
+	}
+
+	if fd := os.Getenv(readyFDEnv); fd != "" {
+		n, err := strconv.Atoi(fd)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", readyFDEnv, err)
+		}
+		u.ready = os.NewFile(uintptr(n), "upgrade-ready")
+	}
+
+	// Children of this process must not see the handoff of its parent.
+	os.Unsetenv(listenersEnv)
+	os.Unsetenv(readyFDEnv)
+
+	return u, nil
+}
+
+// Listen returns the socket the previous process handed over under name, or
+// a new one bound to addr.
+func (u *Upgrader) Listen(name, addr string) (net.Listener, error) {
+	var (
+		ln  net.Listener
+		err error
+	)
+	if file, ok := u.inherited[name]; ok {
+		ln, err = net.FileListener(file)
+		file.Close()
+	} else {
+		ln, err = net.Listen("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for %s on %s: %w", name, addr, err)
+	}
+
+	tcp, ok := ln.(*net.TCPListener)
+	if !ok {
+		return ln, nil
+	}
+	file, err := tcp.File()
+	if err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to duplicate %s listener: %w", name, err)
+	}
+
+	u.mu.Lock()
+	u.listeners = append(u.listeners, namedListener{name: name, file: file})
+	u.mu.Unlock()
+
+	return ln, nil
+}
+
+// Ready tells the previous process, if there is one, that this one is
+// serving, so it can stop accepting and drain.
+func (u *Upgrader) Ready() error {
+	if u.pidFile != "" {
+		if err := os.WriteFile(u.pidFile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+			return fmt.Errorf("failed to write pid file: %w", err)
+		}
+	}
+
+	if u.ready == nil {
+		return nil
+	}
+	defer func() { u.ready = nil }()
+	defer u.ready.Close()
+
+	if _, err := u.ready.Write([]byte{1}); err != nil {
+		return fmt.Errorf("failed to notify the previous process: %w", err)
+	}
+	return nil
+}
+
+// Upgrade starts a new copy of the binary with the same arguments, passes it
+// the listening sockets and waits until it reports ready. On success the
+// channel returned by Exit is closed and this process should shut down; on
+// failure it keeps serving.
+func (u *Upgrader) Upgrade() error {
+	u.mu.Lock()
+	if u.upgrading {
+		u.mu.Unlock()
+		return ErrUpgradeInProgress
+	}
+	u.upgrading = true
+	listeners := u.listeners
+	u.mu.Unlock()
+
+	defer func() {
+		u.mu.Lock()
+		u.upgrading = false
+		u.mu.Unlock()
+	}()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create readiness pipe: %w", err)
+	}
+	defer readyR.Close()
+
+	names := make([]string, 0, len(listeners))
+	files := make([]*os.File, 0, len(listeners)+1)
+	for _, l := range listeners {
+		names = append(names, l.name)
+		files = append(files, l.file)
+	}
+	files = append(files, readyW)
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		listenersEnv+"="+strings.Join(names, ","),
+		readyFDEnv+"="+strconv.Itoa(3+len(listeners)),
+	)
+
+	err = cmd.Start()
+	readyW.Close()
+	if err != nil {
+		return fmt.Errorf("failed to start new process: %w", err)
+	}
+
+	// The pipe reports ready with one byte; EOF without it means the new
+	// process exited or gave up before serving.
+	result := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		if n, _ := readyR.Read(buf); n == 1 {
+			result <- nil
+			return
+		}
+		result <- errors.New("new process exited before reporting ready")
+	}()
+
+	select {
+	case err := <-result:
+		if err != nil {
+			cmd.Wait()
+			return err
+		}
+	case <-time.After(UpgradeTimeout):
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("new process did not report ready within %s", UpgradeTimeout)
+	}
+
+	// The new process is no longer ours to wait for; release it so it is not
+	// left a zombie once it outlives this one.
+	cmd.Process.Release()
+	close(u.exit)
+	return nil
+}
+
+// Exit is closed once a new process has taken over the listeners.
+func (u *Upgrader) Exit() <-chan struct{} {
+	return u.exit
+}
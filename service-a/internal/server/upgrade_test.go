@@ -0,0 +1,195 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The new process of an upgrade is this test binary running
+// TestUpgradeHelperProcess, in the mode set by helperModeEnv.
+const (
+	helperModeEnv    = "UPGRADE_TEST_HELPER"
+	helperPIDFileEnv = "UPGRADE_TEST_PID_FILE"
+)
+
+// TestUpgradeHelperProcess is the new process started by Upgrade. It takes
+// over the "http" socket, reports ready and answers one connection with its
+// pid, unless its mode makes it exit or wait first.
+func TestUpgradeHelperProcess(t *testing.T) {
+	mode := os.Getenv(helperModeEnv)
+	if mode == "" {
+		t.Skip("only run as the new process of an upgrade")
+	}
+
+	u, err := NewUpgrader(os.Getenv(helperPIDFileEnv))
+	if err != nil {
+		os.Exit(2)
+	}
+	switch mode {
+	case "exit":
+		os.Exit(1)
+	case "slow":
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	// An address that cannot be bound: the socket must be the inherited one.
+	ln, err := u.Listen("http", "unbindable")
+	if err != nil {
+		os.Exit(3)
+	}
+	if err := u.Ready(); err != nil {
+		os.Exit(4)
+	}
+
+	// Give up soon when no one connects, so the helper does not outlive the
+	// test for long.
+	ln.(*net.TCPListener).SetDeadline(time.Now().Add(2 * time.Second))
+	if conn, err := ln.Accept(); err == nil {
+		fmt.Fprint(conn, os.Getpid())
+		conn.Close()
+	}
+	os.Exit(0)
+}
+
+// startUpgrade prepares Upgrade to start the helper in mode, listening on
+// "http", and returns the upgrader and its listener.
+func startUpgrade(t *testing.T, mode, pidFile string) (*Upgrader, net.Listener) {
+	t.Helper()
+
+	args := os.Args
+	os.Args = []string{args[0], "-test.run=^TestUpgradeHelperProcess$"}
+	t.Cleanup(func() { os.Args = args })
+	t.Setenv(helperModeEnv, mode)
+	t.Setenv(helperPIDFileEnv, pidFile)
+
+	u, err := NewUpgrader("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := u.Listen("http", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	return u, ln
+}
+
+func TestUpgrade(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "service-a.pid")
+	u, ln := startUpgrade(t, "serve", pidFile)
+
+	if err := u.Upgrade(); err != nil {
+		t.Fatalf("Upgrade failed: %v", err)
+	}
+	select {
+	case <-u.Exit():
+	default:
+		t.Fatal("Exit not closed after the new process took over")
+	}
+
+	// Once this process stops accepting, the new one answers on the same
+	// address.
+	addr := ln.Addr().String()
+	ln.Close()
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	answer, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pid, err := strconv.Atoi(string(answer))
+	if err != nil || pid == os.Getpid() {
+		t.Fatalf("connection answered %q, want the pid of the new process", answer)
+	}
+	written, err := os.ReadFile(pidFile)
+	if err != nil || strings.TrimSpace(string(written)) != strconv.Itoa(pid) {
+		t.Errorf("pid file = %q, %v, want %d", written, err, pid)
+	}
+}
+
+func TestUpgradeNewProcessExits(t *testing.T) {
+	u, _ := startUpgrade(t, "exit", "")
+
+	if err := u.Upgrade(); err == nil || errors.Is(err, ErrUpgradeInProgress) {
+		t.Fatalf("Upgrade error = %v, want the new process reported as exited", err)
+	}
+	select {
+	case <-u.Exit():
+		t.Fatal("Exit closed after a failed upgrade")
+	default:
+	}
+
+	// The failure ends the upgrade, so another may be attempted.
+	if err := u.Upgrade(); errors.Is(err, ErrUpgradeInProgress) {
+		t.Errorf("second Upgrade error = %v, want a new attempt", err)
+	}
+}
+
+func TestUpgradeInProgress(t *testing.T) {
+	u, _ := startUpgrade(t, "slow", "")
+
+	result := make(chan error, 1)
+	go func() { result <- u.Upgrade() }()
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		u.mu.Lock()
+		upgrading := u.upgrading
+		u.mu.Unlock()
+		if upgrading {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("upgrade did not start")
+		}
+	}
+
+	if err := u.Upgrade(); !errors.Is(err, ErrUpgradeInProgress) {
+		t.Errorf("concurrent Upgrade error = %v, want ErrUpgradeInProgress", err)
+	}
+	if err := <-result; err != nil {
+		t.Errorf("first Upgrade failed: %v", err)
+	}
+}
+
+func TestNewUpgrader(t *testing.T) {
+	t.Setenv(readyFDEnv, "not-a-fd")
+	if _, err := NewUpgrader(""); err == nil {
+		t.Fatal("NewUpgrader accepted an invalid ready fd")
+	}
+
+	t.Setenv(listenersEnv, "")
+	t.Setenv(readyFDEnv, "")
+	pidFile := filepath.Join(t.TempDir(), "service-a.pid")
+	u, err := NewUpgrader(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a previous process Listen binds and Ready only writes the pid
+	// file.
+	ln, err := u.Listen("http", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.Close()
+	if err := u.Ready(); err != nil {
+		t.Fatalf("Ready failed: %v", err)
+	}
+	if written, _ := os.ReadFile(pidFile); strings.TrimSpace(string(written)) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("pid file = %q, want %d", written, os.Getpid())
+	}
+}
@@ -0,0 +1,129 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+)
+
+const (
+	DefaultMaxBodySize = 1 << 20
+	DefaultMaxDepth    = 10
+)
+
+// DecodeError is a request body that could not be decoded. Status is 400 for
+// malformed documents and 422 for well-formed documents with invalid fields.
+// Detail says what was wrong for spans and logs; clients read the catalog
+// message of Code.
+type DecodeError struct {
+	Status int
+	Detail string
+	Fields []problem.FieldError
+}
+
+// Code is the machine-readable error code for the problem document.
+func (e *DecodeError) Code() contracts.ErrorCode {
+	switch e.Status {
+	case http.StatusUnprocessableEntity:
+		return contracts.CodeValidationFailed
+	case http.StatusRequestEntityTooLarge:
+		return contracts.CodePayloadTooLarge
+	default:
+		return contracts.CodeBadRequest
+	}
+}
+
+func (e *DecodeError) Error() string {
+	return e.Detail
+}
+
+// Decode strictly decodes a JSON body into v: unknown fields, trailing data
+// and documents nested deeper than maxDepth are rejected, and v is then
+// validated against its `validate` tags.
+func Decode(r *http.Request, v interface{}, maxDepth int) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, DefaultMaxBodySize+1))
+	if err != nil {
+		return &DecodeError{Status: http.StatusBadRequest, Detail: "failed to read request body"}
+	}
+	if len(body) > DefaultMaxBodySize {
+		return &DecodeError{Status: http.StatusRequestEntityTooLarge, Detail: "request body too large"}
+	}
+
+	if err := checkDepth(body, maxDepth); err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	// More misses a stray closing delimiter; Token reports anything but the
+	// end of the body.
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return &DecodeError{Status: http.StatusBadRequest, Detail: "request body must contain a single JSON document"}
+	}
+
+	if fields := Struct(v); len(fields) > 0 {
+		return &DecodeError{Status: http.StatusUnprocessableEntity, Detail: "invalid request", Fields: fields}
+	}
+
+	return nil
+}
+
+func decodeError(err error) error {
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxError), errors.Is(err, io.ErrUnexpectedEOF):
+		return &DecodeError{Status: http.StatusBadRequest, Detail: "malformed JSON"}
+	case errors.Is(err, io.EOF):
+		return &DecodeError{Status: http.StatusBadRequest, Detail: "request body is empty"}
+	case errors.As(err, &typeError):
+		return &DecodeError{
+			Status: http.StatusUnprocessableEntity,
+			Detail: "invalid request",
+			Fields: []problem.FieldError{{Field: typeError.Field, Message: "must be a " + typeError.Type.String()}},
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &DecodeError{
+			Status: http.StatusBadRequest,
+			Detail: "unknown field",
+			Fields: []problem.FieldError{{Field: field, Message: "is not allowed"}},
+		}
+	default:
+		return &DecodeError{Status: http.StatusBadRequest, Detail: "invalid JSON"}
+	}
+}
+
+func checkDepth(body []byte, maxDepth int) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			// Syntax errors are reported by the real decode.
+			return nil
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+			if depth > maxDepth {
+				return &DecodeError{Status: http.StatusBadRequest, Detail: fmt.Sprintf("JSON nested deeper than %d levels", maxDepth)}
+			}
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package validation
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+)
+
+type request struct {
+	ZipCode string `json:"cep" validate:"required,len=8,numeric"`
+	Note    string `json:"note,omitempty" validate:"max=5"`
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   contracts.ErrorCode
+		wantFields []problem.FieldError
+	}{
+		{name: "valid", body: `{"cep": "29902555"}`},
+		{name: "empty", body: ``, wantStatus: http.StatusBadRequest, wantCode: contracts.CodeBadRequest},
+		{name: "malformed", body: `{"cep": "29902555"`, wantStatus: http.StatusBadRequest, wantCode: contracts.CodeBadRequest},
+		{name: "syntax error", body: `{"cep" "29902555"}`, wantStatus: http.StatusBadRequest, wantCode: contracts.CodeBadRequest},
+		{name: "trailing document", body: `{"cep": "29902555"} {}`, wantStatus: http.StatusBadRequest, wantCode: contracts.CodeBadRequest},
+		{name: "trailing brace", body: `{"cep": "29902555"}}`, wantStatus: http.StatusBadRequest, wantCode: contracts.CodeBadRequest},
+		{name: "trailing bracket", body: `{"cep": "29902555"} ]`, wantStatus: http.StatusBadRequest, wantCode: contracts.CodeBadRequest},
+		{name: "trailing whitespace", body: "{\"cep\": \"29902555\"}\n"},
+		{
+			name:       "unknown field",
+			body:       `{"cep": "29902555", "zip": "1"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   contracts.CodeBadRequest,
+			wantFields: []problem.FieldError{{Field: "zip", Message: "is not allowed"}},
+		},
+		{
+			name:       "wrong type",
+			body:       `{"cep": 29902555}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   contracts.CodeValidationFailed,
+			wantFields: []problem.FieldError{{Field: "cep", Message: "must be a string"}},
+		},
+		{
+			name:       "required",
+			body:       `{}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   contracts.CodeValidationFailed,
+			wantFields: []problem.FieldError{{Field: "cep", Message: "is required"}},
+		},
+		{
+			name:       "length and max",
+			body:       `{"cep": "2990", "note": "too long"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   contracts.CodeValidationFailed,
+			wantFields: []problem.FieldError{
+				{Field: "cep", Message: "must have 8 characters"},
+				{Field: "note", Message: "must have at most 5 characters"},
+			},
+		},
+		{
+			name:       "numeric",
+			body:       `{"cep": "2990255a"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   contracts.CodeValidationFailed,
+			wantFields: []problem.FieldError{{Field: "cep", Message: "must contain only digits"}},
+		},
+		{name: "too deep", body: `{"cep": "29902555", "x": [[[]]]}`, wantStatus: http.StatusBadRequest, wantCode: contracts.CodeBadRequest},
+		{
+			name:       "too large",
+			body:       `{"cep": "` + strings.Repeat("1", DefaultMaxBodySize) + `"}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   contracts.CodePayloadTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			var got request
+			err := Decode(req, &got, 3)
+
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("Decode failed: %v", err)
+				}
+				return
+			}
+
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("Decode error = %v, want *DecodeError", err)
+			}
+			if decodeErr.Status != tt.wantStatus || decodeErr.Code() != tt.wantCode {
+				t.Errorf("Decode error = %d %s, want %d %s", decodeErr.Status, decodeErr.Code(), tt.wantStatus, tt.wantCode)
+			}
+			if tt.wantFields != nil && !reflect.DeepEqual(decodeErr.Fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", decodeErr.Fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	for _, bench := range []struct {
+		name string
+		body string
+	}{
+		{name: "valid", body: `{"cep": "29902555"}`},
+		{name: "invalid", body: `{"cep": "2990", "note": "too long"}`},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			body := strings.NewReader(bench.body)
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			b.SetBytes(int64(len(bench.body)))
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				body.Reset(bench.body)
+				req.Body = io.NopCloser(body)
+				var msg request
+				Decode(req, &msg, DefaultMaxDepth)
+			}
+		})
+	}
+}
+
+func BenchmarkStruct(b *testing.B) {
+	msg := request{ZipCode: "29902555"}
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		Struct(&msg)
+	}
+}
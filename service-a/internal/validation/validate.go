@@ -0,0 +1,95 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+)
+
+// Struct validates v against `validate` struct tags, go-playground/validator
+// style. Supported rules: required, len=N, min=N, max=N and numeric. Fields
+// are reported by their JSON name.
+func Struct(v interface{}) []problem.FieldError {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []problem.FieldError
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" || !field.IsExported() {
+			continue
+		}
+
+		for _, rule := range strings.Split(tag, ",") {
+			if message := check(value.Field(i), rule); message != "" {
+				fields = append(fields, problem.FieldError{Field: jsonName(field), Message: message})
+				break
+			}
+		}
+	}
+
+	return fields
+}
+
+func check(value reflect.Value, rule string) string {
+	name, param, _ := strings.Cut(rule, "=")
+
+	switch name {
+	case "required":
+		if value.IsZero() {
+			return "is required"
+		}
+	case "len", "min", "max":
+		limit, err := strconv.Atoi(param)
+		if err != nil {
+			panic(fmt.Sprintf("validation: invalid %s rule parameter %q", name, param))
+		}
+		size := length(value)
+		switch {
+		case name == "len" && size != limit:
+			return fmt.Sprintf("must have %d characters", limit)
+		case name == "min" && size < limit:
+			return fmt.Sprintf("must have at least %d characters", limit)
+		case name == "max" && size > limit:
+			return fmt.Sprintf("must have at most %d characters", limit)
+		}
+	case "numeric":
+		for _, c := range value.String() {
+			if c < '0' || c > '9' {
+				return "must contain only digits"
+			}
+		}
+	default:
+		panic(fmt.Sprintf("validation: unknown rule %q", name))
+	}
+
+	return ""
+}
+
+func length(value reflect.Value) int {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String())
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len()
+	default:
+		return 0
+	}
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+
+	return name
+}
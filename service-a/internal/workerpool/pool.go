@@ -0,0 +1,245 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrQueueFull = errors.New("worker pool queue is full")
+	ErrClosed    = errors.New("worker pool is closed")
+)
+
+type Config struct {
+	// Name identifies the pool on spans and metrics, e.g. "mirror".
+	Name string
+	// Workers is the number of goroutines running tasks.
+	Workers int
+	// QueueDepth is how many tasks may wait for a worker before
+	// SubmitDetached rejects new ones.
+	QueueDepth int
+}
+
+// Task is a unit of work. The context carries the submitter's trace and
+// cancellation.
+type Task func(ctx context.Context) error
+
+type job struct {
+	ctx      context.Context
+	name     string
+	task     Task
+	queuedAt time.Time
+}
+
+// Pool runs tasks on a fixed number of goroutines, so fan-out stays bounded
+// under load. Each task gets its own span, and a panicking task is recovered
+// and reported as an error without taking its worker down.
+type Pool struct {
+	cfg    Config
+	tracer trace.Tracer
+	jobs   chan job
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+
+	tasks     metric.Int64Counter
+	queued    metric.Int64UpDownCounter
+	queueWait metric.Float64Histogram
+	rejected  metric.Int64Counter
+}
+
+func New(cfg Config, tracer trace.Tracer) (*Pool, error) {
+	if cfg.Workers < 1 {
+		return nil, fmt.Errorf("worker pool %q needs at least one worker", cfg.Name)
+	}
+	if cfg.QueueDepth < 0 {
+		return nil, fmt.Errorf("worker pool %q queue depth must not be negative", cfg.Name)
+	}
+
+	meter := otel.Meter("microservice-meter")
+
+	tasks, err := meter.Int64Counter("workerpool.tasks",
+		metric.WithDescription("Tasks finished by worker pools, by pool and result"))
+	if err != nil {
+		log.Printf("failed to create worker pool tasks counter: %v", err)
+	}
+
+	queued, err := meter.Int64UpDownCounter("workerpool.queued",
+		metric.WithDescription("Tasks waiting for a worker, by pool"))
+	if err != nil {
+		log.Printf("failed to create worker pool queue counter: %v", err)
+	}
+
+	queueWait, err := meter.Float64Histogram("workerpool.queue.wait",
+		metric.WithDescription("Time tasks waited for a worker, by pool"),
+		metric.WithUnit("ms"))
+	if err != nil {
+		log.Printf("failed to create worker pool queue wait histogram: %v", err)
+	}
+
+	rejected, err := meter.Int64Counter("workerpool.rejected",
+		metric.WithDescription("Tasks rejected because the queue was full, by pool"))
+	if err != nil {
+		log.Printf("failed to create worker pool rejected counter: %v", err)
+	}
+
+	p := &Pool{
+		cfg:       cfg,
+		tracer:    tracer,
+		jobs:      make(chan job, cfg.QueueDepth),
+		tasks:     tasks,
+		queued:    queued,
+		queueWait: queueWait,
+		rejected:  rejected,
+	}
+
+	p.wg.Add(cfg.Workers)
+	for i := 0; i < cfg.Workers; i++ {
+		go p.work()
+	}
+
+	return p, nil
+}
+
+// SubmitDetached queues follow-up work the caller does not wait for, without
+// blocking. It returns ErrQueueFull when every worker is busy and the queue
+// is at capacity, so callers can shed load. The task span starts a new trace
+// linked to the span in ctx, so the work is not counted in the latency of the
+// request that queued it, and the task is not cancelled when that request
+// ends.
+func (p *Pool) SubmitDetached(ctx context.Context, name string, task Task) error {
+	return p.enqueue(job{ctx: telemetry.Detach(ctx), name: name, task: task})
+}
+
+func (p *Pool) enqueue(j job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+
+	j.queuedAt = time.Now()
+	select {
+	case p.jobs <- j:
+		p.addQueued(j.ctx, 1)
+		return nil
+	default:
+		if p.rejected != nil {
+			p.rejected.Add(context.WithoutCancel(j.ctx), 1, metric.WithAttributes(attribute.String("workerpool.name", p.cfg.Name)))
+		}
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting tasks and waits for the queued ones to finish, or
+// for ctx to be done.
+func (p *Pool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	for j := range p.jobs {
+		p.addQueued(j.ctx, -1)
+		p.run(j)
+	}
+}
+
+func (p *Pool) run(j job) {
+	wait := time.Since(j.queuedAt)
+	opts := append([]trace.SpanStartOption{trace.WithAttributes(
+		attribute.String("workerpool.name", p.cfg.Name),
+		attribute.String("workerpool.task", j.name),
+		attribute.Int64("workerpool.queue_wait_ms", wait.Milliseconds()),
+	)}, telemetry.FollowUp(j.ctx)...)
+	ctx, span := p.tracer.Start(j.ctx, "workerpool.task", opts...)
+	defer span.End()
+
+	if p.queueWait != nil {
+		p.queueWait.Record(context.WithoutCancel(ctx), float64(wait)/float64(time.Millisecond),
+			metric.WithAttributes(attribute.String("workerpool.name", p.cfg.Name)))
+	}
+
+	result := "ok"
+	err := p.call(ctx, j.task)
+
+	var panicErr *PanicError
+	switch {
+	case errors.As(err, &panicErr):
+		result = "panic"
+		log.Printf("worker pool %s: task %s panicked: %v\n%s", p.cfg.Name, j.name, panicErr.Value, panicErr.Stack)
+	case err != nil:
+		result = "error"
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	if p.tasks != nil {
+		p.tasks.Add(context.WithoutCancel(ctx), 1, metric.WithAttributes(
+			attribute.String("workerpool.name", p.cfg.Name),
+			attribute.String("result", result),
+		))
+	}
+}
+
+// PanicError is the error a task's panic is converted into.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("task panicked: %v", e.Value)
+}
+
+func (p *Pool) call(ctx context.Context, task Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	return task(ctx)
+}
+
+func (p *Pool) addQueued(ctx context.Context, delta int64) {
+	if p.queued != nil {
+		p.queued.Add(context.WithoutCancel(ctx), delta, metric.WithAttributes(attribute.String("workerpool.name", p.cfg.Name)))
+	}
+}
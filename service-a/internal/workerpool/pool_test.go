@@ -0,0 +1,131 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/tracetesting"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestPool(t *testing.T, cfg Config) (*Pool, *tracetest.InMemoryExporter, *sdktrace.TracerProvider) {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+
+	pool, err := New(cfg, tp.Tracer("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pool, exporter, tp
+}
+
+func closePool(t *testing.T, pool *Pool) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := pool.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+func TestSubmitDetachedLinksTheSubmitter(t *testing.T) {
+	pool, exporter, tp := newTestPool(t, Config{Name: "mirror", Workers: 1, QueueDepth: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, parent := tp.Tracer("test").Start(ctx, "request")
+	var taskErr error
+	err := pool.SubmitDetached(ctx, "copy", func(ctx context.Context) error {
+		taskErr = ctx.Err()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The request ending must not cancel the task.
+	cancel()
+	parent.End()
+	closePool(t, pool)
+
+	if taskErr != nil {
+		t.Errorf("task context error = %v, want it detached from the request", taskErr)
+	}
+	spans := exporter.GetSpans()
+	task := tracetesting.FindSpan(t, spans, "workerpool.task")
+	request := tracetesting.FindSpan(t, spans, "request")
+	if task.SpanContext.TraceID() == request.SpanContext.TraceID() {
+		t.Error("task span is in the request's trace, want a trace of its own")
+	}
+	if len(task.Links) != 1 || task.Links[0].SpanContext.SpanID() != request.SpanContext.SpanID() {
+		t.Errorf("task span links = %v, want one link to the request span", task.Links)
+	}
+	tracetesting.AssertAttr(t, task, "workerpool.name", "mirror")
+	tracetesting.AssertAttr(t, task, "workerpool.task", "copy")
+}
+
+func TestSubmitDetachedRejectsWhenFull(t *testing.T) {
+	pool, _, _ := newTestPool(t, Config{Name: "test", Workers: 1, QueueDepth: 1})
+
+	started, release := make(chan struct{}), make(chan struct{})
+	blocking := func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}
+	if err := pool.SubmitDetached(context.Background(), "running", blocking); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	noop := func(context.Context) error { return nil }
+	if err := pool.SubmitDetached(context.Background(), "queued", noop); err != nil {
+		t.Fatalf("second task error = %v, want it queued", err)
+	}
+	if err := pool.SubmitDetached(context.Background(), "rejected", noop); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("third task error = %v, want ErrQueueFull", err)
+	}
+
+	close(release)
+	closePool(t, pool)
+	if err := pool.SubmitDetached(context.Background(), "late", noop); !errors.Is(err, ErrClosed) {
+		t.Errorf("task after Close error = %v, want ErrClosed", err)
+	}
+}
+
+func TestPanickingTaskKeepsTheWorker(t *testing.T) {
+	pool, exporter, _ := newTestPool(t, Config{Name: "test", Workers: 1, QueueDepth: 2})
+
+	ran := false
+	pool.SubmitDetached(context.Background(), "panics", func(context.Context) error { panic("boom") })
+	pool.SubmitDetached(context.Background(), "after", func(context.Context) error {
+		ran = true
+		return nil
+	})
+	closePool(t, pool)
+
+	if !ran {
+		t.Error("the task after the panic did not run")
+	}
+	spans := tracetesting.FindSpans(exporter.GetSpans(), "workerpool.task")
+	if len(spans) != 2 {
+		t.Fatalf("got %d task spans, want 2", len(spans))
+	}
+	tracetesting.AssertAttr(t, spans[0], "workerpool.task", "panics")
+	tracetesting.AssertStatus(t, spans[0], codes.Error)
+	tracetesting.AssertStatus(t, spans[1], codes.Unset)
+}
+
+func TestNewRejectsConfig(t *testing.T) {
+	for _, cfg := range []Config{{Name: "none", Workers: 0}, {Name: "negative", Workers: 1, QueueDepth: -1}} {
+		if _, err := New(cfg, nil); err == nil {
+			t.Errorf("New(%+v) error = nil, want the config rejected", cfg)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/callbudget"
+	"github.com/luis-olivetti/go-observability/pkg/platform/ipfilter"
+	"github.com/luis-olivetti/go-observability/pkg/platform/jwtauth"
+	"github.com/luis-olivetti/go-observability/pkg/platform/reqsign"
+	"github.com/luis-olivetti/go-observability/pkg/platform/slo"
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"github.com/luis-olivetti/go-observability/pkg/platform/tenant"
+	"github.com/luis-olivetti/go-observability/service-b/internal/config"
+	"github.com/luis-olivetti/go-observability/service-b/internal/handlers"
+	"github.com/luis-olivetti/go-observability/service-b/internal/middleware"
+)
+
+// The middleware chains of every router and route, outermost first. A router
+// chain runs before the chain of the route matched.
+var (
+	publicChain      = []string{middleware.Metrics, middleware.IPFilter, middleware.JWT, middleware.HMAC}
+	adminChain       = []string{middleware.IPFilter}
+	cityWeatherChain = []string{middleware.SLO, middleware.TenantMetrics, middleware.CallBudget}
+	// alertsChain leaves out the SLO, whose objectives cover the weather
+	// lookup only.
+	alertsChain = []string{middleware.TenantMetrics, middleware.CallBudget}
+)
+
+// newMiddlewares registers the middlewares configured in cfg.
+func newMiddlewares(cfg *config.Config, ipFilter *ipfilter.Filter, objectives *slo.Recorder) *middleware.Registry {
+	chains := middleware.NewRegistry(cfg.DisabledMiddlewares)
+
+	chains.Register(middleware.Metrics, telemetry.ActiveRequests())
+	if cfg.FilterIPs {
+		chains.Register(middleware.IPFilter, ipFilter.Middleware)
+	}
+	if cfg.JWT != nil {
+		chains.Register(middleware.JWT, jwtauth.New(*cfg.JWT).Middleware)
+	}
+	if cfg.HMACSecret != "" {
+		chains.Register(middleware.HMAC, reqsign.NewVerifier([]byte(cfg.HMACSecret), cfg.HMACReplayWindow).Middleware)
+	}
+
+	chains.Register(middleware.SLO, func(next http.Handler) http.Handler {
+		return objectives.Middleware(handlers.CityWeatherRoute, next)
+	})
+	chains.Register(middleware.TenantMetrics, tenant.NewMetrics().Middleware)
+	chains.Register(middleware.CallBudget, callbudget.Middleware(cfg.OutboundCallBudget))
+
+	return chains
+}
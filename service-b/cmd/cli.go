@@ -0,0 +1,230 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/chaos"
+	"github.com/luis-olivetti/go-observability/pkg/platform/configfile"
+	"github.com/luis-olivetti/go-observability/pkg/platform/ipfilter"
+	"github.com/luis-olivetti/go-observability/pkg/platform/redact"
+	"github.com/luis-olivetti/go-observability/service-b/internal/config"
+	"github.com/luis-olivetti/go-observability/service-b/internal/units"
+	"github.com/spf13/cobra"
+)
+
+const binaryName = "serviceb"
+
+// version is set at build time with -ldflags "-X main.version=<tag>".
+var version = "dev"
+
+// runCLI dispatches args to a subcommand; with no arguments the servers are
+// started, so existing entrypoints keep working.
+func runCLI(args []string) {
+	root := newRootCommand()
+	root.SetArgs(args)
+
+	if cmd, err := root.ExecuteC(); err != nil {
+		log.Fatalf("%s: %v", cmd.Name(), err)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	serve := newServeCommand()
+
+	root := &cobra.Command{
+		Use:           binaryName,
+		Short:         "Weather orchestration service (service B)",
+		Args:          cobra.NoArgs,
+		RunE:          serve.RunE,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	root.AddCommand(
+		serve,
+		newVersionCommand(),
+		newHealthcheckCommand(),
+		newValidateConfigCommand(),
+		newSmokeCommand(),
+	)
+
+	return root
+}
+
+// configFlags registers the flags that sit above the environment and the
+// config file, and returns a function that applies them once parsed.
+func configFlags(cmd *cobra.Command) func() error {
+	path := cmd.Flags().String("config", "", "config file (YAML, TOML or JSON); takes precedence over CONFIG_FILE")
+	settings := cmd.Flags().StringArray("set", nil, "setting overriding the environment and the config file, e.g. --set SAMPLING_RATIO=0.1 (repeatable)")
+
+	return func() error {
+		if *path != "" {
+			configfile.UseFile(*path)
+		}
+		for _, setting := range *settings {
+			key, value, ok := strings.Cut(setting, "=")
+			if !ok || key == "" {
+				return fmt.Errorf("setting must be in the form KEY=VALUE: %s", setting)
+			}
+			configfile.Override(key, value)
+		}
+		return nil
+	}
+}
+
+func newServeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the HTTP and admin servers (default)",
+		Args:  cobra.NoArgs,
+	}
+	applyConfigFlags := configFlags(cmd)
+	cmd.RunE = func(*cobra.Command, []string) error {
+		if err := applyConfigFlags(); err != nil {
+			return err
+		}
+		serve()
+		return nil
+	}
+
+	return cmd
+}
+
+func newSmokeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "smoke",
+		Short: "Run the post-deploy smoke check and exit",
+		Args:  cobra.NoArgs,
+		Run: func(*cobra.Command, []string) {
+			smoke()
+		},
+	}
+}
+
+func newVersionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the build version and exit",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			revision := revision()
+			if revision == "" {
+				revision = "unknown"
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s (revision %s, %s)\n", binaryName, version, revision, runtime.Version())
+		},
+	}
+}
+
+// revision returns the VCS revision the binary was built from, or "" when
+// the build did not record one.
+func revision() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return ""
+}
+
+// newHealthcheckCommand lets images without curl define a HEALTHCHECK: it
+// reads the admin port from the same configuration as the server and probes
+// /readyz.
+func newHealthcheckCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "healthcheck",
+		Short: "Query the local /readyz and exit 0 when ready",
+		Args:  cobra.NoArgs,
+	}
+	url := cmd.Flags().String("url", "", "readiness URL to probe (default: http://localhost:<ADMIN_PORT>/readyz)")
+	timeout := cmd.Flags().Duration("timeout", 2*time.Second, "how long to wait for the response")
+	applyConfigFlags := configFlags(cmd)
+
+	cmd.RunE = func(*cobra.Command, []string) error {
+		if err := applyConfigFlags(); err != nil {
+			return err
+		}
+
+		client := &http.Client{Timeout: *timeout}
+
+		target := *url
+		if target == "" {
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+
+			target = "http://localhost:" + cfg.AdminPort + "/readyz"
+			if cfg.AdminTLS.CertFile != "" {
+				// The certificate names the service, not localhost; the probe
+				// only cares that this process answers.
+				target = "https://localhost:" + cfg.AdminPort + "/readyz"
+				client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+			}
+		}
+
+		resp, err := client.Get(target)
+		if err != nil {
+			return fmt.Errorf("failed to reach %s: %w", target, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s answered %s", target, resp.Status)
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// newValidateConfigCommand loads the configuration and builds the components
+// that serve would reject it in, without opening listeners or upstream
+// connections.
+func newValidateConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate-config",
+		Short: "Load the configuration, report problems and exit",
+		Args:  cobra.NoArgs,
+	}
+	applyConfigFlags := configFlags(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error {
+		if err := applyConfigFlags(); err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if _, err := redact.NewScrubber(cfg.RedactPatterns, cfg.CEPs); err != nil {
+			return fmt.Errorf("invalid redact patterns: %w", err)
+		}
+		if cfg.Chaos != nil {
+			if _, err := chaos.New(*cfg.Chaos); err != nil {
+				return fmt.Errorf("invalid chaos settings: %w", err)
+			}
+		}
+		if _, err := ipfilter.New(cfg.IPFilter); err != nil {
+			return fmt.Errorf("invalid ip filter: %w", err)
+		}
+		if _, err := units.NewConverter(cfg.TemperaturePrecision, cfg.TemperatureRounding); err != nil {
+			return fmt.Errorf("invalid temperature settings: %w", err)
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), "configuration is valid")
+		return nil
+	}
+
+	return cmd
+}
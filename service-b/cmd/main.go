@@ -2,115 +2,104 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"log"
+	"log/slog"
 	"net/http"
-	neturl "net/url"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/spf13/viper"
+	"github.com/luis-olivetti/go-observability/pkg/platform/audit"
+	"github.com/luis-olivetti/go-observability/pkg/platform/chaos"
+	"github.com/luis-olivetti/go-observability/pkg/platform/errclass"
+	"github.com/luis-olivetti/go-observability/pkg/platform/featureflag"
+	"github.com/luis-olivetti/go-observability/pkg/platform/health"
+	"github.com/luis-olivetti/go-observability/pkg/platform/httpclient"
+	"github.com/luis-olivetti/go-observability/pkg/platform/ipfilter"
+	"github.com/luis-olivetti/go-observability/pkg/platform/logsample"
+	"github.com/luis-olivetti/go-observability/pkg/platform/messages"
+	"github.com/luis-olivetti/go-observability/pkg/platform/problem"
+	"github.com/luis-olivetti/go-observability/pkg/platform/redact"
+	"github.com/luis-olivetti/go-observability/pkg/platform/runtimelimits"
+	"github.com/luis-olivetti/go-observability/pkg/platform/sampling"
+	"github.com/luis-olivetti/go-observability/pkg/platform/slo"
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"github.com/luis-olivetti/go-observability/service-b/internal/clients"
+	"github.com/luis-olivetti/go-observability/service-b/internal/config"
+	"github.com/luis-olivetti/go-observability/service-b/internal/fixture"
+	"github.com/luis-olivetti/go-observability/service-b/internal/handlers"
+	"github.com/luis-olivetti/go-observability/service-b/internal/middleware"
+	"github.com/luis-olivetti/go-observability/service-b/internal/server"
+	"github.com/luis-olivetti/go-observability/service-b/internal/units"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
-type ViaCepError struct {
-	Erro interface{} `json:"erro"`
-}
+// newChaosInjector returns nil when chaos mode is off; it is meant for
+// staging game days only.
+func newChaosInjector(cfg *chaos.Config) (*chaos.Injector, error) {
+	if cfg == nil {
+		return nil, nil
+	}
 
-type ViaCep struct {
-	Cep         string `json:"cep"`
-	Logradouro  string `json:"logradouro"`
-	Complemento string `json:"complemento"`
-	Bairro      string `json:"bairro"`
-	Localidade  string `json:"localidade"`
-	Uf          string `json:"uf"`
-	Ibge        string `json:"ibge"`
-	Gia         string `json:"gia"`
-	Ddd         string `json:"ddd"`
-	Siafi       string `json:"siafi"`
-}
+	injector, err := chaos.New(*cfg)
+	if err != nil {
+		return nil, err
+	}
 
-type Weather struct {
-	Location struct {
-		Name           string  `json:"name"`
-		Region         string  `json:"region"`
-		Country        string  `json:"country"`
-		Lat            float64 `json:"lat"`
-		Lon            float64 `json:"lon"`
-		TzID           string  `json:"tz_id"`
-		LocaltimeEpoch int     `json:"localtime_epoch"`
-		Localtime      string  `json:"localtime"`
-	} `json:"location"`
-	Current struct {
-		TempC     float64 `json:"temp_c"`
-		Condition struct {
-		} `json:"condition"`
-	} `json:"current"`
+	log.Println("WARNING: chaos mode is enabled; upstream calls and spans will be disrupted")
+	return injector, nil
 }
 
-type TemperatureWithCity struct {
-	Celsius    float64 `json:"temp_C"`
-	Fahrenheit float64 `json:"temp_F"`
-	Kelvin     float64 `json:"temp_K"`
-	CityName   string  `json:"city"`
+// prewarm opens upstream connections before the instance reports ready, then
+// keeps them warm in the background.
+func prewarm(ctx context.Context, cfg httpclient.WarmConfig, client *http.Client, baseURL string) {
+	httpclient.Warm(ctx, client, baseURL, cfg)
+	go httpclient.KeepWarm(ctx, client, baseURL, cfg)
 }
 
-var tracer = otel.Tracer("microservice-tracer")
-
-func initProvider(serviceName, collectorUrl string) (func(context.Context) error, error) {
-	ctx := context.Background()
-
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceName(serviceName),
-		),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create resource: %w", err)
-	}
+// newRouter serves the weather and alerts routes through their chains.
+func newRouter(chains *middleware.Registry, cityWeather, alerts http.Handler) *mux.Router {
+	r := mux.NewRouter()
+	r.NotFoundHandler = http.HandlerFunc(problem.NotFound)
+	r.Use(chains.Resolve(publicChain...)...)
+	r.Handle(handlers.CityWeatherRoute, chains.Chain(cityWeather, cityWeatherChain...))
+	r.Handle(handlers.AlertsRoute, chains.Chain(alerts, alertsChain...))
 
-	conn, err := grpc.Dial(collectorUrl,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create grpc connection to collector: %w", err)
-	}
+	return r
+}
 
-	traceExporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithGRPCConn(conn))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+// applyTunables swaps in the sampling rules, feature flags, upstream
+// timeouts and alerts cache ttl of a reloaded config file. The span pipeline
+// is built at startup, so sampling cannot be switched on at runtime; removing
+// the rules keeps every trace.
+func applyTunables(cfg *config.Config, policy *sampling.Policy, flags *featureflag.StaticProvider, alerts *clients.CachedAlerts, tunables config.Tunables) {
+	flags.Set(tunables.Features)
+	cfg.ViaCEP.HTTP.Timeout.Set(tunables.ViaCEPTimeout)
+	cfg.Weather.HTTP.Timeout.Set(tunables.WeatherTimeout)
+	alerts.SetTTL(tunables.AlertsCacheTTL)
+
+	switch {
+	case policy == nil && tunables.Sampling != nil:
+		log.Println("sampling can only be enabled at startup; restart to apply SAMPLING_RATIO")
+	case policy != nil && tunables.Sampling == nil:
+		policy.Set(sampling.Config{Ratio: 1})
+	case policy != nil:
+		policy.Set(*tunables.Sampling)
 	}
-
-	bsp := sdktrace.NewBatchSpanProcessor(traceExporter)
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		sdktrace.WithResource(res),
-		sdktrace.WithSpanProcessor(bsp),
-	)
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.TraceContext{})
-
-	return tp.Shutdown, nil
 }
 
-func init() {
-	viper.AutomaticEnv()
+func main() {
+	runCLI(os.Args[1:])
 }
 
-func main() {
+// serve runs the service until SIGINT or SIGTERM.
+func serve() {
+	limits := runtimelimits.Apply()
+	log.Printf("runtime limits: %s", limits)
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
@@ -123,212 +112,188 @@ func main() {
 		cancel()
 	}()
 
-	shutdown, err := initProvider(viper.GetString("OTEL_SERVICE_NAME"), viper.GetString("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("failed to initialize provider: %v", err)
-	}
-	defer func() {
-		if err := shutdown(ctx); err != nil {
-			log.Fatalf("failed to shutdown TraceProvider: %v", err)
-		}
-	}()
-
-	r := mux.NewRouter()
-	r.HandleFunc("/city-weather", cityWeatherHandler)
-
-	srv := &http.Server{
-		Addr:         ":" + viper.GetString("HTTP_PORT"),
-		Handler:      r,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 5 * time.Second,
+		log.Fatalf("failed to load configuration: %v", err)
 	}
+	logsample.Default = logsample.New(cfg.LogSampling)
+	errclass.Default = errclass.NewMapping(cfg.ErrorStatuses)
+	messages.Default = messages.NewCatalog(cfg.Messages)
 
-	go func() {
-		log.Printf("Server started at http://localhost:%s\n", viper.GetString("HTTP_PORT"))
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Error starting server: %v\n", err)
-		}
-	}()
-
-	<-ctx.Done()
-
-	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancelShutdown()
-
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Fatalf("Server shutdown failed: %v\n", err)
-	}
-
-	log.Println("Server shutdown completed.")
-}
-
-func getViaCep(ctx context.Context, zipCode string, w http.ResponseWriter, r *http.Request) *ViaCep {
-	carrier := propagation.HeaderCarrier(r.Header)
-	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
-
-	ctx, span := tracer.Start(ctx, "getViaCep")
-	defer span.End()
-
-	url := fmt.Sprintf("http://viacep.com.br/ws/%s/json/", zipCode)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	scrubber, err := redact.NewScrubber(cfg.RedactPatterns, cfg.CEPs)
 	if err != nil {
-		span.RecordError(fmt.Errorf("failed to create request (viacep): %w", err))
-		http.Error(w, fmt.Sprintf("Failed to create request (viacep): %v", err), http.StatusInternalServerError)
-		return nil
+		log.Fatalf("failed to create span scrubber: %v", err)
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	injector, err := newChaosInjector(cfg.Chaos)
 	if err != nil {
-		span.RecordError(fmt.Errorf("failed to make HTTP request (viacep): %w", err))
-		http.Error(w, fmt.Sprintf("Failed to make HTTP request (viacep): %v", err), http.StatusInternalServerError)
-		return nil
+		log.Fatalf("failed to configure chaos mode: %v", err)
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		span.RecordError(fmt.Errorf("unexpected status code (viacep): %d", res.StatusCode))
-		log.Printf("Unexpected status code (viacep): %d", res.StatusCode)
-
-		http.Error(w, "Invalid zipcode", http.StatusUnprocessableEntity)
-		return nil
+	var policy *sampling.Policy
+	if cfg.Sampling != nil {
+		policy = sampling.NewPolicy(*cfg.Sampling)
 	}
 
-	var bodyBytes []byte
-	if bodyBytes, err = io.ReadAll(res.Body); err != nil {
-		span.RecordError(fmt.Errorf("failed to read response body: %w", err))
-		http.Error(w, "Failed to read response body: "+err.Error(), http.StatusInternalServerError)
-		return nil
+	metrics, err := telemetry.NewPrometheusExporter()
+	if err != nil {
+		log.Fatalf("failed to create metrics exporter: %v", err)
 	}
-
-	var viaCepErrorResponse ViaCepError
-	if err := json.Unmarshal(bodyBytes, &viaCepErrorResponse); err != nil {
-		span.RecordError(fmt.Errorf("failed to decode response (viacep): %w", err))
-		http.Error(w, "Failed to decode response (viacep): "+err.Error(), http.StatusInternalServerError)
-		return nil
+	shutdown, err := telemetry.Setup(ctx, telemetry.Config{
+		ServiceName:  cfg.ServiceName,
+		Exporter:     cfg.TracesExporter,
+		CollectorURL: cfg.CollectorURL,
+		Protocol:     cfg.OTLPProtocol,
+		Deployment: telemetry.Deployment{
+			Environment: cfg.DeploymentEnvironment,
+			Region:      cfg.Region,
+			Version:     version,
+			Revision:    revision(),
+		},
+		Attributes: limits.Attributes(),
+		LogLevel:   cfg.LogLevel,
+		Scrubber:   scrubber,
+		Injector:   injector,
+		Policy:     policy,
+		Metrics:    metrics,
+	})
+	if err != nil {
+		log.Fatalf("failed to initialize provider: %v", err)
 	}
+	defer func() {
+		// ctx is already cancelled here; flushing needs its own deadline.
+		flushCtx, cancel := context.WithTimeout(context.Background(), server.ShutdownTimeout)
+		defer cancel()
 
-	// Devido um bug no viacep, o campo erro pode ser uma string ou um boolean
-	var foundError bool
-	switch erro := viaCepErrorResponse.Erro.(type) {
-	case bool:
-		foundError = erro
-	case string:
-		foundError = erro == "true"
-	}
+		if err := shutdown(flushCtx); err != nil {
+			log.Fatalf("failed to shutdown TraceProvider: %v", err)
+		}
+	}()
 
-	if foundError {
-		span.RecordError(fmt.Errorf("cannot find zipcode"))
-		http.Error(w, "Cannot find zipcode", http.StatusNotFound)
-		return nil
-	}
+	tracer := otel.Tracer(telemetry.TracerName)
 
-	var viaCepResponse ViaCep
-	if err := json.Unmarshal(bodyBytes, &viaCepResponse); err != nil {
-		span.RecordError(fmt.Errorf("failed to decode response (viacep): %w", err))
-		http.Error(w, "Failed to decode response (viacep): "+err.Error(), http.StatusInternalServerError)
-		return nil
+	flags := featureflag.NewStaticProvider(cfg.Features)
+	features, err := featureflag.NewClient(cfg.ServiceName, flags)
+	if err != nil {
+		log.Fatalf("failed to set up feature flags: %v", err)
 	}
 
-	if viaCepResponse.Localidade == "" {
-		span.RecordError(fmt.Errorf("invalid zipcode"))
-		http.Error(w, "Invalid zipcode", http.StatusUnprocessableEntity)
-		return nil
-	}
+	auditLog := audit.NewLogger(cfg.AuditKey, audit.NewLogSink(slog.Default().Handler()))
 
-	return &viaCepResponse
-}
-
-func getWeather(ctx context.Context, cityName string, w http.ResponseWriter, r *http.Request) *Weather {
-	carrier := propagation.HeaderCarrier(r.Header)
-	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
-
-	ctx, span := tracer.Start(ctx, "getWeather")
-	defer span.End()
-
-	var response Weather
-
-	cityNameEncoded := neturl.QueryEscape(cityName)
-	url := fmt.Sprintf("http://api.weatherapi.com/v1/current.json?key=a91eb948a337442782b123810242601&q=%s", cityNameEncoded)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	viaCepClient, err := clients.NewHTTPClient("viacep", cfg.ViaCEP, cfg.FixtureMode, scrubber, injector)
 	if err != nil {
-		span.RecordError(fmt.Errorf("failed to create request (weather): %w", err))
-		http.Error(w, fmt.Sprintf("Failed to create request (weather): %v", err), http.StatusInternalServerError)
-		return nil
+		log.Fatalf("failed to create viacep client: %v", err)
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	weatherClient, err := clients.NewHTTPClient("weatherapi", cfg.Weather, cfg.FixtureMode, scrubber, injector)
 	if err != nil {
-		span.RecordError(fmt.Errorf("failed to make HTTP request (weather): %w", err))
-		http.Error(w, fmt.Sprintf("Failed to make HTTP request (weather): %v", err), http.StatusInternalServerError)
-		return nil
+		log.Fatalf("failed to create weather client: %v", err)
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		span.RecordError(fmt.Errorf("unexpected status code (weather): %d", res.StatusCode))
-		log.Printf("Unexpected status code (weather): %d", res.StatusCode)
-
-		http.Error(w, "Invalid zipcode", http.StatusUnprocessableEntity)
-		return nil
+	ipFilter, err := ipfilter.New(cfg.IPFilter)
+	if err != nil {
+		log.Fatalf("failed to create ip filter: %v", err)
 	}
 
-	err = json.NewDecoder(res.Body).Decode(&response)
+	converter, err := units.NewConverter(cfg.TemperaturePrecision, cfg.TemperatureRounding)
 	if err != nil {
-		span.RecordError(fmt.Errorf("failed to decode response (weather): %w", err))
-		http.Error(w, fmt.Sprintf("Failed to decode response (weather): %v", err), http.StatusInternalServerError)
-		return nil
+		log.Fatalf("failed to create temperature converter: %v", err)
 	}
 
-	return &response
-}
-
-func cityWeatherHandler(w http.ResponseWriter, r *http.Request) {
-	carrier := propagation.HeaderCarrier(r.Header)
-	ctx := r.Context()
-	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+	ceps := clients.NewViaCepResolver(viaCepClient, cfg.ViaCEP.BaseURL, tracer)
+	weatherAPI := clients.NewWeatherAPIProvider(weatherClient, cfg.Weather.BaseURL, cfg.WeatherAPIKey, tracer)
+	handler := handlers.NewCityWeatherHandler(ceps, weatherAPI, converter, features, tracer)
+	alerts := clients.NewCachedAlerts(weatherAPI, cfg.AlertsCacheTTL)
+	alertsHandler := handlers.NewAlertsHandler(ceps, alerts, features, tracer)
+
+	config.WatchFile(func(reload config.Reload) {
+		applyTunables(cfg, policy, flags, alerts, reload.Tunables)
+
+		err := auditLog.Record(ctx, audit.Event{
+			Actor:  "config-watcher",
+			Action: "config.reload",
+			Target: reload.Path,
+			Details: map[string]string{
+				"version":  strconv.FormatInt(reload.Version, 10),
+				"checksum": reload.Checksum,
+			},
+		})
+		if err != nil {
+			log.Printf("failed to audit config reload: %v", err)
+		}
+	})
 
-	ctx, span := tracer.Start(ctx, "cityWeatherHandler")
-	defer span.End()
+	objectives := slo.NewRecorder(cfg.SLO)
+	chains := newMiddlewares(cfg, ipFilter, objectives)
 
-	if !validParams(w, r) {
-		span.RecordError(fmt.Errorf("invalid parameters"))
-		return
-	}
+	r := newRouter(chains, handler, alertsHandler)
 
-	zipCode := r.URL.Query().Get("zipcode")
+	checker := health.NewChecker()
 
-	viacepReturn := getViaCep(ctx, zipCode, w, r)
-	if viacepReturn == nil {
-		span.RecordError(fmt.Errorf("failed to get viacep"))
-		return
+	// In replay mode fixtures stand in for the upstreams, which are never
+	// contacted.
+	if cfg.FixtureMode != fixture.ModeReplay {
+		startupChecks := []health.StartupCheck{
+			health.DNSCheck("viacep", cfg.ViaCEP.BaseURL),
+			health.DNSCheck("weather", cfg.Weather.BaseURL),
+		}
+		if cfg.StartupUpstreamCheck {
+			startupChecks = append(startupChecks,
+				health.UpstreamCheck("viacep", viaCepClient, cfg.ViaCEP.BaseURL),
+				health.UpstreamCheck("weather", weatherClient, cfg.Weather.BaseURL),
+			)
+		}
+		checker.RunStartupChecks(ctx, startupChecks...)
 	}
 
-	cityName := viacepReturn.Localidade
-
-	weatherReturn := getWeather(ctx, cityName, w, r)
-	if weatherReturn == nil {
-		span.RecordError(fmt.Errorf("failed to get weather"))
-		return
+	admin := mux.NewRouter()
+	admin.Use(chains.Resolve(adminChain...)...)
+	admin.HandleFunc("/healthz", checker.Liveness)
+	admin.HandleFunc("/readyz", checker.Readiness)
+	admin.HandleFunc("/startupz", checker.Startup)
+	admin.Handle("/metrics", metrics)
+	admin.HandleFunc("/admin/slo", objectives.StatusHandler)
+
+	// The weather and alerts endpoints only serve service A, so they move to
+	// the internal listener when one is configured.
+	api := server.Listener{
+		Name: server.Public,
+		Server: &http.Server{
+			Addr:         ":" + cfg.HTTPPort,
+			Handler:      r,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 5 * time.Second,
+		},
+		CertFile: cfg.PublicTLS.CertFile,
+		KeyFile:  cfg.PublicTLS.KeyFile,
+	}
+	if cfg.InternalPort != "" {
+		api.Name = server.Internal
+		api.Server.Addr = ":" + cfg.InternalPort
+		api.CertFile, api.KeyFile = cfg.InternalTLS.CertFile, cfg.InternalTLS.KeyFile
 	}
 
-	temperatureWithCity := TemperatureWithCity{
-		Celsius:    weatherReturn.Current.TempC,
-		Fahrenheit: (weatherReturn.Current.TempC * 9 / 5) + 32,
-		Kelvin:     weatherReturn.Current.TempC + 273.15,
-		CityName:   cityName,
+	adminListener := server.Listener{
+		Name: server.Admin,
+		Server: &http.Server{
+			Addr:         ":" + cfg.AdminPort,
+			Handler:      admin,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: cfg.AdminWriteTimeout,
+		},
+		CertFile: cfg.AdminTLS.CertFile,
+		KeyFile:  cfg.AdminTLS.KeyFile,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(temperatureWithCity)
-}
+	// Warm-up requests would be recorded or fail to replay in fixture mode.
+	if cfg.Prewarm != nil && cfg.FixtureMode == fixture.ModeOff {
+		prewarm(ctx, *cfg.Prewarm, viaCepClient, cfg.ViaCEP.BaseURL)
+		prewarm(ctx, *cfg.Prewarm, weatherClient, cfg.Weather.BaseURL)
+	}
 
-func validParams(w http.ResponseWriter, r *http.Request) bool {
-	if r.URL.Query().Get("zipcode") == "" {
-		http.Error(w, "Missing 'zipcode' parameter", http.StatusBadRequest)
-		return false
+	if err := server.Run(ctx, checker, api, adminListener); err != nil {
+		log.Fatal(err)
 	}
 
-	return true
+	log.Println("Server shutdown completed.")
 }
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+	"github.com/luis-olivetti/go-observability/pkg/platform/configfile"
+	"github.com/luis-olivetti/go-observability/pkg/platform/featureflag"
+	"github.com/luis-olivetti/go-observability/pkg/platform/metrictesting"
+	"github.com/luis-olivetti/go-observability/service-b/internal/clients"
+	"github.com/luis-olivetti/go-observability/service-b/internal/config"
+	"github.com/luis-olivetti/go-observability/service-b/internal/fixture"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitForReload returns the next reload applied, failing after a few seconds.
+func waitForReload(t *testing.T, reloads <-chan config.Reload) config.Reload {
+	t.Helper()
+
+	select {
+	case reload := <-reloads:
+		return reload
+	case <-time.After(5 * time.Second):
+		t.Fatal("config file change was not applied")
+		return config.Reload{}
+	}
+}
+
+// waitForRejection waits until the watcher has counted a rejected reload.
+func waitForRejection(t *testing.T, metrics *metrictesting.Reader) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		rm, err := metrics.Collect(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rejected, _ := metrictesting.Sum(rm, "config.reloads", attribute.String("result", "rejected")); rejected == 1 {
+			return
+		}
+	}
+	t.Fatal("invalid config file change was not rejected")
+}
+
+func TestReloadAppliesTunables(t *testing.T) {
+	metrics := metrictesting.Install()
+	t.Cleanup(func() { metrics.Shutdown(context.Background()) })
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "WEATHER_TIMEOUT: 1s\nALERTS_CACHE_TTL: 5m\n")
+	t.Setenv("APP_PROFILE", "dev")
+	configfile.UseFile(path)
+	t.Cleanup(func() { configfile.UseFile("") })
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	t.Cleanup(slow.Close)
+	weatherClient, err := clients.NewHTTPClient("weatherapi", cfg.Weather, fixture.ModeOff, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func() error {
+		resp, err := weatherClient.Get(slow.URL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	var sourceCalls int
+	source := alertSourceFunc(func(context.Context, string) ([]clients.WeatherAlert, error) {
+		sourceCalls++
+		return nil, nil
+	})
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	alerts := clients.NewCachedAlerts(source, cfg.AlertsCacheTTL)
+	alerts.Clock = clk
+
+	reloads := make(chan config.Reload, 4)
+	config.WatchFile(func(reload config.Reload) {
+		applyTunables(cfg, nil, featureflag.NewStaticProvider(nil), alerts, reload.Tunables)
+		reloads <- reload
+	})
+
+	if err := call(); err != nil {
+		t.Fatalf("call within the 1s timeout failed: %v", err)
+	}
+	alerts.Alerts(context.Background(), "Linhares")
+	clk.Advance(2 * time.Minute)
+
+	// A negative timeout is rejected and the previous settings stay.
+	writeConfig(t, path, "WEATHER_TIMEOUT: -1s\nALERTS_CACHE_TTL: 1m\n")
+	waitForRejection(t, metrics)
+	if got := cfg.Weather.HTTP.Timeout.Get(); got != time.Second {
+		t.Fatalf("timeout after a rejected reload = %s, want 1s", got)
+	}
+
+	writeConfig(t, path, "WEATHER_TIMEOUT: 50ms\nALERTS_CACHE_TTL: 1m\n")
+	reload := waitForReload(t, reloads)
+
+	if reload.Version != 2 {
+		t.Errorf("version = %d, want 2", reload.Version)
+	}
+	if reload.Tunables.WeatherTimeout != 50*time.Millisecond || reload.Tunables.AlertsCacheTTL != time.Minute {
+		t.Errorf("tunables = %+v, want the file's", reload.Tunables)
+	}
+	if err := call(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("call past the reloaded 50ms timeout: error = %v, want a deadline", err)
+	}
+	alerts.Alerts(context.Background(), "Linhares")
+	if sourceCalls != 2 {
+		t.Errorf("alert source called %d times, want the entry expired by the reloaded 1m ttl", sourceCalls)
+	}
+}
+
+// alertSourceFunc adapts a function to clients.AlertSource.
+type alertSourceFunc func(ctx context.Context, cityName string) ([]clients.WeatherAlert, error)
+
+func (f alertSourceFunc) Alerts(ctx context.Context, cityName string) ([]clients.WeatherAlert, error) {
+	return f(ctx, cityName)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/metrictesting"
+	"github.com/luis-olivetti/go-observability/pkg/platform/redact"
+	"github.com/luis-olivetti/go-observability/pkg/platform/slo"
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"github.com/luis-olivetti/go-observability/pkg/platform/tracetesting"
+	"github.com/luis-olivetti/go-observability/service-b/internal/clients"
+	"github.com/luis-olivetti/go-observability/service-b/internal/config"
+	"github.com/luis-olivetti/go-observability/service-b/internal/fixture"
+	"github.com/luis-olivetti/go-observability/service-b/internal/handlers"
+	"github.com/luis-olivetti/go-observability/service-b/internal/units"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// callerTrace is the trace context service A would propagate.
+const (
+	callerTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	callerSpanID  = "00f067aa0ba902b7"
+)
+
+// upstreamServer answers ViaCEP and WeatherAPI requests; weatherStatus is
+// what current.json answers with.
+func upstreamServer(t *testing.T, weatherStatus int) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/29902555/json/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"cep": "29902-555", "localidade": "Linhares", "uf": "ES"}`)
+	})
+	mux.HandleFunc("/v1/current.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(weatherStatus)
+		fmt.Fprint(w, `{"location": {"name": "Linhares"}, "current": {"temp_c": 25}}`)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestRouter wires the real handlers, clients and middleware chains the
+// way serve does, against upstream, recording spans in memory.
+func newTestRouter(t *testing.T, upstream string) (http.Handler, *tracetest.InMemoryExporter) {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	scrubber, err := redact.NewScrubber(nil, redact.DefaultCEPPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp := telemetry.NewTracerProvider(sdktrace.NewSimpleSpanProcessor(exporter), scrubber, nil)
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+	telemetry.Install(tp)
+	tracer := tp.Tracer(telemetry.TracerName)
+
+	cfg := &config.Config{
+		ViaCEP:  config.Upstream{BaseURL: upstream},
+		Weather: config.Upstream{BaseURL: upstream},
+	}
+	viaCepClient, err := clients.NewHTTPClient("viacep", cfg.ViaCEP, fixture.ModeOff, scrubber, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weatherClient, err := clients.NewHTTPClient("weatherapi", cfg.Weather, fixture.ModeOff, scrubber, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	converter, err := units.NewConverter(units.DefaultPrecision, units.DefaultRounding)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ceps := clients.NewViaCepResolver(viaCepClient, cfg.ViaCEP.BaseURL, tracer)
+	weatherAPI := clients.NewWeatherAPIProvider(weatherClient, cfg.Weather.BaseURL, "key", tracer)
+	chains := newMiddlewares(cfg, nil, slo.NewRecorder(cfg.SLO))
+
+	return newRouter(chains,
+		handlers.NewCityWeatherHandler(ceps, weatherAPI, converter, nil, tracer),
+		handlers.NewAlertsHandler(ceps, clients.NewCachedAlerts(weatherAPI, 0), nil, tracer),
+	), exporter
+}
+
+func TestCityWeatherSpans(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		weatherStatus int
+		wantStatus    int
+		// wantChildren are the client spans expected under the server span.
+		wantChildren []string
+		wantCode     codes.Code
+		wantErrType  string
+	}{
+		{
+			name:          "success",
+			query:         "?zipcode=29902555",
+			weatherStatus: http.StatusOK,
+			wantStatus:    http.StatusOK,
+			wantChildren:  []string{"getViaCep", "getWeather"},
+			wantCode:      codes.Unset,
+		},
+		{
+			name:          "weather upstream failing",
+			query:         "?zipcode=29902555",
+			weatherStatus: http.StatusInternalServerError,
+			wantStatus:    http.StatusBadGateway,
+			wantChildren:  []string{"getViaCep", "getWeather"},
+			wantCode:      codes.Error,
+			wantErrType:   "UPSTREAM_ERROR",
+		},
+		{
+			name:          "invalid zipcode",
+			query:         "?zipcode=123",
+			weatherStatus: http.StatusOK,
+			wantStatus:    http.StatusUnprocessableEntity,
+			wantCode:      codes.Error,
+			wantErrType:   "ZIPCODE_INVALID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := metrictesting.Install()
+			t.Cleanup(func() { metrics.Shutdown(context.Background()) })
+			router, exporter := newTestRouter(t, upstreamServer(t, tt.weatherStatus).URL)
+
+			req := httptest.NewRequest(http.MethodGet, handlers.CityWeatherRoute+tt.query, nil)
+			req.Header.Set("traceparent", "00-"+callerTraceID+"-"+callerSpanID+"-01")
+			req.Header.Set("baggage", "tenant.id=acme,client.id=mobile")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			metrics.AssertHistogramCount(t, "http.server.request.duration", 1,
+				attribute.String("http.route", handlers.CityWeatherRoute),
+				attribute.Int("http.response.status_code", tt.wantStatus))
+			caller := []attribute.KeyValue{
+				attribute.String("tenant.id", "acme"),
+				attribute.String("client.id", "mobile"),
+				attribute.Int("http.status_code", tt.wantStatus),
+			}
+			metrics.AssertSum(t, "tenant.requests", 1, caller...)
+			metrics.AssertHistogramCount(t, "tenant.request.duration", 1, caller...)
+
+			spans := exporter.GetSpans()
+			server := tracetesting.FindSpan(t, spans, "GET "+handlers.CityWeatherRoute)
+			if server.SpanKind != trace.SpanKindServer {
+				t.Errorf("server span kind = %v, want server", server.SpanKind)
+			}
+			if got := server.SpanContext.TraceID().String(); got != callerTraceID {
+				t.Errorf("trace id = %s, want the caller's %s", got, callerTraceID)
+			}
+			if got := server.Parent.SpanID().String(); got != callerSpanID || !server.Parent.IsRemote() {
+				t.Errorf("server span parent = %s (remote %v), want the caller's %s", got, server.Parent.IsRemote(), callerSpanID)
+			}
+			tracetesting.AssertAttr(t, server, "http.route", handlers.CityWeatherRoute)
+			tracetesting.AssertStatus(t, server, tt.wantCode)
+			if tt.wantErrType != "" {
+				tracetesting.AssertAttr(t, server, "error.type", tt.wantErrType)
+			} else {
+				tracetesting.AssertNoAttr(t, server, "error.type")
+			}
+
+			if len(spans) != 1+len(tt.wantChildren) {
+				t.Errorf("got %d spans, want %d", len(spans), 1+len(tt.wantChildren))
+			}
+			for _, name := range tt.wantChildren {
+				tracetesting.AssertChildOf(t, tracetesting.FindSpan(t, spans, name), server)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/metrictesting"
+	"github.com/luis-olivetti/go-observability/pkg/platform/redact"
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"github.com/luis-olivetti/go-observability/pkg/platform/tenant"
+	"github.com/luis-olivetti/go-observability/service-b/internal/clients"
+	"github.com/luis-olivetti/go-observability/service-b/internal/handlers"
+	"github.com/luis-olivetti/go-observability/service-b/internal/units"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const smokeZipcode = "29902555"
+
+// smokeUpstreams answers the ViaCEP and WeatherAPI routes with canned
+// payloads so the smoke check never leaves the process.
+func smokeUpstreams() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/"+smokeZipcode+"/json/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"cep":"29902-555","logradouro":"Rua Ozias Gomes","bairro":"Vila Nova","localidade":"Linhares","uf":"ES"}`)
+	})
+	mux.HandleFunc("/v1/current.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") != "Linhares" {
+			http.Error(w, "unexpected city", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"location":{"name":"Linhares"},"current":{"temp_c":25,"condition":{"text":"Sunny","code":1000}}}`)
+	})
+	return mux
+}
+
+// runSmoke sends a canned request through the real handler stack, backed by
+// in-process upstreams and an in-memory span exporter, and returns an error
+// when the response or the emitted spans are not what a healthy build
+// produces.
+func runSmoke() error {
+	exporter := tracetest.NewInMemoryExporter()
+	scrubber, err := redact.NewScrubber(nil, redact.DefaultCEPPolicy)
+	if err != nil {
+		return fmt.Errorf("failed to create span scrubber: %w", err)
+	}
+
+	tp := telemetry.NewTracerProvider(sdktrace.NewSimpleSpanProcessor(exporter), scrubber, nil)
+	defer tp.Shutdown(context.Background())
+	telemetry.Install(tp)
+	tracer := tp.Tracer(telemetry.TracerName)
+
+	metrics := metrictesting.Install()
+	defer metrics.Shutdown(context.Background())
+
+	upstreams := httptest.NewServer(smokeUpstreams())
+	defer upstreams.Close()
+
+	converter, err := units.NewConverter(units.DefaultPrecision, units.DefaultRounding)
+	if err != nil {
+		return err
+	}
+
+	handler := tenant.NewMetrics().Middleware(handlers.NewCityWeatherHandler(
+		clients.NewViaCepResolver(upstreams.Client(), upstreams.URL, tracer),
+		clients.NewWeatherAPIProvider(upstreams.Client(), upstreams.URL, "smoke", tracer),
+		converter,
+		nil,
+		tracer,
+	))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/city-weather?zipcode="+smokeZipcode+"&include=conditions", nil))
+
+	if rec.Code != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", rec.Code, strings.TrimSpace(rec.Body.String()))
+	}
+
+	var body contracts.TemperatureWithCity
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	want := contracts.TemperatureWithCity{Celsius: 25, Fahrenheit: 77, Kelvin: 298.15, CityName: "Linhares"}
+	if body.Conditions == nil || body.Conditions.Text != "Sunny" {
+		return fmt.Errorf("missing conditions in response: %+v", body)
+	}
+	body.Conditions = nil
+	if body != want {
+		return fmt.Errorf("unexpected response %+v, want %+v", body, want)
+	}
+
+	if err := checkSmokeSpans(exporter.GetSpans(), "GET "+handlers.CityWeatherRoute, "getViaCep", "getWeather"); err != nil {
+		return err
+	}
+
+	return checkSmokeMetrics(metrics)
+}
+
+// checkSmokeMetrics verifies that the request was counted and timed by the
+// tenant metrics middleware.
+func checkSmokeMetrics(reader *metrictesting.Reader) error {
+	rm, err := reader.Collect(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to collect metrics: %w", err)
+	}
+
+	status := attribute.Int("http.status_code", http.StatusOK)
+	if count, _ := metrictesting.Sum(rm, "tenant.requests", status); count != 1 {
+		return fmt.Errorf("tenant.requests recorded %v requests, want 1", count)
+	}
+	if count, _ := metrictesting.HistogramCount(rm, "tenant.request.duration", status); count != 1 {
+		return fmt.Errorf("tenant.request.duration recorded %d measurements, want 1", count)
+	}
+
+	return nil
+}
+
+// checkSmokeSpans verifies that root and each of children were emitted once,
+// in a single trace, with children directly under root and no error status.
+func checkSmokeSpans(spans tracetest.SpanStubs, root string, children ...string) error {
+	byName := make(map[string]tracetest.SpanStub)
+	for _, span := range spans {
+		if _, dup := byName[span.Name]; dup {
+			return fmt.Errorf("span %q emitted more than once", span.Name)
+		}
+		byName[span.Name] = span
+	}
+
+	parent, ok := byName[root]
+	if !ok {
+		return fmt.Errorf("span %q not emitted", root)
+	}
+
+	for _, name := range append([]string{root}, children...) {
+		span, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("span %q not emitted", name)
+		}
+		if span.Status.Code == codes.Error {
+			return fmt.Errorf("span %q has error status: %s", name, span.Status.Description)
+		}
+		if name == root {
+			continue
+		}
+		if span.SpanContext.TraceID() != parent.SpanContext.TraceID() || span.Parent.SpanID() != parent.SpanContext.SpanID() {
+			return fmt.Errorf("span %q is not a child of %q", name, root)
+		}
+	}
+
+	return nil
+}
+
+func smoke() {
+	if err := runSmoke(); err != nil {
+		log.Fatalf("smoke check failed: %v", err)
+	}
+	log.Println("smoke check passed")
+}
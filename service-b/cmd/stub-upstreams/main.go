@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/luis-olivetti/go-observability/pkg/platform/cep"
+	"github.com/spf13/viper"
+)
+
+// Behavior controls the faults injected into one stubbed upstream.
+type Behavior struct {
+	ErrorRate   float64 `json:"error_rate"`
+	ErrorStatus int     `json:"error_status"`
+	Latency     string  `json:"latency"`
+	Jitter      string  `json:"jitter"`
+}
+
+type address struct {
+	Cep        string `json:"cep"`
+	Logradouro string `json:"logradouro"`
+	Bairro     string `json:"bairro"`
+	Localidade string `json:"localidade"`
+	Uf         string `json:"uf"`
+	Ibge       string `json:"ibge"`
+	Ddd        string `json:"ddd"`
+}
+
+type cityWeather struct {
+	Region    string
+	TempC     float64
+	Condition string
+	Code      int
+	Humidity  int
+	WindKph   float64
+	WindDir   string
+}
+
+var addresses = map[string]address{
+	"29902555": {Cep: "29902-555", Logradouro: "Rua Ozias Gomes", Bairro: "Vila Nova", Localidade: "Linhares", Uf: "ES", Ibge: "3203205", Ddd: "27"},
+	"01001000": {Cep: "01001-000", Logradouro: "Praça da Sé", Bairro: "Sé", Localidade: "São Paulo", Uf: "SP", Ibge: "3550308", Ddd: "11"},
+	"20040020": {Cep: "20040-020", Logradouro: "Avenida Rio Branco", Bairro: "Centro", Localidade: "Rio de Janeiro", Uf: "RJ", Ibge: "3304557", Ddd: "21"},
+	"89010025": {Cep: "89010-025", Logradouro: "Rua Doutor Luiz de Freitas Melro", Bairro: "Centro", Localidade: "Blumenau", Uf: "SC", Ibge: "4202404", Ddd: "47"},
+}
+
+var weather = map[string]cityWeather{
+	"Linhares":       {Region: "Espirito Santo", TempC: 29.4, Condition: "Sunny", Code: 1000, Humidity: 62, WindKph: 13.0, WindDir: "ENE"},
+	"São Paulo":      {Region: "Sao Paulo", TempC: 21.0, Condition: "Partly cloudy", Code: 1003, Humidity: 73, WindKph: 9.4, WindDir: "SSE"},
+	"Rio de Janeiro": {Region: "Rio de Janeiro", TempC: 27.0, Condition: "Patchy rain possible", Code: 1063, Humidity: 78, WindKph: 15.1, WindDir: "S"},
+	"Blumenau":       {Region: "Santa Catarina", TempC: 18.2, Condition: "Overcast", Code: 1009, Humidity: 88, WindKph: 5.8, WindDir: "W"},
+}
+
+// alerts are the alerts.json entries of the cities with an alert in effect;
+// the other cities have none.
+var alerts = map[string][]map[string]any{
+	"Blumenau": {{
+		"headline":    "Tempestade - Perigo Potencial",
+		"msgtype":     "Alert",
+		"severity":    "Moderate",
+		"urgency":     "Immediate",
+		"areas":       "Vale do Itajaí",
+		"category":    "Met",
+		"certainty":   "Likely",
+		"event":       "Tempestade",
+		"effective":   "2026-10-16T10:00:00-03:00",
+		"expires":     "2026-10-17T10:00:00-03:00",
+		"desc":        "Chuva entre 20 e 30 mm/h ou até 50 mm/dia, ventos intensos (40-60 km/h).",
+		"instruction": "Em caso de rajadas de vento, não se abrigue debaixo de árvores.",
+	}},
+}
+
+type stub struct {
+	mu        sync.RWMutex
+	behaviors map[string]Behavior
+	defaults  map[string]Behavior
+}
+
+func newStub(defaults map[string]Behavior) *stub {
+	s := &stub{defaults: defaults}
+	s.reset()
+	return s
+}
+
+func (s *stub) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.behaviors = make(map[string]Behavior, len(s.defaults))
+	for name, behavior := range s.defaults {
+		s.behaviors[name] = behavior
+	}
+}
+
+func (s *stub) behavior(name string) Behavior {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.behaviors[name]
+}
+
+// inject applies the configured latency and, with the configured probability,
+// writes the error response. It reports whether the request was handled.
+func (s *stub) inject(w http.ResponseWriter, r *http.Request, name string) bool {
+	behavior := s.behavior(name)
+
+	delay, _ := time.ParseDuration(behavior.Latency)
+	if jitter, _ := time.ParseDuration(behavior.Jitter); jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(jitter)))
+	}
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+
+	if behavior.ErrorRate > 0 && rand.Float64() < behavior.ErrorRate {
+		status := behavior.ErrorStatus
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		http.Error(w, http.StatusText(status), status)
+		return true
+	}
+
+	return false
+}
+
+func (s *stub) viaCepHandler(w http.ResponseWriter, r *http.Request) {
+	if s.inject(w, r, "viacep") {
+		return
+	}
+
+	zipcode := mux.Vars(r)["cep"]
+	// Like ViaCEP, only the canonical form is accepted in the path.
+	if len(zipcode) != 8 || !cep.Valid(zipcode) {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if addr, ok := addresses[zipcode]; ok {
+		json.NewEncoder(w).Encode(addr)
+		return
+	}
+	fmt.Fprint(w, `{"erro": true}`)
+}
+
+func (s *stub) weatherHandler(w http.ResponseWriter, r *http.Request) {
+	if s.inject(w, r, "weather") {
+		return
+	}
+
+	if r.URL.Query().Get("key") == "" {
+		writeWeatherError(w, http.StatusUnauthorized, 1002, "API key is invalid or not provided.")
+		return
+	}
+
+	city := r.URL.Query().Get("q")
+	current, ok := weather[city]
+	if !ok {
+		writeWeatherError(w, http.StatusBadRequest, 1006, "No matching location found.")
+		return
+	}
+
+	now := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"location": map[string]any{
+			"name":            city,
+			"region":          current.Region,
+			"country":         "Brazil",
+			"tz_id":           "America/Sao_Paulo",
+			"localtime_epoch": now.Unix(),
+			"localtime":       now.Format("2006-01-02 15:04"),
+		},
+		"current": map[string]any{
+			"temp_c": current.TempC,
+			"condition": map[string]any{
+				"text": current.Condition,
+				"icon": fmt.Sprintf("//cdn.weatherapi.com/weather/64x64/day/%d.png", current.Code),
+				"code": current.Code,
+			},
+			"wind_kph":    current.WindKph,
+			"wind_dir":    current.WindDir,
+			"humidity":    current.Humidity,
+			"feelslike_c": current.TempC,
+		},
+	})
+}
+
+func (s *stub) alertsHandler(w http.ResponseWriter, r *http.Request) {
+	if s.inject(w, r, "weather") {
+		return
+	}
+
+	if r.URL.Query().Get("key") == "" {
+		writeWeatherError(w, http.StatusUnauthorized, 1002, "API key is invalid or not provided.")
+		return
+	}
+
+	city := r.URL.Query().Get("q")
+	if _, ok := weather[city]; !ok {
+		writeWeatherError(w, http.StatusBadRequest, 1006, "No matching location found.")
+		return
+	}
+
+	active := alerts[city]
+	if active == nil {
+		active = []map[string]any{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"location": map[string]any{"name": city, "region": weather[city].Region, "country": "Brazil"},
+		"alerts":   map[string]any{"alert": active},
+	})
+}
+
+func writeWeatherError(w http.ResponseWriter, status, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{"code": code, "message": message},
+	})
+}
+
+func (s *stub) getBehaviors(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.behaviors)
+}
+
+func (s *stub) putBehavior(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["upstream"]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.behaviors[name]; !ok {
+		http.Error(w, "unknown upstream", http.StatusNotFound)
+		return
+	}
+
+	var behavior Behavior
+	if err := json.NewDecoder(r.Body).Decode(&behavior); err != nil {
+		http.Error(w, fmt.Sprintf("invalid behavior: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := validateBehavior(behavior); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.behaviors[name] = behavior
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *stub) resetHandler(w http.ResponseWriter, r *http.Request) {
+	s.reset()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateBehavior(behavior Behavior) error {
+	if behavior.ErrorRate < 0 || behavior.ErrorRate > 1 {
+		return fmt.Errorf("error_rate must be between 0 and 1")
+	}
+	for _, value := range []string{behavior.Latency, behavior.Jitter} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid duration %q: %w", value, err)
+		}
+	}
+	return nil
+}
+
+func behaviorFromConfig(prefix string) (Behavior, error) {
+	behavior := Behavior{
+		ErrorRate:   viper.GetFloat64(prefix + "_ERROR_RATE"),
+		ErrorStatus: viper.GetInt(prefix + "_ERROR_STATUS"),
+		Latency:     viper.GetString(prefix + "_LATENCY"),
+		Jitter:      viper.GetString(prefix + "_JITTER"),
+	}
+	return behavior, validateBehavior(behavior)
+}
+
+func init() {
+	viper.AutomaticEnv()
+	viper.SetDefault("STUB_PORT", "8282")
+}
+
+func main() {
+	defaults := make(map[string]Behavior)
+	for _, name := range []string{"viacep", "weather"} {
+		behavior, err := behaviorFromConfig("STUB_" + strings.ToUpper(name))
+		if err != nil {
+			log.Fatalf("failed to load %s behavior: %v", name, err)
+		}
+		defaults[name] = behavior
+	}
+
+	s := newStub(defaults)
+
+	r := mux.NewRouter()
+	r.HandleFunc("/ws/{cep}/json/", s.viaCepHandler).Methods("GET")
+	r.HandleFunc("/v1/current.json", s.weatherHandler).Methods("GET")
+	r.HandleFunc("/v1/alerts.json", s.alertsHandler).Methods("GET")
+	r.HandleFunc("/admin/behaviors", s.getBehaviors).Methods("GET")
+	r.HandleFunc("/admin/behaviors/{upstream}", s.putBehavior).Methods("PUT")
+	r.HandleFunc("/admin/reset", s.resetHandler).Methods("POST")
+
+	srv := &http.Server{
+		Addr:         ":" + viper.GetString("STUB_PORT"),
+		Handler:      r,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: time.Minute,
+	}
+
+	go func() {
+		log.Printf("Stub upstreams started at http://localhost:%s\n", viper.GetString("STUB_PORT"))
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Error starting server: %v\n", err)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Fatalf("Server shutdown failed: %v\n", err)
+	}
+}
@@ -0,0 +1,101 @@
+package clients
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxCachedAlertCities bounds the cache; Brazil has about 5,600 cities, and
+// far fewer are asked for within a TTL.
+const maxCachedAlertCities = 2000
+
+// AlertSource returns the severe-weather alerts in effect for a city.
+type AlertSource interface {
+	Alerts(ctx context.Context, cityName string) ([]WeatherAlert, error)
+}
+
+// CachedAlerts keeps the alerts of each city for ttl, so cities asked for
+// often cost one WeatherAPI call per ttl. Alerts change over hours, so a
+// short ttl costs little freshness. Failures are not cached. Whether the
+// answer came from the cache is recorded on the span of ctx as
+// alerts.cache_hit.
+type CachedAlerts struct {
+	source AlertSource
+	ttl    time.Duration
+
+	// Clock defaults to the wall clock when nil.
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	entries map[string]cachedAlerts
+}
+
+type cachedAlerts struct {
+	alerts   []WeatherAlert
+	storedAt time.Time
+}
+
+func NewCachedAlerts(source AlertSource, ttl time.Duration) *CachedAlerts {
+	return &CachedAlerts{source: source, ttl: ttl, entries: map[string]cachedAlerts{}}
+}
+
+func (c *CachedAlerts) Alerts(ctx context.Context, cityName string) ([]WeatherAlert, error) {
+	span := trace.SpanFromContext(ctx)
+
+	c.mu.Lock()
+	entry, ok := c.entries[cityName]
+	fresh := ok && c.fresh(entry, clock.Or(c.Clock).Now())
+	c.mu.Unlock()
+	if fresh {
+		span.SetAttributes(attribute.Bool("alerts.cache_hit", true))
+		return entry.alerts, nil
+	}
+	span.SetAttributes(attribute.Bool("alerts.cache_hit", false))
+
+	alerts, err := c.source.Alerts(ctx, cityName)
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(cityName, alerts)
+	return alerts, nil
+}
+
+func (c *CachedAlerts) store(cityName string, alerts []WeatherAlert) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := clock.Or(c.Clock).Now()
+	if len(c.entries) >= maxCachedAlertCities {
+		for city, entry := range c.entries {
+			if !c.fresh(entry, now) {
+				delete(c.entries, city)
+			}
+		}
+		// Every entry is still fresh: this city goes uncached rather than
+		// evicting one.
+		if len(c.entries) >= maxCachedAlertCities {
+			return
+		}
+	}
+
+	c.entries[cityName] = cachedAlerts{alerts: alerts, storedAt: now}
+}
+
+// SetTTL changes the ttl, cached entries included, so it can follow a
+// reloaded config file.
+func (c *CachedAlerts) SetTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+}
+
+// fresh reports whether entry is within the ttl; c.mu must be held.
+func (c *CachedAlerts) fresh(entry cachedAlerts, now time.Time) bool {
+	return now.Before(entry.storedAt.Add(c.ttl))
+}
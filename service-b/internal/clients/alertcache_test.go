@@ -0,0 +1,163 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/clock"
+)
+
+// alertSourceFunc adapts a function to AlertSource.
+type alertSourceFunc func(ctx context.Context, cityName string) ([]WeatherAlert, error)
+
+func (f alertSourceFunc) Alerts(ctx context.Context, cityName string) ([]WeatherAlert, error) {
+	return f(ctx, cityName)
+}
+
+var flood = []WeatherAlert{{Headline: "Alerta de chuvas", Event: "Chuvas intensas", Severity: "Moderate"}}
+
+func TestCachedAlerts(t *testing.T) {
+	var calls int
+	failing := false
+	source := alertSourceFunc(func(context.Context, string) ([]WeatherAlert, error) {
+		calls++
+		if failing {
+			return nil, errors.New("unavailable")
+		}
+		return flood, nil
+	})
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	cache := NewCachedAlerts(source, time.Minute)
+	cache.Clock = clk
+	ctx := context.Background()
+
+	cache.Alerts(ctx, "Linhares")
+	cache.Alerts(ctx, "Linhares")
+	if calls != 1 {
+		t.Fatalf("source called %d times within the ttl, want 1", calls)
+	}
+
+	clk.Advance(time.Minute)
+	failing = true
+	if _, err := cache.Alerts(ctx, "Linhares"); err == nil {
+		t.Fatal("expired entry served instead of asking the source")
+	}
+	if _, err := cache.Alerts(ctx, "Linhares"); err == nil || calls != 3 {
+		t.Errorf("failure was cached: %d calls, error %v", calls, err)
+	}
+}
+
+func TestCachedAlertsSetTTL(t *testing.T) {
+	var calls int
+	source := alertSourceFunc(func(context.Context, string) ([]WeatherAlert, error) {
+		calls++
+		return flood, nil
+	})
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	cache := NewCachedAlerts(source, time.Hour)
+	cache.Clock = clk
+	ctx := context.Background()
+
+	cache.Alerts(ctx, "Linhares")
+	clk.Advance(2 * time.Minute)
+
+	// A shorter ttl expires what was stored under the longer one.
+	cache.SetTTL(time.Minute)
+	cache.Alerts(ctx, "Linhares")
+	if calls != 2 {
+		t.Fatalf("source called %d times, want the entry expired by the new ttl", calls)
+	}
+
+	cache.SetTTL(time.Hour)
+	clk.Advance(30 * time.Minute)
+	cache.Alerts(ctx, "Linhares")
+	if calls != 2 {
+		t.Errorf("source called %d times, want the entry kept by the longer ttl", calls)
+	}
+}
+
+func TestCachedAlertsConcurrent(t *testing.T) {
+	var calls atomic.Int64
+	source := alertSourceFunc(func(context.Context, string) ([]WeatherAlert, error) {
+		calls.Add(1)
+		return flood, nil
+	})
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	cache := NewCachedAlerts(source, time.Minute)
+	cache.Clock = clk
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if j%25 == 0 {
+					clk.Advance(time.Second)
+				}
+				alerts, err := cache.Alerts(context.Background(), "city-"+strconv.Itoa((i+j)%10))
+				if err != nil || len(alerts) != 1 {
+					t.Errorf("Alerts = %v, %v", alerts, err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Concurrent misses may each reach the source, but most lookups of the
+	// ten cities must be served from the cache.
+	if got := calls.Load(); got > 5000/2 {
+		t.Errorf("source called %d times for 5000 lookups of 10 cities", got)
+	}
+}
+
+func BenchmarkCachedAlerts(b *testing.B) {
+	source := alertSourceFunc(func(context.Context, string) ([]WeatherAlert, error) {
+		return flood, nil
+	})
+	ctx := context.Background()
+
+	b.Run("hit", func(b *testing.B) {
+		cache := NewCachedAlerts(source, time.Hour)
+		cache.Alerts(ctx, "Linhares")
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			cache.Alerts(ctx, "Linhares")
+		}
+	})
+
+	b.Run("miss", func(b *testing.B) {
+		cache := NewCachedAlerts(source, time.Hour)
+		cities := make([]string, maxCachedAlertCities)
+		for i := range cities {
+			cities[i] = "city-" + strconv.Itoa(i)
+		}
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			if i%len(cities) == 0 {
+				cache = NewCachedAlerts(source, time.Hour)
+			}
+			cache.Alerts(ctx, cities[i%len(cities)])
+		}
+	})
+
+	b.Run("hit parallel", func(b *testing.B) {
+		cache := NewCachedAlerts(source, time.Hour)
+		cache.Alerts(ctx, "Linhares")
+		b.ReportAllocs()
+
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				cache.Alerts(ctx, "Linhares")
+			}
+		})
+	})
+}
@@ -0,0 +1,49 @@
+package clients
+
+import (
+	"net/http"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/callbudget"
+	"github.com/luis-olivetti/go-observability/pkg/platform/chaos"
+	"github.com/luis-olivetti/go-observability/pkg/platform/debugtrace"
+	"github.com/luis-olivetti/go-observability/pkg/platform/httpclient"
+	"github.com/luis-olivetti/go-observability/pkg/platform/redact"
+	"github.com/luis-olivetti/go-observability/service-b/internal/config"
+	"github.com/luis-olivetti/go-observability/service-b/internal/fixture"
+)
+
+// NewHTTPClient builds the long-lived client of the upstream called name,
+// recording or replaying fixtures when fixtureMode is set, with CEPs stored
+// as scrubber says for history, and injecting chaos faults when injector is
+// not nil. Calls are spread over the alternate base URLs of upstream when it
+// has any. Calls made within a debug trace record their connection events and
+// headers, a share of the calls record their bodies when upstream says so,
+// and every call counts against the outbound call budget of its
+// request.
+func NewHTTPClient(name string, upstream config.Upstream, fixtureMode fixture.Mode, scrubber *redact.Scrubber, injector *chaos.Injector) (*http.Client, error) {
+	client, err := httpclient.New(upstream.HTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fixtures are keyed on the URL built on BaseURL, whichever endpoint
+	// answers.
+	client.Transport, err = httpclient.Endpoints(name, append([]string{upstream.BaseURL}, upstream.Alternates...), client.Transport)
+	if err != nil {
+		return nil, err
+	}
+
+	if fixtureMode != fixture.ModeOff {
+		client.Transport = &fixture.Transport{
+			Mode:         fixtureMode,
+			Dir:          upstream.FixtureDir,
+			SecretParams: []string{"key"},
+			Scrub:        scrubber.History,
+			Base:         client.Transport,
+		}
+	}
+
+	client.Transport = callbudget.Transport(httpclient.Measure(name, debugtrace.Transport(httpclient.Capture(name, upstream.Capture, injector.Transport(client.Transport)))))
+
+	return client, nil
+}
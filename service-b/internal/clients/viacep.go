@@ -0,0 +1,108 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/apierror"
+	"github.com/luis-olivetti/go-observability/pkg/platform/httpclient"
+	"github.com/luis-olivetti/go-observability/pkg/platform/jsoncodec"
+	"github.com/luis-olivetti/go-observability/pkg/platform/logsample"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxViaCepResponse caps a ViaCEP body; a real address is well under 1 KiB.
+const maxViaCepResponse = 64 << 10
+
+// ViaCepFlag decodes the erro field of ViaCEP. Devido um bug no viacep, o
+// campo pode vir como boolean (true) ou como string ("true").
+type ViaCepFlag bool
+
+func (f *ViaCepFlag) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", `"true"`:
+		*f = true
+	default:
+		*f = false
+	}
+
+	return nil
+}
+
+type ViaCep struct {
+	Erro        ViaCepFlag `json:"erro,omitempty"`
+	Cep         string     `json:"cep"`
+	Logradouro  string     `json:"logradouro"`
+	Complemento string     `json:"complemento"`
+	Bairro      string     `json:"bairro"`
+	Localidade  string     `json:"localidade"`
+	Uf          string     `json:"uf"`
+	Ibge        string     `json:"ibge"`
+	Gia         string     `json:"gia"`
+	Ddd         string     `json:"ddd"`
+	Siafi       string     `json:"siafi"`
+}
+
+// ViaCepResolver resolves zipcodes with the ViaCEP API.
+type ViaCepResolver struct {
+	client  *http.Client
+	baseURL string
+	tracer  trace.Tracer
+}
+
+func NewViaCepResolver(client *http.Client, baseURL string, tracer trace.Tracer) *ViaCepResolver {
+	return &ViaCepResolver{client: client, baseURL: strings.TrimRight(baseURL, "/"), tracer: tracer}
+}
+
+func (v *ViaCepResolver) Resolve(ctx context.Context, zipCode string) (*ViaCep, error) {
+	ctx, span := v.tracer.Start(ctx, "getViaCep")
+	defer span.End()
+
+	viaCep, err := v.resolve(ctx, zipCode)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return viaCep, nil
+}
+
+func (v *ViaCepResolver) resolve(ctx context.Context, zipCode string) (*ViaCep, error) {
+	url := fmt.Sprintf("%s/ws/%s/json/", v.baseURL, zipCode)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, apierror.Internal(fmt.Errorf("failed to create request (viacep): %w", err))
+	}
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return nil, apierror.UpstreamFailure(fmt.Errorf("failed to make HTTP request (viacep): %w", err))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		logsample.Errorf(ctx, "Unexpected status code (viacep): %d", res.StatusCode)
+		return nil, apierror.UpstreamStatus(res.StatusCode, fmt.Errorf("unexpected status code (viacep): %d", res.StatusCode))
+	}
+
+	var viaCepResponse ViaCep
+	body := httpclient.LimitBody(res.Body, maxViaCepResponse)
+	if err := jsoncodec.Default.NewDecoder(body).Decode(&viaCepResponse); err != nil {
+		return nil, apierror.UpstreamFailure(fmt.Errorf("failed to decode response (viacep): %w", err))
+	}
+
+	if viaCepResponse.Erro {
+		return nil, apierror.ZipcodeNotFound(nil)
+	}
+
+	if viaCepResponse.Localidade == "" {
+		return nil, apierror.InvalidZipcode(nil)
+	}
+
+	return &viaCepResponse, nil
+}
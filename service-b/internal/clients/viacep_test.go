@@ -0,0 +1,124 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/apierror"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// roundTripFunc answers requests without a network, so the clients can be
+// tested against canned upstream responses.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func stubClient(status int, body string) *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	})}
+}
+
+func TestViaCepFlag(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want ViaCepFlag
+	}{
+		{name: "boolean true", json: `{"erro": true}`, want: true},
+		{name: "string true", json: `{"erro": "true"}`, want: true},
+		{name: "boolean false", json: `{"erro": false}`, want: false},
+		{name: "string false", json: `{"erro": "false"}`, want: false},
+		{name: "absent", json: `{"cep": "01001-000"}`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got ViaCep
+			if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if got.Erro != tt.want {
+				t.Errorf("Erro = %v, want %v", got.Erro, tt.want)
+			}
+		})
+	}
+}
+
+func TestViaCepResolverErro(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCode contracts.ErrorCode
+		wantCity string
+	}{
+		{name: "boolean erro", body: `{"erro": true}`, wantCode: contracts.CodeZipcodeNotFound},
+		{name: "string erro", body: `{"erro": "true"}`, wantCode: contracts.CodeZipcodeNotFound},
+		{
+			name:     "address",
+			body:     `{"cep": "01001-000", "localidade": "São Paulo", "uf": "SP"}`,
+			wantCity: "São Paulo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := NewViaCepResolver(stubClient(http.StatusOK, tt.body), "http://viacep.test", noop.NewTracerProvider().Tracer(""))
+			got, err := resolver.Resolve(context.Background(), "01001000")
+
+			if tt.wantCode != "" {
+				var apiErr *apierror.Error
+				if !errors.As(err, &apiErr) || apiErr.Code != tt.wantCode {
+					t.Fatalf("Resolve error = %v, want code %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve failed: %v", err)
+			}
+			if got.Localidade != tt.wantCity {
+				t.Errorf("Localidade = %q, want %q", got.Localidade, tt.wantCity)
+			}
+		})
+	}
+}
+
+func FuzzViaCepResolve(f *testing.F) {
+	for _, seed := range []string{
+		`{"erro": true}`,
+		`{"erro": "true"}`,
+		`{"cep": "01001-000", "localidade": "São Paulo", "uf": "SP"}`,
+		`{"localidade": 42}`,
+		`[]`,
+		`{`,
+		``,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, body string) {
+		resolver := NewViaCepResolver(stubClient(http.StatusOK, body), "http://viacep.test", noop.NewTracerProvider().Tracer(""))
+		got, err := resolver.Resolve(context.Background(), "01001000")
+		if err != nil {
+			var apiErr *apierror.Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Resolve(%q) returned %T, want *apierror.Error", body, err)
+			}
+			return
+		}
+		if got.Localidade == "" || got.Erro {
+			t.Fatalf("Resolve(%q) accepted %+v", body, got)
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/apierror"
+	"github.com/luis-olivetti/go-observability/pkg/platform/errclass"
+	"github.com/luis-olivetti/go-observability/pkg/platform/httpclient"
+	"github.com/luis-olivetti/go-observability/pkg/platform/jsoncodec"
+	"github.com/luis-olivetti/go-observability/pkg/platform/logsample"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Weather struct {
+	Location struct {
+		Name           string  `json:"name"`
+		Region         string  `json:"region"`
+		Country        string  `json:"country"`
+		Lat            float64 `json:"lat"`
+		Lon            float64 `json:"lon"`
+		TzID           string  `json:"tz_id"`
+		LocaltimeEpoch int     `json:"localtime_epoch"`
+		Localtime      string  `json:"localtime"`
+	} `json:"location"`
+	Current struct {
+		TempC     float64 `json:"temp_c"`
+		Condition struct {
+			Text string `json:"text"`
+			Icon string `json:"icon"`
+			Code int    `json:"code"`
+		} `json:"condition"`
+		WindKph    float64 `json:"wind_kph"`
+		WindDir    string  `json:"wind_dir"`
+		Humidity   int     `json:"humidity"`
+		FeelsLikeC float64 `json:"feelslike_c"`
+	} `json:"current"`
+}
+
+// WeatherAPI error codes with a meaning of their own: a query matching no
+// location, and the account's monthly quota being spent.
+const (
+	weatherNoLocation    = 1006
+	weatherQuotaExceeded = 2007
+)
+
+// maxWeatherResponse caps a WeatherAPI body; current.json is a few KiB.
+const maxWeatherResponse = 256 << 10
+
+// WeatherAPIProvider reads the current weather from WeatherAPI.
+type WeatherAPIProvider struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	tracer  trace.Tracer
+}
+
+func NewWeatherAPIProvider(client *http.Client, baseURL, apiKey string, tracer trace.Tracer) *WeatherAPIProvider {
+	return &WeatherAPIProvider{client: client, baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey, tracer: tracer}
+}
+
+func (p *WeatherAPIProvider) Current(ctx context.Context, cityName string) (*Weather, error) {
+	ctx, span := p.tracer.Start(ctx, "getWeather")
+	defer span.End()
+
+	weather, err := p.current(ctx, cityName)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return weather, nil
+}
+
+func (p *WeatherAPIProvider) current(ctx context.Context, cityName string) (*Weather, error) {
+	var response Weather
+
+	cityNameEncoded := neturl.QueryEscape(cityName)
+	url := fmt.Sprintf("%s/v1/current.json?key=%s&q=%s", p.baseURL, p.apiKey, cityNameEncoded)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, apierror.Internal(fmt.Errorf("failed to create request (weather): %w", err))
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, apierror.UpstreamFailure(fmt.Errorf("failed to make HTTP request (weather): %w", err))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, weatherError(ctx, res)
+	}
+
+	err = jsoncodec.Default.NewDecoder(httpclient.LimitBody(res.Body, maxWeatherResponse)).Decode(&response)
+	if err != nil {
+		return nil, apierror.UpstreamFailure(fmt.Errorf("failed to decode response (weather): %w", err))
+	}
+
+	return &response, nil
+}
+
+// WeatherAlert is one alert of alerts.json.
+type WeatherAlert struct {
+	Headline    string `json:"headline"`
+	Severity    string `json:"severity"`
+	Urgency     string `json:"urgency"`
+	Areas       string `json:"areas"`
+	Event       string `json:"event"`
+	Effective   string `json:"effective"`
+	Expires     string `json:"expires"`
+	Desc        string `json:"desc"`
+	Instruction string `json:"instruction"`
+}
+
+// Alerts returns the severe-weather alerts in effect for a city.
+func (p *WeatherAPIProvider) Alerts(ctx context.Context, cityName string) ([]WeatherAlert, error) {
+	ctx, span := p.tracer.Start(ctx, "getWeatherAlerts")
+	defer span.End()
+
+	alerts, err := p.alerts(ctx, cityName)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+func (p *WeatherAPIProvider) alerts(ctx context.Context, cityName string) ([]WeatherAlert, error) {
+	var response struct {
+		Alerts struct {
+			Alert []WeatherAlert `json:"alert"`
+		} `json:"alerts"`
+	}
+
+	url := fmt.Sprintf("%s/v1/alerts.json?key=%s&q=%s", p.baseURL, p.apiKey, neturl.QueryEscape(cityName))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, apierror.Internal(fmt.Errorf("failed to create request (weather alerts): %w", err))
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, apierror.UpstreamFailure(fmt.Errorf("failed to make HTTP request (weather alerts): %w", err))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, weatherError(ctx, res)
+	}
+
+	err = jsoncodec.Default.NewDecoder(httpclient.LimitBody(res.Body, maxWeatherResponse)).Decode(&response)
+	if err != nil {
+		return nil, apierror.UpstreamFailure(fmt.Errorf("failed to decode response (weather alerts): %w", err))
+	}
+
+	return response.Alerts.Alert, nil
+}
+
+// weatherError classifies a WeatherAPI error response. Error 1006 means the
+// city of the zipcode is unknown to WeatherAPI, and 2007 or a 429 that the
+// provider quota is spent; any other answer is the upstream failing, whatever
+// its status, since the query is a city ViaCEP resolved rather than caller
+// input.
+func weatherError(ctx context.Context, res *http.Response) *apierror.Error {
+	cause := fmt.Errorf("unexpected status code (weather): %d", res.StatusCode)
+
+	var body struct {
+		Error struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	err := jsoncodec.Default.NewDecoder(httpclient.LimitBody(res.Body, maxWeatherResponse)).Decode(&body)
+	if err == nil && body.Error.Code == weatherNoLocation {
+		return apierror.FromClass(errclass.NotFound, contracts.CodeZipcodeNotFound, "cannot find the weather of the zipcode's city", cause)
+	}
+
+	logsample.Errorf(ctx, "Unexpected status code (weather): %d", res.StatusCode)
+	if res.StatusCode == http.StatusTooManyRequests || (err == nil && body.Error.Code == weatherQuotaExceeded) {
+		return apierror.ProviderQuota(cause)
+	}
+
+	class := errclass.FromStatus(res.StatusCode)
+	if class != errclass.UpstreamTimeout {
+		class = errclass.UpstreamError
+	}
+	return apierror.Upstream(class, cause)
+}
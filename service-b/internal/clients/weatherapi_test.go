@@ -0,0 +1,80 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/apierror"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestWeatherAPIError(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantStatus int
+		wantCode   contracts.ErrorCode
+	}{
+		{name: "no location", status: http.StatusBadRequest, body: `{"error": {"code": 1006}}`, wantStatus: http.StatusNotFound, wantCode: contracts.CodeZipcodeNotFound},
+		{name: "quota code", status: http.StatusForbidden, body: `{"error": {"code": 2007}}`, wantStatus: http.StatusBadGateway, wantCode: contracts.CodeProviderQuota},
+		{name: "too many requests", status: http.StatusTooManyRequests, body: ``, wantStatus: http.StatusBadGateway, wantCode: contracts.CodeProviderQuota},
+		{name: "bad key", status: http.StatusUnauthorized, body: `{"error": {"code": 2006}}`, wantStatus: http.StatusBadGateway, wantCode: contracts.CodeUpstreamError},
+		{name: "other client error", status: http.StatusBadRequest, body: `{"error": {"code": 1003}}`, wantStatus: http.StatusBadGateway, wantCode: contracts.CodeUpstreamError},
+		{name: "server error", status: http.StatusInternalServerError, body: `oops`, wantStatus: http.StatusBadGateway, wantCode: contracts.CodeUpstreamError},
+		{name: "gateway timeout", status: http.StatusGatewayTimeout, body: ``, wantStatus: http.StatusGatewayTimeout, wantCode: contracts.CodeUpstreamTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewWeatherAPIProvider(stubClient(tt.status, tt.body), "http://weather.test", "key", noop.NewTracerProvider().Tracer(""))
+			_, err := provider.Current(context.Background(), "São Paulo")
+
+			var apiErr *apierror.Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Current error = %v, want *apierror.Error", err)
+			}
+			if apiErr.Status != tt.wantStatus || apiErr.Code != tt.wantCode {
+				t.Errorf("Current error = %d %s, want %d %s", apiErr.Status, apiErr.Code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestWeatherAPICurrent(t *testing.T) {
+	body := `{"location": {"name": "Linhares"}, "current": {"temp_c": 28.5, "condition": {"text": "Ensolarado", "code": 1000}, "humidity": 74, "feelslike_c": 31.2}}`
+	provider := NewWeatherAPIProvider(stubClient(http.StatusOK, body), "http://weather.test", "key", noop.NewTracerProvider().Tracer(""))
+
+	got, err := provider.Current(context.Background(), "Linhares")
+	if err != nil {
+		t.Fatalf("Current failed: %v", err)
+	}
+	if got.Location.Name != "Linhares" || got.Current.TempC != 28.5 || got.Current.Condition.Code != 1000 || got.Current.Humidity != 74 || got.Current.FeelsLikeC != 31.2 {
+		t.Errorf("Current = %+v", got)
+	}
+}
+
+func FuzzWeatherAPICurrent(f *testing.F) {
+	for _, seed := range []string{
+		`{"location": {"name": "São Paulo"}, "current": {"temp_c": 28.5}}`,
+		`{"current": {"temp_c": "hot"}}`,
+		`{"current": {"temp_c": 1e400}}`,
+		`null`,
+		`{`,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, body string) {
+		provider := NewWeatherAPIProvider(stubClient(http.StatusOK, body), "http://weather.test", "key", noop.NewTracerProvider().Tracer(""))
+		if _, err := provider.Current(context.Background(), "São Paulo"); err != nil {
+			var apiErr *apierror.Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Current(%q) returned %T, want *apierror.Error", body, err)
+			}
+		}
+	})
+}
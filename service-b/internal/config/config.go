@@ -0,0 +1,395 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/chaos"
+	"github.com/luis-olivetti/go-observability/pkg/platform/configfile"
+	"github.com/luis-olivetti/go-observability/pkg/platform/errclass"
+	"github.com/luis-olivetti/go-observability/pkg/platform/featureflag"
+	"github.com/luis-olivetti/go-observability/pkg/platform/httpclient"
+	"github.com/luis-olivetti/go-observability/pkg/platform/ipfilter"
+	"github.com/luis-olivetti/go-observability/pkg/platform/jwtauth"
+	"github.com/luis-olivetti/go-observability/pkg/platform/logsample"
+	"github.com/luis-olivetti/go-observability/pkg/platform/messages"
+	"github.com/luis-olivetti/go-observability/pkg/platform/redact"
+	"github.com/luis-olivetti/go-observability/pkg/platform/sampling"
+	"github.com/luis-olivetti/go-observability/pkg/platform/slo"
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"github.com/luis-olivetti/go-observability/service-b/internal/fixture"
+	"github.com/luis-olivetti/go-observability/service-b/internal/middleware"
+	"github.com/luis-olivetti/go-observability/service-b/internal/units"
+	"github.com/spf13/viper"
+)
+
+// ServerTLS holds the certificate a listener serves; when both files are
+// empty the listener speaks plain HTTP.
+type ServerTLS struct {
+	CertFile string
+	KeyFile  string
+}
+
+// Upstream holds the settings of one external API.
+type Upstream struct {
+	BaseURL string
+	// Alternates are equivalent base URLs, such as regional mirrors; calls
+	// go to the fastest healthy one of these and BaseURL.
+	Alternates []string
+	HTTP       httpclient.Config
+	// Capture is nil unless UPSTREAM_CAPTURE_PERCENT is set.
+	Capture *httpclient.CaptureConfig
+	// FixtureDir is where the upstream's fixtures are recorded or replayed.
+	FixtureDir string
+}
+
+type Config struct {
+	ServiceName  string
+	CollectorURL string
+	// OTLPProtocol is telemetry.ProtocolGRPC or telemetry.ProtocolHTTP.
+	OTLPProtocol string
+	// TracesExporter is telemetry.ExporterOTLP or telemetry.ExporterStdout.
+	TracesExporter string
+	LogLevel       slog.Level
+	// Strict rejects settings only meant for development, such as chaos
+	// mode or upstreams on this host.
+	Strict bool
+	// DeploymentEnvironment and Region say where the instance runs; both are
+	// stamped on every span when set.
+	DeploymentEnvironment string
+	Region                string
+
+	HTTPPort          string
+	AdminPort         string
+	AdminWriteTimeout time.Duration
+	// InternalPort is empty when the internal listener is off.
+	InternalPort string
+
+	PublicTLS   ServerTLS
+	InternalTLS ServerTLS
+	AdminTLS    ServerTLS
+
+	RedactPatterns []string
+	// CEPs says how CEPs appear in spans, logs and recorded fixtures.
+	CEPs redact.CEPPolicy
+	// AuditKey chains the audit trail with HMAC-SHA256; it is required.
+	AuditKey []byte
+
+	ViaCEP        Upstream
+	Weather       Upstream
+	WeatherAPIKey string
+	FixtureMode   fixture.Mode
+	// AlertsCacheTTL is how long the weather alerts of a city are reused.
+	// It can change at runtime, as can the timeouts of ViaCEP and Weather.
+	AlertsCacheTTL time.Duration
+
+	TemperaturePrecision int
+	TemperatureRounding  units.Rounding
+
+	// JWT is nil when JWT_JWKS_URL is not set.
+	JWT *jwtauth.Config
+
+	HMACSecret       string
+	HMACReplayWindow time.Duration
+
+	IPFilter ipfilter.Config
+	// FilterIPs reports whether an allow or deny list is configured.
+	FilterIPs bool
+
+	// Chaos is nil unless CHAOS_ENABLED is set.
+	Chaos *chaos.Config
+
+	// Prewarm is nil when PREWARM_CONNECTIONS is not set.
+	Prewarm *httpclient.WarmConfig
+
+	// StartupUpstreamCheck adds one request to each upstream to the startup
+	// checks, on top of resolving their hosts.
+	StartupUpstreamCheck bool
+
+	// DisabledMiddlewares are optional middlewares left out of every chain.
+	DisabledMiddlewares map[string]bool
+
+	// OutboundCallBudget caps the upstream calls one request may trigger;
+	// zero lifts the cap.
+	OutboundCallBudget int
+
+	// LogSampling throttles repeated error lines; a zero Burst logs them all.
+	LogSampling logsample.Config
+
+	// ErrorStatuses overrides the status errors of each class are answered
+	// with; classes missing from it keep their default.
+	ErrorStatuses map[errclass.Class]int
+
+	// Messages overrides or adds to the built-in message catalog; nil keeps
+	// it as shipped.
+	Messages messages.Texts
+
+	// SLO sets the latency objectives of the measured routes.
+	SLO slo.Config
+
+	// Sampling is nil when SAMPLING_RATIO is not set; every span is exported.
+	// It can change at runtime, see WatchFile.
+	Sampling *sampling.Config
+
+	// Features holds the feature flag rules; flags without a rule use the
+	// default of the code that checks them. They can change at runtime.
+	Features map[string]featureflag.Rule
+}
+
+func init() {
+	viper.AutomaticEnv()
+	viper.SetDefault("ADMIN_PORT", "9181")
+	viper.SetDefault("ADMIN_WRITE_TIMEOUT", "60s")
+	viper.SetDefault("OUTBOUND_CALL_BUDGET", 8)
+	viper.SetDefault("OTEL_TRACES_EXPORTER", telemetry.ExporterOTLP)
+	viper.SetDefault("OTEL_EXPORTER_OTLP_PROTOCOL", telemetry.ProtocolGRPC)
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("CEP_PRIVACY_SPANS", string(redact.DefaultCEPPolicy.Spans))
+	viper.SetDefault("CEP_PRIVACY_LOGS", string(redact.DefaultCEPPolicy.Logs))
+	viper.SetDefault("CEP_PRIVACY_HISTORY", string(redact.DefaultCEPPolicy.History))
+	viper.SetDefault("JWT_CLOCK_SKEW", "30s")
+	viper.SetDefault("HMAC_REPLAY_WINDOW", "5m")
+	viper.SetDefault("VIACEP_BASE_URL", "http://viacep.com.br")
+	viper.SetDefault("WEATHER_BASE_URL", "http://api.weatherapi.com")
+	viper.SetDefault("UPSTREAM_FIXTURE_DIR", "fixtures")
+	viper.SetDefault("UPSTREAM_CAPTURE_MAX_BYTES", 2048)
+	viper.SetDefault("TEMPERATURE_PRECISION", units.DefaultPrecision)
+	viper.SetDefault("TEMPERATURE_ROUNDING", string(units.DefaultRounding))
+	viper.SetDefault("WEATHER_API_KEY", "a91eb948a337442782b123810242601")
+	viper.SetDefault("PREWARM_TIMEOUT", "5s")
+	viper.SetDefault("ALERTS_CACHE_TTL", "5m")
+	viper.SetDefault("SAMPLING_LATENCY_THRESHOLD", "1s")
+	viper.SetDefault("SLO_LATENCY_THRESHOLD", "1s")
+	viper.SetDefault("LOG_SAMPLING_BURST", 10)
+	viper.SetDefault("LOG_SAMPLING_WINDOW", "1m")
+	viper.SetDefault("SLO_AVAILABILITY_TARGET", 0.999)
+	viper.SetDefault("SLO_LATENCY_TARGET", 0.99)
+	viper.SetDefault("SLO_WINDOWS", "5m,30m,1h,6h,24h")
+}
+
+// Load reads the service configuration from the environment and reports
+// every invalid or missing setting at once, as a *ValidationError.
+func Load() (*Config, error) {
+	if err := configfile.Read(); err != nil {
+		return nil, err
+	}
+
+	var problems problems
+	applyProfile(&problems)
+	checkDurations(&problems)
+
+	fixtureMode, err := fixture.ParseMode(viper.GetString("UPSTREAM_FIXTURE_MODE"))
+	if err != nil {
+		problems.addf("UPSTREAM_FIXTURE_MODE", "is invalid: %v", err)
+	}
+
+	hmacSecret := readSecret(&problems, "HMAC_SECRET")
+
+	cfg := &Config{
+		ServiceName:  viper.GetString("OTEL_SERVICE_NAME"),
+		CollectorURL: viper.GetString("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		OTLPProtocol: viper.GetString("OTEL_EXPORTER_OTLP_PROTOCOL"),
+
+		TracesExporter: viper.GetString("OTEL_TRACES_EXPORTER"),
+		Strict:         viper.GetBool("STRICT_CONFIG"),
+
+		DeploymentEnvironment: viper.GetString("DEPLOYMENT_ENVIRONMENT"),
+		Region:                viper.GetString("REGION"),
+
+		HTTPPort:          viper.GetString("HTTP_PORT"),
+		AdminPort:         viper.GetString("ADMIN_PORT"),
+		AdminWriteTimeout: viper.GetDuration("ADMIN_WRITE_TIMEOUT"),
+		InternalPort:      viper.GetString("INTERNAL_PORT"),
+
+		PublicTLS:   serverTLS("HTTP"),
+		InternalTLS: serverTLS("INTERNAL"),
+		AdminTLS:    serverTLS("ADMIN"),
+
+		RedactPatterns: redact.ParsePatterns(viper.GetString("REDACT_ATTRIBUTE_PATTERNS")),
+
+		ViaCEP:        upstream("VIACEP"),
+		Weather:       upstream("WEATHER"),
+		WeatherAPIKey: viper.GetString("WEATHER_API_KEY"),
+		FixtureMode:   fixtureMode,
+
+		TemperaturePrecision: viper.GetInt("TEMPERATURE_PRECISION"),
+		TemperatureRounding:  units.Rounding(viper.GetString("TEMPERATURE_ROUNDING")),
+
+		HMACSecret:       hmacSecret,
+		HMACReplayWindow: viper.GetDuration("HMAC_REPLAY_WINDOW"),
+
+		IPFilter: ipfilter.Config{
+			Allow:          ipfilter.ParseList(viper.GetString("IP_ALLOWLIST")),
+			Deny:           ipfilter.ParseList(viper.GetString("IP_DENYLIST")),
+			TrustedProxies: ipfilter.ParseList(viper.GetString("TRUSTED_PROXIES")),
+		},
+		FilterIPs: viper.GetString("IP_ALLOWLIST") != "" || viper.GetString("IP_DENYLIST") != "",
+
+		StartupUpstreamCheck: viper.GetBool("STARTUP_UPSTREAM_CHECK"),
+		OutboundCallBudget:   viper.GetInt("OUTBOUND_CALL_BUDGET"),
+	}
+
+	if viper.GetString("JWT_JWKS_URL") != "" {
+		cfg.JWT = &jwtauth.Config{
+			JWKSURL:     viper.GetString("JWT_JWKS_URL"),
+			Issuer:      viper.GetString("JWT_ISSUER"),
+			Audience:    viper.GetString("JWT_AUDIENCE"),
+			ClockSkew:   viper.GetDuration("JWT_CLOCK_SKEW"),
+			TenantClaim: viper.GetString("JWT_TENANT_CLAIM"),
+			RefreshTTL:  viper.GetDuration("JWT_JWKS_REFRESH_INTERVAL"),
+		}
+	}
+
+	if viper.GetBool("CHAOS_ENABLED") {
+		cfg.Chaos = &chaos.Config{
+			FailureRate:   viper.GetFloat64("CHAOS_FAILURE_RATE"),
+			FailureStatus: viper.GetInt("CHAOS_FAILURE_STATUS"),
+			Distribution:  chaos.Distribution(viper.GetString("CHAOS_LATENCY_DISTRIBUTION")),
+			Latency:       viper.GetDuration("CHAOS_LATENCY"),
+			Spread:        viper.GetDuration("CHAOS_LATENCY_SPREAD"),
+			SpanDropRate:  viper.GetFloat64("CHAOS_SPAN_DROP_RATE"),
+		}
+	}
+
+	if connections := viper.GetInt("PREWARM_CONNECTIONS"); connections > 0 {
+		cfg.Prewarm = &httpclient.WarmConfig{
+			Connections: connections,
+			Timeout:     viper.GetDuration("PREWARM_TIMEOUT"),
+			Interval:    viper.GetDuration("PREWARM_INTERVAL"),
+		}
+	}
+
+	cfg.LogSampling = logsample.Config{
+		Burst:  viper.GetInt("LOG_SAMPLING_BURST"),
+		Window: viper.GetDuration("LOG_SAMPLING_WINDOW"),
+	}
+
+	cfg.CEPs = redact.CEPPolicy{
+		Spans:   cepMode(&problems, "CEP_PRIVACY_SPANS"),
+		Logs:    cepMode(&problems, "CEP_PRIVACY_LOGS"),
+		History: cepMode(&problems, "CEP_PRIVACY_HISTORY"),
+	}
+	cfg.CEPs.HashKey = []byte(readSecret(&problems, "CEP_HASH_KEY"))
+	cfg.AuditKey = []byte(readSecret(&problems, "AUDIT_HMAC_KEY"))
+
+	if err := cfg.LogLevel.UnmarshalText([]byte(viper.GetString("LOG_LEVEL"))); err != nil {
+		problems.addf("LOG_LEVEL", "must be debug, info, warn or error, got %q", viper.GetString("LOG_LEVEL"))
+	}
+
+	if cfg.DisabledMiddlewares, err = middleware.ParseDisabled(viper.GetString("MIDDLEWARES_DISABLED")); err != nil {
+		problems.addf("MIDDLEWARES_DISABLED", "is invalid: %v", err)
+	}
+
+	if cfg.ErrorStatuses, err = errclass.ParseOverrides(viper.GetString("ERROR_STATUS_OVERRIDES")); err != nil {
+		problems.addf("ERROR_STATUS_OVERRIDES", "is invalid: %v", err)
+	}
+
+	if cfg.Messages, err = messages.LoadFile(viper.GetString("MESSAGE_CATALOG_FILE")); err != nil {
+		problems.addf("MESSAGE_CATALOG_FILE", "is invalid: %v", err)
+	}
+
+	cfg.SLO.LatencyThreshold = viper.GetDuration("SLO_LATENCY_THRESHOLD")
+	if cfg.SLO.RouteThresholds, err = slo.ParseThresholds(viper.GetString("SLO_ROUTE_LATENCY_THRESHOLDS")); err != nil {
+		problems.addf("SLO_ROUTE_LATENCY_THRESHOLDS", "is invalid: %v", err)
+	}
+	cfg.SLO.AvailabilityTarget = viper.GetFloat64("SLO_AVAILABILITY_TARGET")
+	cfg.SLO.LatencyTarget = viper.GetFloat64("SLO_LATENCY_TARGET")
+	if cfg.SLO.Windows, err = slo.ParseWindows(viper.GetString("SLO_WINDOWS")); err != nil {
+		problems.addf("SLO_WINDOWS", "is invalid: %v", err)
+	}
+
+	tunables := loadTunables(&problems)
+	cfg.Sampling = tunables.Sampling
+	cfg.Features = tunables.Features
+	cfg.ViaCEP.HTTP.Timeout = httpclient.NewTimeout(tunables.ViaCEPTimeout)
+	cfg.Weather.HTTP.Timeout = httpclient.NewTimeout(tunables.WeatherTimeout)
+	cfg.AlertsCacheTTL = tunables.AlertsCacheTTL
+
+	cfg.validate(&problems)
+	if err := problems.err(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// serverTLS reads the certificate of the listener whose port is
+// <PREFIX>_PORT.
+func serverTLS(prefix string) ServerTLS {
+	return ServerTLS{
+		CertFile: viper.GetString(prefix + "_TLS_CERT_FILE"),
+		KeyFile:  viper.GetString(prefix + "_TLS_KEY_FILE"),
+	}
+}
+
+// upstream reads the settings of the upstream named prefix. TLS settings fall
+// back to the global TLS_* values when no <PREFIX>_TLS_* value is set.
+func upstream(prefix string) Upstream {
+	tlsSetting := func(key string) string {
+		if value := viper.GetString(prefix + "_" + key); value != "" {
+			return value
+		}
+		return viper.GetString(key)
+	}
+
+	var capture *httpclient.CaptureConfig
+	if percent := viper.GetFloat64("UPSTREAM_CAPTURE_PERCENT"); percent != 0 {
+		capture = &httpclient.CaptureConfig{
+			Percent:  percent,
+			MaxBytes: viper.GetInt("UPSTREAM_CAPTURE_MAX_BYTES"),
+		}
+	}
+
+	return Upstream{
+		BaseURL:    viper.GetString(prefix + "_BASE_URL"),
+		Alternates: splitList(viper.GetString(prefix + "_ALTERNATE_BASE_URLS")),
+		HTTP: httpclient.Config{
+			TLS: httpclient.TLSConfig{
+				CAFile:             tlsSetting("TLS_CA_FILE"),
+				MinVersion:         tlsSetting("TLS_MIN_VERSION"),
+				InsecureSkipVerify: viper.GetBool(prefix + "_TLS_INSECURE_SKIP_VERIFY"),
+			},
+			MaxIdleConnsPerHost: viper.GetInt("PREWARM_CONNECTIONS"),
+		},
+		Capture:    capture,
+		FixtureDir: filepath.Join(viper.GetString("UPSTREAM_FIXTURE_DIR"), strings.ToLower(prefix)),
+	}
+}
+
+// splitList reads a comma-separated setting, dropping blank entries.
+func splitList(raw string) []string {
+	var list []string
+	for _, entry := range strings.Split(raw, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// ReadSecret returns the value of key, or the contents of the file named by
+// key_FILE when the secret is mounted from a secret store.
+func ReadSecret(key string) (string, error) {
+	if path := viper.GetString(key + "_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	return viper.GetString(key), nil
+}
+
+// readSecret is ReadSecret for Load: an unreadable file is one more problem.
+func readSecret(p *problems, key string) string {
+	value, err := ReadSecret(key)
+	if err != nil {
+		p.addf(key+"_FILE", "is unreadable: %v", err)
+	}
+	return value
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	t.Setenv("OTEL_SERVICE_NAME", "go-service-b")
+	t.Setenv("HTTP_PORT", "http")
+	t.Setenv("UPSTREAM_FIXTURE_MODE", "rewind")
+	t.Setenv("HMAC_SECRET_FILE", missing)
+	t.Setenv("CEP_HASH_KEY_FILE", missing)
+	t.Setenv("AUDIT_HMAC_KEY_FILE", missing)
+
+	_, err := Load()
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Load() error = %v, want a *ValidationError", err)
+	}
+	for _, key := range []string{
+		"HTTP_PORT",
+		"UPSTREAM_FIXTURE_MODE",
+		"HMAC_SECRET_FILE",
+		"CEP_HASH_KEY_FILE",
+		"AUDIT_HMAC_KEY_FILE",
+	} {
+		if !hasProblem(validationErr, key) {
+			t.Errorf("no problem reported for %s in:\n%v", key, err)
+		}
+	}
+}
+
+func hasProblem(err *ValidationError, key string) bool {
+	for _, problem := range err.Problems {
+		if strings.HasPrefix(problem, key+" ") {
+			return true
+		}
+	}
+	return false
+}
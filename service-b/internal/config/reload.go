@@ -0,0 +1,83 @@
+package config
+
+import (
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/configfile"
+	"github.com/luis-olivetti/go-observability/pkg/platform/featureflag"
+	"github.com/luis-olivetti/go-observability/pkg/platform/sampling"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// Tunables are the settings that can change while the service runs, through
+// the file named by CONFIG_FILE. Everything else needs a restart.
+type Tunables struct {
+	// Sampling is nil when SAMPLING_RATIO is not set.
+	Sampling *sampling.Config
+	// Features holds the rule of each feature flag, keyed by flag name.
+	Features map[string]featureflag.Rule
+	// ViaCEPTimeout and WeatherTimeout bound each call to the upstream;
+	// zero means no limit.
+	ViaCEPTimeout  time.Duration
+	WeatherTimeout time.Duration
+	// AlertsCacheTTL is how long the weather alerts of a city are reused.
+	AlertsCacheTTL time.Duration
+}
+
+// Reload describes a config file change that was applied.
+type Reload struct {
+	configfile.Change
+	Tunables Tunables
+}
+
+func loadTunables(p *problems) Tunables {
+	var tunables Tunables
+	var err error
+
+	if tunables.Sampling, err = configfile.Sampling(); err != nil {
+		p.add(err)
+	}
+	if tunables.Features, err = configfile.Features(); err != nil {
+		p.add(err)
+	}
+
+	tunables.ViaCEPTimeout = duration(p, "VIACEP_TIMEOUT")
+	requireNonNegative(p, "VIACEP_TIMEOUT", tunables.ViaCEPTimeout)
+	tunables.WeatherTimeout = duration(p, "WEATHER_TIMEOUT")
+	requireNonNegative(p, "WEATHER_TIMEOUT", tunables.WeatherTimeout)
+	tunables.AlertsCacheTTL = duration(p, "ALERTS_CACHE_TTL")
+	requirePositive(p, "ALERTS_CACHE_TTL", tunables.AlertsCacheTTL)
+
+	return tunables
+}
+
+// duration reads key strictly, as checkDurations does at startup; a reload
+// does not go through Load.
+func duration(p *problems, key string) time.Duration {
+	raw := viper.Get(key)
+	if raw == nil || raw == "" {
+		return 0
+	}
+
+	value, err := cast.ToDurationE(raw)
+	if err != nil {
+		p.addf(key, "is not a duration: %q (use a value such as 500ms, 30s or 5m)", raw)
+	}
+	return value
+}
+
+// WatchFile hands the tunables of every valid change of CONFIG_FILE to
+// apply; see configfile.Watch.
+func WatchFile(apply func(Reload)) {
+	configfile.Watch(func(change configfile.Change) error {
+		var problems problems
+		tunables := loadTunables(&problems)
+		if err := problems.err(); err != nil {
+			return err
+		}
+
+		apply(Reload{Change: change, Tunables: tunables})
+		return nil
+	})
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// durationKeys are parsed leniently by viper, which reads a malformed value
+// as zero and silently turns the feature off; they are checked up front.
+var durationKeys = []string{
+	"ADMIN_WRITE_TIMEOUT",
+	"JWT_CLOCK_SKEW",
+	"JWT_JWKS_REFRESH_INTERVAL",
+	"HMAC_REPLAY_WINDOW",
+	"CHAOS_LATENCY",
+	"CHAOS_LATENCY_SPREAD",
+	"PREWARM_TIMEOUT",
+	"PREWARM_INTERVAL",
+	"SAMPLING_LATENCY_THRESHOLD",
+	"SLO_LATENCY_THRESHOLD",
+	"LOG_SAMPLING_WINDOW",
+}
+
+// ValidationError lists every problem found in the configuration, so a
+// deployment can be fixed in one pass instead of one restart per setting.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// problems keeps the first problem reported for each setting.
+type problems struct {
+	list []string
+	seen map[string]bool
+}
+
+func (p *problems) addf(key, format string, args ...any) {
+	if p.seen[key] {
+		return
+	}
+	if p.seen == nil {
+		p.seen = make(map[string]bool)
+	}
+	p.seen[key] = true
+	p.list = append(p.list, key+" "+fmt.Sprintf(format, args...))
+}
+
+// add records a problem whose message already names its setting.
+func (p *problems) add(err error) {
+	p.list = append(p.list, err.Error())
+}
+
+func (p *problems) err() error {
+	if len(p.list) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: p.list}
+}
+
+func checkDurations(p *problems) {
+	for _, key := range durationKeys {
+		value := viper.Get(key)
+		if value == nil || value == "" {
+			continue
+		}
+		if _, err := cast.ToDurationE(value); err != nil {
+			p.addf(key, "is not a duration: %q (use a value such as 500ms, 30s or 5m)", value)
+		}
+	}
+}
+
+func (c *Config) validate(p *problems) {
+	requirePresent(p, "OTEL_SERVICE_NAME", c.ServiceName)
+	requirePresent(p, "AUDIT_HMAC_KEY", string(c.AuditKey))
+	switch c.TracesExporter {
+	case telemetry.ExporterOTLP:
+		if requirePresent(p, "OTEL_EXPORTER_OTLP_ENDPOINT", c.CollectorURL) {
+			requireHostPort(p, "OTEL_EXPORTER_OTLP_ENDPOINT", c.CollectorURL)
+		}
+		if c.OTLPProtocol != telemetry.ProtocolGRPC && c.OTLPProtocol != telemetry.ProtocolHTTP {
+			p.addf("OTEL_EXPORTER_OTLP_PROTOCOL", "must be %s or %s, got %q", telemetry.ProtocolGRPC, telemetry.ProtocolHTTP, c.OTLPProtocol)
+		}
+	case telemetry.ExporterStdout:
+	default:
+		p.addf("OTEL_TRACES_EXPORTER", "must be %s or %s, got %q", telemetry.ExporterOTLP, telemetry.ExporterStdout, c.TracesExporter)
+	}
+
+	// The weather endpoint moves to the internal listener when there is one.
+	if c.InternalPort == "" {
+		requirePresent(p, "HTTP_PORT", c.HTTPPort)
+	}
+	if c.HTTPPort != "" {
+		requirePort(p, "HTTP_PORT", c.HTTPPort)
+	}
+	requirePort(p, "ADMIN_PORT", c.AdminPort)
+	if c.HTTPPort != "" && c.HTTPPort == c.AdminPort {
+		p.addf("ADMIN_PORT", "must differ from HTTP_PORT (both are %s)", c.HTTPPort)
+	}
+	if c.InternalPort != "" {
+		requirePort(p, "INTERNAL_PORT", c.InternalPort)
+		if c.InternalPort == c.HTTPPort || c.InternalPort == c.AdminPort {
+			p.addf("INTERNAL_PORT", "must differ from HTTP_PORT and ADMIN_PORT (got %s)", c.InternalPort)
+		}
+	}
+	requireCertPair(p, "HTTP", c.PublicTLS)
+	requireCertPair(p, "INTERNAL", c.InternalTLS)
+	requireCertPair(p, "ADMIN", c.AdminTLS)
+	requirePositive(p, "ADMIN_WRITE_TIMEOUT", c.AdminWriteTimeout)
+	requirePositive(p, "SLO_LATENCY_THRESHOLD", c.SLO.LatencyThreshold)
+	if c.OutboundCallBudget < 0 {
+		p.addf("OUTBOUND_CALL_BUDGET", "must not be negative, got %d", c.OutboundCallBudget)
+	}
+	if c.LogSampling.Burst < 0 {
+		p.addf("LOG_SAMPLING_BURST", "must not be negative, got %d", c.LogSampling.Burst)
+	}
+	if c.LogSampling.Burst > 0 {
+		requirePositive(p, "LOG_SAMPLING_WINDOW", c.LogSampling.Window)
+	}
+	requireTarget(p, "SLO_AVAILABILITY_TARGET", c.SLO.AvailabilityTarget)
+	requireTarget(p, "SLO_LATENCY_TARGET", c.SLO.LatencyTarget)
+	if len(c.SLO.Windows) == 0 {
+		p.addf("SLO_WINDOWS", "must list at least one window, such as 5m,1h")
+	}
+
+	requireHTTPURL(p, "VIACEP_BASE_URL", c.ViaCEP.BaseURL)
+	requireHTTPURLs(p, "VIACEP_ALTERNATE_BASE_URLS", c.ViaCEP.Alternates)
+	requireHTTPURL(p, "WEATHER_BASE_URL", c.Weather.BaseURL)
+	requireHTTPURLs(p, "WEATHER_ALTERNATE_BASE_URLS", c.Weather.Alternates)
+	requirePresent(p, "WEATHER_API_KEY", c.WeatherAPIKey)
+
+	// Both upstreams read the same capture settings.
+	if capture := c.ViaCEP.Capture; capture != nil {
+		if capture.Percent <= 0 || capture.Percent > 100 {
+			p.addf("UPSTREAM_CAPTURE_PERCENT", "must be above 0 and at most 100, got %v", capture.Percent)
+		}
+		if capture.MaxBytes <= 0 {
+			p.addf("UPSTREAM_CAPTURE_MAX_BYTES", "must be positive, got %d", capture.MaxBytes)
+		}
+	}
+
+	if c.JWT != nil {
+		requireHTTPURL(p, "JWT_JWKS_URL", c.JWT.JWKSURL)
+		requireNonNegative(p, "JWT_CLOCK_SKEW", c.JWT.ClockSkew)
+		requireNonNegative(p, "JWT_JWKS_REFRESH_INTERVAL", c.JWT.RefreshTTL)
+	}
+
+	if c.HMACSecret != "" {
+		requirePositive(p, "HMAC_REPLAY_WINDOW", c.HMACReplayWindow)
+	}
+
+	if c.Prewarm != nil {
+		requirePositive(p, "PREWARM_TIMEOUT", c.Prewarm.Timeout)
+		requireNonNegative(p, "PREWARM_INTERVAL", c.Prewarm.Interval)
+	}
+
+	if c.Strict {
+		c.validateStrict(p)
+	}
+}
+
+func requirePresent(p *problems, key, value string) bool {
+	if value == "" {
+		p.addf(key, "is required")
+		return false
+	}
+	return true
+}
+
+func requireCertPair(p *problems, prefix string, tls ServerTLS) {
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		p.addf(prefix+"_TLS_CERT_FILE", "and %s_TLS_KEY_FILE must be set together", prefix)
+	}
+}
+
+func requirePort(p *problems, key, value string) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		p.addf(key, "is not a port number between 1 and 65535: %q", value)
+	}
+}
+
+func requireHostPort(p *problems, key, value string) {
+	host, port, err := net.SplitHostPort(value)
+	if err != nil || host == "" {
+		p.addf(key, "must be host:port without a scheme, such as otel-collector:4317: %q", value)
+		return
+	}
+	requirePort(p, key, port)
+}
+
+func requireHTTPURL(p *problems, key, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		p.addf(key, "must be an absolute http or https URL: %q", value)
+	}
+}
+
+func requireHTTPURLs(p *problems, key string, values []string) {
+	for _, value := range values {
+		requireHTTPURL(p, key, value)
+	}
+}
+
+// requireTarget checks an objective, which is only meaningful strictly
+// between 0 and 1: a target of 1 leaves no error budget at all.
+func requireTarget(p *problems, key string, value float64) {
+	if value <= 0 || value >= 1 {
+		p.addf(key, "must be between 0 and 1 exclusive, such as 0.999, got %g", value)
+	}
+}
+
+func requirePositive(p *problems, key string, value time.Duration) {
+	if value <= 0 {
+		p.addf(key, "must be greater than zero, got %s", value)
+	}
+}
+
+func requireNonNegative(p *problems, key string, value time.Duration) {
+	if value < 0 {
+		p.addf(key, "must not be negative, got %s", value)
+	}
+}
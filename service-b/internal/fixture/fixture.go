@@ -0,0 +1,161 @@
+package fixture
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Mode selects whether the transport talks to the network, records what it
+// sees, or answers only from previously recorded fixtures.
+type Mode string
+
+const (
+	ModeOff    Mode = ""
+	ModeRecord Mode = "record"
+	ModeReplay Mode = "replay"
+)
+
+// ParseMode validates a mode read from configuration.
+func ParseMode(value string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case ModeOff, ModeRecord, ModeReplay:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid fixture mode: %s", value)
+	}
+}
+
+// Fixture is a single recorded exchange as stored on disk.
+type Fixture struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Transport records upstream responses into Dir, or replays them from it.
+// Query parameters listed in SecretParams are masked before the URL is
+// stored or used as a key, so API keys never end up in fixtures. When set,
+// Scrub rewrites the URL and body stored, such as to mask CEPs; fixtures
+// are still keyed by the URL before it.
+type Transport struct {
+	Mode         Mode
+	Dir          string
+	SecretParams []string
+	Scrub        func(string) string
+	Base         http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch t.Mode {
+	case ModeReplay:
+		return t.replay(req)
+	case ModeRecord:
+		return t.record(req)
+	default:
+		return t.base().RoundTrip(req)
+	}
+}
+
+func (t *Transport) record(req *http.Request) (*http.Response, error) {
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	maskedURL := t.maskURL(req.URL)
+	fixture := Fixture{
+		Method:     req.Method,
+		URL:        t.scrub(maskedURL),
+		StatusCode: resp.StatusCode,
+		Header:     http.Header{"Content-Type": resp.Header.Values("Content-Type")},
+		Body:       t.scrub(string(body)),
+	}
+
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	if err := os.MkdirAll(t.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create fixture dir: %w", err)
+	}
+	if err := os.WriteFile(t.path(fixture.Method, maskedURL), data, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write fixture: %w", err)
+	}
+
+	return resp, nil
+}
+
+func (t *Transport) replay(req *http.Request) (*http.Response, error) {
+	maskedURL := t.maskURL(req.URL)
+
+	data, err := os.ReadFile(t.path(req.Method, maskedURL))
+	if err != nil {
+		return nil, fmt.Errorf("no fixture recorded for %s %s: %w", req.Method, maskedURL, err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to decode fixture: %w", err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.StatusCode, http.StatusText(fixture.StatusCode)),
+		StatusCode:    fixture.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        fixture.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(fixture.Body)),
+		ContentLength: int64(len(fixture.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (t *Transport) maskURL(u *url.URL) string {
+	masked := *u
+	query := masked.Query()
+	for _, param := range t.SecretParams {
+		if query.Has(param) {
+			query.Set(param, "REDACTED")
+		}
+	}
+	masked.RawQuery = query.Encode()
+	return masked.String()
+}
+
+func (t *Transport) scrub(value string) string {
+	if t.Scrub == nil {
+		return value
+	}
+	return t.Scrub(value)
+}
+
+func (t *Transport) path(method, maskedURL string) string {
+	sum := sha256.Sum256([]byte(method + " " + maskedURL))
+	return filepath.Join(t.Dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/apierror"
+	"github.com/luis-olivetti/go-observability/pkg/platform/callbudget"
+	"github.com/luis-olivetti/go-observability/pkg/platform/cep"
+	"github.com/luis-olivetti/go-observability/pkg/platform/debugtrace"
+	"github.com/luis-olivetti/go-observability/pkg/platform/featureflag"
+	"github.com/luis-olivetti/go-observability/pkg/platform/servertiming"
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"github.com/luis-olivetti/go-observability/pkg/platform/tenant"
+	"github.com/luis-olivetti/go-observability/service-b/internal/clients"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AlertsProvider returns the severe-weather alerts in effect for a city.
+type AlertsProvider interface {
+	Alerts(ctx context.Context, cityName string) ([]clients.WeatherAlert, error)
+}
+
+// AlertsRoute is the path AlertsHandler is served on.
+const AlertsRoute = "/alerts"
+
+// AlertsHandler serves GET /alerts.
+type AlertsHandler struct {
+	ceps     CepResolver
+	alerts   AlertsProvider
+	features *featureflag.Client
+	tracer   trace.Tracer
+	duration *telemetry.RequestDuration
+}
+
+func NewAlertsHandler(ceps CepResolver, alerts AlertsProvider, features *featureflag.Client, tracer trace.Tracer) *AlertsHandler {
+	return &AlertsHandler{
+		ceps:     ceps,
+		alerts:   alerts,
+		features: features,
+		tracer:   tracer,
+		duration: telemetry.NewRequestDuration(),
+	}
+}
+
+func (h *AlertsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	carrier := propagation.HeaderCarrier(r.Header)
+	ctx := r.Context()
+	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+
+	name, opts := telemetry.ServerSpan(r, AlertsRoute)
+	ctx, span := h.tracer.Start(ctx, name, opts...)
+	defer span.End()
+	debugtrace.Annotate(ctx, span, r)
+
+	w, measured := h.duration.Measure(ctx, w, r, AlertsRoute)
+	defer measured()
+
+	timings := servertiming.New()
+	if h.features.Boolean(ctx, ServerTimingFlag, true, tenant.ID(ctx)) {
+		w = timings.Wrap(w)
+	}
+	defer func() { span.SetAttributes(timings.Attributes()...) }()
+	defer func() { span.SetAttributes(callbudget.Attributes(ctx)...) }()
+
+	validated := timings.Start("validation")
+	err := validParams(r)
+	validated()
+	if err != nil {
+		apierror.Write(w, r, span, err)
+		return
+	}
+
+	zipCode, _ := cep.Normalize(r.URL.Query().Get("zipcode"))
+
+	resolved := timings.Start("cep")
+	viacepReturn, err := h.ceps.Resolve(ctx, zipCode)
+	resolved()
+	if err != nil {
+		apierror.Write(w, r, span, err)
+		return
+	}
+
+	cityName := viacepReturn.Localidade
+
+	fetched := timings.Start("alerts")
+	alerts, err := h.alerts.Alerts(ctx, cityName)
+	fetched()
+	if err != nil {
+		apierror.Write(w, r, span, err)
+		return
+	}
+	span.SetAttributes(attribute.Int("alerts.count", len(alerts)))
+
+	cityAlerts := contracts.CityAlerts{CityName: cityName, Alerts: make([]contracts.Alert, 0, len(alerts))}
+	for _, alert := range alerts {
+		cityAlerts.Alerts = append(cityAlerts.Alerts, contracts.Alert{
+			Headline:    alert.Headline,
+			Event:       alert.Event,
+			Severity:    alert.Severity,
+			Urgency:     alert.Urgency,
+			Areas:       alert.Areas,
+			Effective:   alert.Effective,
+			Expires:     alert.Expires,
+			Description: alert.Desc,
+			Instruction: alert.Instruction,
+		})
+	}
+
+	writeJSON(w, r, span, timings, cityAlerts)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/apierror"
+	"github.com/luis-olivetti/go-observability/service-b/internal/clients"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// alertsFunc adapts a function to AlertsProvider.
+type alertsFunc func(ctx context.Context, cityName string) ([]clients.WeatherAlert, error)
+
+func (f alertsFunc) Alerts(ctx context.Context, cityName string) ([]clients.WeatherAlert, error) {
+	return f(ctx, cityName)
+}
+
+func TestAlertsHandler(t *testing.T) {
+	flood := clients.WeatherAlert{Headline: "Alerta de chuvas", Event: "Chuvas intensas", Severity: "Moderate", Desc: "Acumulado de 50 mm", Instruction: "Evite áreas alagadas"}
+
+	tests := []struct {
+		name       string
+		query      string
+		cepErr     error
+		alerts     []clients.WeatherAlert
+		alertsErr  error
+		wantStatus int
+		wantCode   contracts.ErrorCode
+		want       *contracts.CityAlerts
+	}{
+		{
+			name:       "alerts",
+			query:      "?zipcode=29902555",
+			alerts:     []clients.WeatherAlert{flood},
+			wantStatus: http.StatusOK,
+			want: &contracts.CityAlerts{CityName: "Linhares", Alerts: []contracts.Alert{{
+				Headline: "Alerta de chuvas", Event: "Chuvas intensas", Severity: "Moderate",
+				Description: "Acumulado de 50 mm", Instruction: "Evite áreas alagadas",
+			}}},
+		},
+		{
+			name:       "no alerts",
+			query:      "?zipcode=29902555",
+			wantStatus: http.StatusOK,
+			want:       &contracts.CityAlerts{CityName: "Linhares", Alerts: []contracts.Alert{}},
+		},
+		{name: "missing zipcode", wantStatus: http.StatusBadRequest, wantCode: contracts.CodeBadRequest},
+		{name: "invalid zipcode", query: "?zipcode=abc", wantStatus: http.StatusUnprocessableEntity, wantCode: contracts.CodeZipcodeInvalid},
+		{
+			name:       "zipcode not found",
+			query:      "?zipcode=29902555",
+			cepErr:     apierror.ZipcodeNotFound(nil),
+			wantStatus: http.StatusNotFound,
+			wantCode:   contracts.CodeZipcodeNotFound,
+		},
+		{
+			name:       "provider quota",
+			query:      "?zipcode=29902555",
+			alertsErr:  apierror.ProviderQuota(errors.New("quota")),
+			wantStatus: http.StatusBadGateway,
+			wantCode:   contracts.CodeProviderQuota,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &upstreams{cepErr: tt.cepErr}
+			var queried string
+			alerts := alertsFunc(func(_ context.Context, cityName string) ([]clients.WeatherAlert, error) {
+				queried = cityName
+				return tt.alerts, tt.alertsErr
+			})
+			handler := NewAlertsHandler(u.ceps(), alerts, nil, noop.NewTracerProvider().Tracer(""))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, AlertsRoute+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			if tt.want == nil {
+				if p := decodeProblem(t, rec); p.Code != tt.wantCode {
+					t.Errorf("problem code = %s, want %s", p.Code, tt.wantCode)
+				}
+				return
+			}
+
+			if queried != "Linhares" {
+				t.Errorf("alerts queried for %q, want Linhares", queried)
+			}
+			var got contracts.CityAlerts
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(got, *tt.want) {
+				t.Errorf("response = %+v, want %+v", got, *tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/apierror"
+	"github.com/luis-olivetti/go-observability/pkg/platform/bufpool"
+	"github.com/luis-olivetti/go-observability/pkg/platform/callbudget"
+	"github.com/luis-olivetti/go-observability/pkg/platform/cep"
+	"github.com/luis-olivetti/go-observability/pkg/platform/debugtrace"
+	"github.com/luis-olivetti/go-observability/pkg/platform/errclass"
+	"github.com/luis-olivetti/go-observability/pkg/platform/featureflag"
+	"github.com/luis-olivetti/go-observability/pkg/platform/servertiming"
+	"github.com/luis-olivetti/go-observability/pkg/platform/telemetry"
+	"github.com/luis-olivetti/go-observability/pkg/platform/tenant"
+	"github.com/luis-olivetti/go-observability/service-b/internal/clients"
+	"github.com/luis-olivetti/go-observability/service-b/internal/units"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CepResolver finds the address of a zipcode.
+type CepResolver interface {
+	Resolve(ctx context.Context, zipCode string) (*clients.ViaCep, error)
+}
+
+// WeatherProvider returns the current weather of a city.
+type WeatherProvider interface {
+	Current(ctx context.Context, cityName string) (*clients.Weather, error)
+}
+
+// CityWeatherRoute is the path CityWeatherHandler is served on.
+const CityWeatherRoute = "/city-weather"
+
+// ServerTimingFlag gates the Server-Timing response header; it is on unless
+// a rule turns it off.
+const ServerTimingFlag = "server_timing"
+
+// CityWeatherHandler serves GET /city-weather.
+type CityWeatherHandler struct {
+	ceps     CepResolver
+	weather  WeatherProvider
+	units    units.Converter
+	features *featureflag.Client
+	tracer   trace.Tracer
+	duration *telemetry.RequestDuration
+}
+
+func NewCityWeatherHandler(ceps CepResolver, weather WeatherProvider, converter units.Converter, features *featureflag.Client, tracer trace.Tracer) *CityWeatherHandler {
+	return &CityWeatherHandler{
+		ceps:     ceps,
+		weather:  weather,
+		units:    converter,
+		features: features,
+		tracer:   tracer,
+		duration: telemetry.NewRequestDuration(),
+	}
+}
+
+func (h *CityWeatherHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	carrier := propagation.HeaderCarrier(r.Header)
+	ctx := r.Context()
+	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+
+	name, opts := telemetry.ServerSpan(r, CityWeatherRoute)
+	ctx, span := h.tracer.Start(ctx, name, opts...)
+	defer span.End()
+	debugtrace.Annotate(ctx, span, r)
+
+	w, measured := h.duration.Measure(ctx, w, r, CityWeatherRoute)
+	defer measured()
+
+	timings := servertiming.New()
+	if h.features.Boolean(ctx, ServerTimingFlag, true, tenant.ID(ctx)) {
+		w = timings.Wrap(w)
+	}
+	defer func() { span.SetAttributes(timings.Attributes()...) }()
+	defer func() { span.SetAttributes(callbudget.Attributes(ctx)...) }()
+
+	validated := timings.Start("validation")
+	err := validParams(r)
+	var converter units.Converter
+	if err == nil {
+		converter, err = h.converter(r)
+	}
+	validated()
+	if err != nil {
+		apierror.Write(w, r, span, err)
+		return
+	}
+
+	zipCode, _ := cep.Normalize(r.URL.Query().Get("zipcode"))
+
+	resolved := timings.Start("cep")
+	viacepReturn, err := h.ceps.Resolve(ctx, zipCode)
+	resolved()
+	if err != nil {
+		apierror.Write(w, r, span, err)
+		return
+	}
+
+	cityName := viacepReturn.Localidade
+
+	fetched := timings.Start("weather")
+	weatherReturn, err := h.weather.Current(ctx, cityName)
+	fetched()
+	if err != nil {
+		apierror.Write(w, r, span, err)
+		return
+	}
+
+	temperatureWithCity := contracts.TemperatureWithCity{
+		Celsius:    converter.Celsius(weatherReturn.Current.TempC),
+		Fahrenheit: converter.Fahrenheit(weatherReturn.Current.TempC),
+		Kelvin:     converter.Kelvin(weatherReturn.Current.TempC),
+		CityName:   cityName,
+	}
+
+	if includes(r, "conditions") {
+		current := weatherReturn.Current
+		temperatureWithCity.Conditions = &contracts.Conditions{
+			Text:      current.Condition.Text,
+			Code:      current.Condition.Code,
+			Icon:      current.Condition.Icon,
+			Humidity:  current.Humidity,
+			WindKph:   current.WindKph,
+			WindDir:   current.WindDir,
+			FeelsLike: converter.Celsius(current.FeelsLikeC),
+		}
+	}
+
+	writeJSON(w, r, span, timings, temperatureWithCity)
+}
+
+// writeJSON times the encoding separately from the write, so it shows up as
+// its own Server-Timing stage.
+func writeJSON(w http.ResponseWriter, r *http.Request, span trace.Span, timings *servertiming.Timings, v any) {
+	body := bufpool.Get()
+	defer bufpool.Put(body)
+
+	encoded := timings.Start("encode")
+	err := body.Encode(v)
+	encoded()
+	if err != nil {
+		apierror.Write(w, r, span, apierror.Internal(fmt.Errorf("failed to encode response: %w", err)))
+		return
+	}
+
+	if err := bufpool.Write(w, http.StatusOK, "application/json", body); err != nil {
+		span.RecordError(err)
+	}
+}
+
+func validParams(r *http.Request) error {
+	zipCode := r.URL.Query().Get("zipcode")
+	if zipCode == "" {
+		return apierror.BadRequest("missing 'zipcode' parameter")
+	}
+	if !cep.Valid(zipCode) {
+		return apierror.InvalidZipcode(errors.New("invalid zipcode"))
+	}
+
+	return nil
+}
+
+// converter returns the handler's converter, rounding to the precision the
+// request asks for with ?precision= when it does.
+func (h *CityWeatherHandler) converter(r *http.Request) (units.Converter, error) {
+	value := r.URL.Query().Get("precision")
+	if value == "" {
+		return h.units, nil
+	}
+
+	precision, err := strconv.Atoi(value)
+	if err != nil {
+		return units.Converter{}, invalidPrecision(err)
+	}
+	converter, err := h.units.WithPrecision(precision)
+	if err != nil {
+		return units.Converter{}, invalidPrecision(err)
+	}
+	return converter, nil
+}
+
+func invalidPrecision(cause error) *apierror.Error {
+	return apierror.FromClass(errclass.Validation, contracts.CodeValidationFailed, "invalid precision", cause)
+}
+
+func includes(r *http.Request, section string) bool {
+	for _, include := range r.URL.Query()["include"] {
+		for _, value := range strings.Split(include, ",") {
+			if strings.TrimSpace(value) == section {
+				return true
+			}
+		}
+	}
+
+	return false
+}
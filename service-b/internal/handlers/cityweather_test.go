@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/luis-olivetti/go-observability/pkg/contracts"
+	"github.com/luis-olivetti/go-observability/pkg/platform/apierror"
+	"github.com/luis-olivetti/go-observability/pkg/platform/errclass"
+	"github.com/luis-olivetti/go-observability/service-b/internal/clients"
+	"github.com/luis-olivetti/go-observability/service-b/internal/units"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// cepFunc adapts a function to CepResolver.
+type cepFunc func(ctx context.Context, zipCode string) (*clients.ViaCep, error)
+
+func (f cepFunc) Resolve(ctx context.Context, zipCode string) (*clients.ViaCep, error) {
+	return f(ctx, zipCode)
+}
+
+// weatherFunc adapts a function to WeatherProvider.
+type weatherFunc func(ctx context.Context, cityName string) (*clients.Weather, error)
+
+func (f weatherFunc) Current(ctx context.Context, cityName string) (*clients.Weather, error) {
+	return f(ctx, cityName)
+}
+
+// upstreams records the calls the handler makes and answers them with the
+// configured results.
+type upstreams struct {
+	cepErr     error
+	weatherErr error
+
+	resolved string
+	queried  string
+}
+
+func (u *upstreams) ceps() CepResolver {
+	return cepFunc(func(_ context.Context, zipCode string) (*clients.ViaCep, error) {
+		u.resolved = zipCode
+		if u.cepErr != nil {
+			return nil, u.cepErr
+		}
+		return &clients.ViaCep{Cep: zipCode, Localidade: "Linhares", Uf: "ES"}, nil
+	})
+}
+
+func (u *upstreams) weather() WeatherProvider {
+	return weatherFunc(func(_ context.Context, cityName string) (*clients.Weather, error) {
+		u.queried = cityName
+		if u.weatherErr != nil {
+			return nil, u.weatherErr
+		}
+		var weather clients.Weather
+		weather.Current.TempC = 28.5
+		weather.Current.Condition.Text = "Ensolarado"
+		weather.Current.Condition.Code = 1000
+		weather.Current.Humidity = 74
+		weather.Current.WindKph = 11.2
+		weather.Current.WindDir = "SSE"
+		weather.Current.FeelsLikeC = 31.25
+		return &weather, nil
+	})
+}
+
+// decodeProblem fails the test unless rec holds a problem document.
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) contracts.Problem {
+	t.Helper()
+
+	if ct := rec.Header().Get("Content-Type"); ct != contracts.ProblemContentType {
+		t.Fatalf("Content-Type = %q, want %q", ct, contracts.ProblemContentType)
+	}
+	var p contracts.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatalf("failed to decode problem: %v", err)
+	}
+	return p
+}
+
+func TestCityWeatherHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		cepErr     error
+		weatherErr error
+		wantStatus int
+		wantCode   contracts.ErrorCode
+		want       *contracts.TemperatureWithCity
+		// wantResolved is the zipcode ViaCEP must be asked for; empty when
+		// no upstream may be called.
+		wantResolved string
+	}{
+		{
+			name:         "valid",
+			query:        "?zipcode=29902555",
+			wantStatus:   http.StatusOK,
+			want:         &contracts.TemperatureWithCity{Celsius: 28.5, Fahrenheit: 83.3, Kelvin: 301.65, CityName: "Linhares"},
+			wantResolved: "29902555",
+		},
+		{
+			name:         "hyphenated",
+			query:        "?zipcode=29902-555",
+			wantStatus:   http.StatusOK,
+			want:         &contracts.TemperatureWithCity{Celsius: 28.5, Fahrenheit: 83.3, Kelvin: 301.65, CityName: "Linhares"},
+			wantResolved: "29902555",
+		},
+		{
+			name:         "precision",
+			query:        "?zipcode=29902555&precision=0",
+			wantStatus:   http.StatusOK,
+			want:         &contracts.TemperatureWithCity{Celsius: 29, Fahrenheit: 83, Kelvin: 302, CityName: "Linhares"},
+			wantResolved: "29902555",
+		},
+		{
+			name:       "conditions",
+			query:      "?zipcode=29902555&include=alerts,%20conditions",
+			wantStatus: http.StatusOK,
+			want: &contracts.TemperatureWithCity{
+				Celsius: 28.5, Fahrenheit: 83.3, Kelvin: 301.65, CityName: "Linhares",
+				Conditions: &contracts.Conditions{Text: "Ensolarado", Code: 1000, Humidity: 74, WindKph: 11.2, WindDir: "SSE", FeelsLike: 31.25},
+			},
+			wantResolved: "29902555",
+		},
+		{name: "missing zipcode", query: "", wantStatus: http.StatusBadRequest, wantCode: contracts.CodeBadRequest},
+		{name: "invalid zipcode", query: "?zipcode=2990255", wantStatus: http.StatusUnprocessableEntity, wantCode: contracts.CodeZipcodeInvalid},
+		{name: "precision not a number", query: "?zipcode=29902555&precision=two", wantStatus: http.StatusUnprocessableEntity, wantCode: contracts.CodeValidationFailed},
+		{name: "precision out of range", query: "?zipcode=29902555&precision=7", wantStatus: http.StatusUnprocessableEntity, wantCode: contracts.CodeValidationFailed},
+		{
+			name:         "zipcode not found",
+			query:        "?zipcode=29902555",
+			cepErr:       apierror.ZipcodeNotFound(nil),
+			wantStatus:   http.StatusNotFound,
+			wantCode:     contracts.CodeZipcodeNotFound,
+			wantResolved: "29902555",
+		},
+		{
+			name:         "weather timeout",
+			query:        "?zipcode=29902555",
+			weatherErr:   apierror.Upstream(errclass.UpstreamTimeout, context.DeadlineExceeded),
+			wantStatus:   http.StatusGatewayTimeout,
+			wantCode:     contracts.CodeUpstreamTimeout,
+			wantResolved: "29902555",
+		},
+	}
+
+	converter, err := units.NewConverter(units.DefaultPrecision, units.DefaultRounding)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &upstreams{cepErr: tt.cepErr, weatherErr: tt.weatherErr}
+			handler := NewCityWeatherHandler(u.ceps(), u.weather(), converter, nil, noop.NewTracerProvider().Tracer(""))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, CityWeatherRoute+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if u.resolved != tt.wantResolved {
+				t.Errorf("ViaCEP asked for %q, want %q", u.resolved, tt.wantResolved)
+			}
+			if tt.cepErr != nil && u.queried != "" {
+				t.Errorf("weather queried for %q after the zipcode failed", u.queried)
+			}
+
+			if tt.want == nil {
+				if p := decodeProblem(t, rec); p.Code != tt.wantCode || p.Status != tt.wantStatus {
+					t.Errorf("problem = %+v, want code %s status %d", p, tt.wantCode, tt.wantStatus)
+				}
+				return
+			}
+
+			if u.queried != "Linhares" {
+				t.Errorf("weather queried for %q, want Linhares", u.queried)
+			}
+			var got contracts.TemperatureWithCity
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(got, *tt.want) {
+				t.Errorf("response = %+v, want %+v", got, *tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkCityWeatherHandler(b *testing.B) {
+	converter, err := units.NewConverter(units.DefaultPrecision, units.DefaultRounding)
+	if err != nil {
+		b.Fatal(err)
+	}
+	u := &upstreams{}
+	handler := NewCityWeatherHandler(u.ceps(), u.weather(), converter, nil, noop.NewTracerProvider().Tracer(""))
+
+	for _, bench := range []struct {
+		name  string
+		query string
+	}{
+		{name: "temperature", query: "?zipcode=29902555"},
+		{name: "conditions", query: "?zipcode=29902555&include=conditions"},
+		{name: "invalid zipcode", query: "?zipcode=2990255"},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			req := httptest.NewRequest(http.MethodGet, CityWeatherRoute+bench.query, nil)
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				handler.ServeHTTP(httptest.NewRecorder(), req)
+			}
+		})
+	}
+}
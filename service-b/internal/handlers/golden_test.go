@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/apierror"
+	"github.com/luis-olivetti/go-observability/pkg/platform/errclass"
+	"github.com/luis-olivetti/go-observability/service-b/internal/clients"
+	"github.com/luis-olivetti/go-observability/service-b/internal/units"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// assertGolden compares the body of rec with testdata/<name>.golden.json,
+// rewriting the file instead when -update is set.
+func assertGolden(t *testing.T, name string, rec *httptest.ResponseRecorder) {
+	t.Helper()
+
+	var got bytes.Buffer
+	if err := json.Indent(&got, bytes.TrimSpace(rec.Body.Bytes()), "", "  "); err != nil {
+		t.Fatalf("response is not JSON: %v; body %s", err, rec.Body)
+	}
+	got.WriteByte('\n')
+
+	path := filepath.Join("testdata", name+".golden.json")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("response differs from %s (run with -update if the change is intended)\ngot:\n%s\nwant:\n%s", path, got.Bytes(), want)
+	}
+}
+
+func TestCityWeatherGolden(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		cepErr     error
+		weatherErr error
+	}{
+		{name: "cityweather_ok", query: "?zipcode=29902555"},
+		{name: "cityweather_ok_conditions", query: "?zipcode=29902555&include=conditions"},
+		{name: "cityweather_bad_request", query: ""},
+		{name: "cityweather_invalid", query: "?zipcode=2990255"},
+		{name: "cityweather_validation_failed", query: "?zipcode=29902555&precision=7"},
+		{name: "cityweather_not_found", query: "?zipcode=29902555", cepErr: apierror.ZipcodeNotFound(nil)},
+		{name: "cityweather_upstream_error", query: "?zipcode=29902555", weatherErr: apierror.UpstreamFailure(errors.New("boom"))},
+		{name: "cityweather_upstream_timeout", query: "?zipcode=29902555", weatherErr: apierror.Upstream(errclass.UpstreamTimeout, context.DeadlineExceeded)},
+		{name: "cityweather_provider_quota", query: "?zipcode=29902555", weatherErr: apierror.ProviderQuota(errors.New("quota"))},
+		{name: "cityweather_internal", query: "?zipcode=29902555", weatherErr: errors.New("boom")},
+	}
+
+	converter, err := units.NewConverter(units.DefaultPrecision, units.DefaultRounding)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &upstreams{cepErr: tt.cepErr, weatherErr: tt.weatherErr}
+			handler := NewCityWeatherHandler(u.ceps(), u.weather(), converter, nil, noop.NewTracerProvider().Tracer(""))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, CityWeatherRoute+tt.query, nil))
+
+			assertGolden(t, tt.name, rec)
+		})
+	}
+}
+
+func TestAlertsGolden(t *testing.T) {
+	flood := clients.WeatherAlert{
+		Headline: "Alerta de chuvas", Event: "Chuvas intensas", Severity: "Moderate", Urgency: "Expected",
+		Areas: "Linhares", Effective: "2024-01-10T12:00:00-03:00", Expires: "2024-01-11T12:00:00-03:00",
+		Desc: "Acumulado de 50 mm", Instruction: "Evite áreas alagadas",
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		cepErr    error
+		alerts    []clients.WeatherAlert
+		alertsErr error
+	}{
+		{name: "alerts_ok", query: "?zipcode=29902555", alerts: []clients.WeatherAlert{flood}},
+		{name: "alerts_ok_none", query: "?zipcode=29902555"},
+		{name: "alerts_bad_request", query: ""},
+		{name: "alerts_invalid", query: "?zipcode=abc"},
+		{name: "alerts_not_found", query: "?zipcode=29902555", cepErr: apierror.ZipcodeNotFound(nil)},
+		{name: "alerts_provider_quota", query: "?zipcode=29902555", alertsErr: apierror.ProviderQuota(errors.New("quota"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &upstreams{cepErr: tt.cepErr}
+			alerts := alertsFunc(func(context.Context, string) ([]clients.WeatherAlert, error) {
+				return tt.alerts, tt.alertsErr
+			})
+			handler := NewAlertsHandler(u.ceps(), alerts, nil, noop.NewTracerProvider().Tracer(""))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, AlertsRoute+tt.query, nil))
+
+			assertGolden(t, tt.name, rec)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Names of the middlewares main registers.
+const (
+	Metrics       = "metrics"
+	IPFilter      = "ip_filter"
+	JWT           = "jwt"
+	HMAC          = "hmac"
+	SLO           = "slo"
+	TenantMetrics = "tenant_metrics"
+	CallBudget    = "call_budget"
+)
+
+// Optional lists the middlewares MIDDLEWARES_DISABLED may turn off. The ones
+// that authenticate or filter callers are always on when configured.
+var Optional = []string{Metrics, SLO, TenantMetrics, CallBudget}
+
+// ParseDisabled reads a comma-separated list of optional middlewares.
+func ParseDisabled(raw string) (map[string]bool, error) {
+	disabled := map[string]bool{}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if !isOptional(name) {
+			return nil, fmt.Errorf("cannot disable %q (optional middlewares: %s)", name, strings.Join(Optional, ", "))
+		}
+		disabled[name] = true
+	}
+
+	return disabled, nil
+}
+
+func isOptional(name string) bool {
+	for _, optional := range Optional {
+		if name == optional {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry holds the middlewares of the service by name, so the chain of
+// each router and route is declared as a list of names in one place.
+type Registry struct {
+	middlewares map[string]mux.MiddlewareFunc
+	disabled    map[string]bool
+}
+
+func NewRegistry(disabled map[string]bool) *Registry {
+	return &Registry{middlewares: map[string]mux.MiddlewareFunc{}, disabled: disabled}
+}
+
+// Register makes mw available as name. Features that are not configured are
+// not registered, and chains that list them skip them.
+func (reg *Registry) Register(name string, mw mux.MiddlewareFunc) {
+	reg.middlewares[name] = mw
+}
+
+// Resolve returns the middlewares of names, outermost first, leaving out the
+// ones not registered or disabled.
+func (reg *Registry) Resolve(names ...string) []mux.MiddlewareFunc {
+	var chain []mux.MiddlewareFunc
+	for _, name := range names {
+		if mw, ok := reg.middlewares[name]; ok && !reg.disabled[name] {
+			chain = append(chain, mw)
+		}
+	}
+	return chain
+}
+
+// Chain wraps h in the middlewares of names, the first one outermost.
+func (reg *Registry) Chain(h http.Handler, names ...string) http.Handler {
+	chain := reg.Resolve(names...)
+	for i := len(chain) - 1; i >= 0; i-- {
+		h = chain[i](h)
+	}
+	return h
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/luis-olivetti/go-observability/pkg/platform/health"
+)
+
+// ShutdownTimeout bounds how long in-flight requests get to finish.
+const ShutdownTimeout = 30 * time.Second
+
+// Listener names.
+const (
+	Public   = "public"
+	Internal = "internal"
+	Admin    = "admin"
+)
+
+// Listener is one HTTP server of the process. Each listener has its own
+// port, TLS certificate and handler, and so its own middleware chain.
+type Listener struct {
+	Name   string
+	Server *http.Server
+	// CertFile and KeyFile switch the listener to TLS when set.
+	CertFile string
+	KeyFile  string
+}
+
+func (l Listener) url() string {
+	if l.CertFile != "" {
+		return "https://localhost" + l.Server.Addr
+	}
+	return "http://localhost" + l.Server.Addr
+}
+
+// Run serves every listener until ctx is done, then marks the instance not
+// ready and shuts them all down gracefully.
+func Run(ctx context.Context, checker *health.Checker, listeners ...Listener) error {
+	for _, l := range listeners {
+		ln, err := net.Listen("tcp", l.Server.Addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen for %s on %s: %w", l.Name, l.Server.Addr, err)
+		}
+
+		go func(l Listener) {
+			log.Printf("%s server started at %s\n", l.Name, l.url())
+
+			var err error
+			if l.CertFile != "" {
+				err = l.Server.ServeTLS(ln, l.CertFile, l.KeyFile)
+			} else {
+				err = l.Server.Serve(ln)
+			}
+			if err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Error starting %s server: %v\n", l.Name, err)
+			}
+		}(l)
+	}
+
+	checker.SetReady(true)
+
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	checker.SetReady(false)
+
+	var errs []error
+	for _, l := range listeners {
+		if err := l.Server.Shutdown(shutdownCtx); err != nil {
+			errs = append(errs, fmt.Errorf("%s server shutdown failed: %w", l.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
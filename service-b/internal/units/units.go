@@ -0,0 +1,142 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// MaxPrecision bounds the number of decimal places a Converter may keep.
+const MaxPrecision = 6
+
+// DefaultPrecision matches the two decimal places consumers have been
+// relying on for Kelvin (273.15 offset).
+const DefaultPrecision = 2
+
+// Rounding is how a Converter rounds the digits past its precision.
+type Rounding string
+
+const (
+	// RoundHalfUp rounds halves away from zero: 302.555 -> 302.56,
+	// -0.125 -> -0.13.
+	RoundHalfUp Rounding = "half_up"
+	// RoundHalfEven rounds halves to the even neighbour, so rounding does
+	// not bias sums upwards: 302.555 -> 302.56, 302.545 -> 302.54.
+	RoundHalfEven Rounding = "half_even"
+	// RoundDown drops the extra digits, rounding towards zero:
+	// 302.559 -> 302.55, -0.129 -> -0.12.
+	RoundDown Rounding = "down"
+)
+
+// DefaultRounding is the rounding consumers have been relying on.
+const DefaultRounding = RoundHalfUp
+
+// Converter converts temperatures from Celsius and rounds the results.
+//
+// Conversions are done on the shortest decimal form of the input (29.4, not
+// 29.399999999999998578...), so they are exact and never produce values like
+// 77.00000000000001. Results are rounded to Precision decimal places as
+// Rounding says; the zero Rounding is DefaultRounding.
+type Converter struct {
+	Precision int
+	Rounding  Rounding
+}
+
+// NewConverter validates precision and rounding and returns a Converter.
+// An empty rounding is DefaultRounding.
+func NewConverter(precision int, rounding Rounding) (Converter, error) {
+	if precision < 0 || precision > MaxPrecision {
+		return Converter{}, fmt.Errorf("temperature precision must be between 0 and %d, got %d", MaxPrecision, precision)
+	}
+	switch rounding {
+	case "":
+		rounding = DefaultRounding
+	case RoundHalfUp, RoundHalfEven, RoundDown:
+	default:
+		return Converter{}, fmt.Errorf("unknown temperature rounding %q, want %s, %s or %s", rounding, RoundHalfUp, RoundHalfEven, RoundDown)
+	}
+	return Converter{Precision: precision, Rounding: rounding}, nil
+}
+
+// WithPrecision returns a copy of c that rounds to precision decimal places
+// instead, such as one asked for by a request.
+func (c Converter) WithPrecision(precision int) (Converter, error) {
+	return NewConverter(precision, c.Rounding)
+}
+
+// Celsius returns celsius rounded to the converter precision.
+func (c Converter) Celsius(celsius float64) float64 {
+	return c.convert(celsius, func(r *big.Rat) *big.Rat { return r })
+}
+
+// Fahrenheit converts celsius to Fahrenheit: C * 9/5 + 32.
+func (c Converter) Fahrenheit(celsius float64) float64 {
+	return c.convert(celsius, func(r *big.Rat) *big.Rat {
+		r.Mul(r, big.NewRat(9, 5))
+		return r.Add(r, big.NewRat(32, 1))
+	})
+}
+
+// Kelvin converts celsius to Kelvin: C + 273.15.
+func (c Converter) Kelvin(celsius float64) float64 {
+	return c.convert(celsius, func(r *big.Rat) *big.Rat {
+		return r.Add(r, big.NewRat(27315, 100))
+	})
+}
+
+func (c Converter) convert(celsius float64, fn func(*big.Rat) *big.Rat) float64 {
+	value, ok := decimal(celsius)
+	if !ok {
+		return celsius
+	}
+	return round(fn(value), c.Precision, c.Rounding)
+}
+
+// Round rounds v to precision decimal places, halves away from zero, using
+// the shortest decimal form of v.
+func Round(v float64, precision int) float64 {
+	value, ok := decimal(v)
+	if !ok || precision < 0 {
+		return v
+	}
+	return round(value, precision, RoundHalfUp)
+}
+
+// decimal returns the exact value of the shortest decimal representation of
+// v. NaN and infinities have none.
+func decimal(v float64) (*big.Rat, bool) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, false
+	}
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(v, 'f', -1, 64))
+	return r, ok
+}
+
+func round(r *big.Rat, precision int, rounding Rounding) float64 {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(precision)), nil)
+
+	// rounded is the integer part of the scaled magnitude and rem what is
+	// left of it, as a fraction of the denominator.
+	scaled := new(big.Rat).Mul(new(big.Rat).Abs(r), new(big.Rat).SetInt(scale))
+	rounded, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+
+	half := new(big.Int).Lsh(rem, 1).Cmp(scaled.Denom())
+	switch rounding {
+	case RoundDown:
+	case RoundHalfEven:
+		if half > 0 || half == 0 && rounded.Bit(0) == 1 {
+			rounded.Add(rounded, big.NewInt(1))
+		}
+	default:
+		if half >= 0 {
+			rounded.Add(rounded, big.NewInt(1))
+		}
+	}
+	if r.Sign() < 0 {
+		rounded.Neg(rounded)
+	}
+
+	result, _ := new(big.Rat).SetFrac(rounded, scale).Float64()
+	return result
+}
@@ -0,0 +1,87 @@
+package units
+
+import (
+	"math"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// celsius draws temperatures between absolute zero and the surface of the
+// sun, with at most MaxPrecision decimal places like upstream readings.
+func celsius() *rapid.Generator[float64] {
+	return rapid.Custom(func(t *rapid.T) float64 {
+		scaled := rapid.Int64Range(-273150000, 6000000000).Draw(t, "scaled")
+		return float64(scaled) / 1e6
+	})
+}
+
+func converter() *rapid.Generator[Converter] {
+	return rapid.Custom(func(t *rapid.T) Converter {
+		c, err := NewConverter(
+			rapid.IntRange(0, MaxPrecision).Draw(t, "precision"),
+			rapid.SampledFrom([]Rounding{RoundHalfUp, RoundHalfEven, RoundDown}).Draw(t, "rounding"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	})
+}
+
+// step is the width of one rounding step at precision.
+func step(precision int) float64 {
+	return math.Pow10(-precision)
+}
+
+func TestFahrenheitRoundTrip(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		c := converter().Draw(t, "converter")
+		v := celsius().Draw(t, "celsius")
+
+		// Undoing the conversion can only be off by the rounding of F,
+		// scaled back by 5/9, plus the rounding of C itself.
+		back := (c.Fahrenheit(v) - 32) * 5 / 9
+		if diff := math.Abs(back - v); diff > step(c.Precision)+1e-9 {
+			t.Fatalf("C->F->C: %v came back as %v (precision %d, %s)", v, back, c.Precision, c.Rounding)
+		}
+	})
+}
+
+func TestKelvinOffset(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		c := converter().Draw(t, "converter")
+		v := celsius().Draw(t, "celsius")
+
+		k := c.Kelvin(v)
+		if k < 0 {
+			t.Fatalf("Kelvin(%v) = %v, below absolute zero", v, k)
+		}
+		if diff := math.Abs(k - (v + 273.15)); diff > step(c.Precision)+1e-9 {
+			t.Fatalf("Kelvin(%v) = %v, want %v within %v", v, k, v+273.15, step(c.Precision))
+		}
+	})
+}
+
+func TestRoundingIdempotent(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		c := converter().Draw(t, "converter")
+		v := celsius().Draw(t, "celsius")
+
+		once := c.Celsius(v)
+		if twice := c.Celsius(once); twice != once {
+			t.Fatalf("Celsius(Celsius(%v)) = %v, want %v", v, twice, once)
+		}
+	})
+}
+
+func TestRoundDownTowardsZero(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		c, _ := NewConverter(rapid.IntRange(0, MaxPrecision).Draw(t, "precision"), RoundDown)
+		v := celsius().Draw(t, "celsius")
+
+		if got := c.Celsius(v); math.Abs(got) > math.Abs(v) {
+			t.Fatalf("Celsius(%v) = %v rounded away from zero", v, got)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConverter(t *testing.T) {
+	tests := []struct {
+		name      string
+		celsius   float64
+		wantC     float64
+		wantF     float64
+		wantK     float64
+		precision int
+		rounding  Rounding
+	}{
+		{name: "freezing", celsius: 0, wantC: 0, wantF: 32, wantK: 273.15, precision: 2},
+		{name: "boiling", celsius: 100, wantC: 100, wantF: 212, wantK: 373.15, precision: 2},
+		{name: "no float noise", celsius: 25, wantC: 25, wantF: 77, wantK: 298.15, precision: 2},
+		{name: "shortest decimal", celsius: 29.4, wantC: 29.4, wantF: 84.92, wantK: 302.55, precision: 2},
+		{name: "negative", celsius: -40, wantC: -40, wantF: -40, wantK: 233.15, precision: 2},
+		{name: "absolute zero", celsius: -273.15, wantC: -273.15, wantF: -459.67, wantK: 0, precision: 2},
+		{name: "precision zero", celsius: 28.5, wantC: 29, wantF: 83, wantK: 302, precision: 0},
+		{name: "precision one", celsius: 28.55, wantC: 28.6, wantF: 83.4, wantK: 301.7, precision: 1},
+		{name: "half up", celsius: 29.405, wantC: 29.41, wantF: 84.93, wantK: 302.56, precision: 2, rounding: RoundHalfUp},
+		{name: "half even", celsius: 29.405, wantC: 29.4, wantF: 84.93, wantK: 302.56, precision: 2, rounding: RoundHalfEven},
+		{name: "half even to even neighbour", celsius: 29.395, wantC: 29.4, wantF: 84.91, wantK: 302.54, precision: 2, rounding: RoundHalfEven},
+		{name: "down", celsius: 29.409, wantC: 29.4, wantF: 84.93, wantK: 302.55, precision: 2, rounding: RoundDown},
+		{name: "down negative", celsius: -0.129, wantC: -0.12, wantF: 31.76, wantK: 273.02, precision: 2, rounding: RoundDown},
+		{name: "half up negative", celsius: -0.125, wantC: -0.13, wantF: 31.78, wantK: 273.03, precision: 2, rounding: RoundHalfUp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewConverter(tt.precision, tt.rounding)
+			if err != nil {
+				t.Fatalf("NewConverter failed: %v", err)
+			}
+			if got := c.Celsius(tt.celsius); got != tt.wantC {
+				t.Errorf("Celsius(%v) = %v, want %v", tt.celsius, got, tt.wantC)
+			}
+			if got := c.Fahrenheit(tt.celsius); got != tt.wantF {
+				t.Errorf("Fahrenheit(%v) = %v, want %v", tt.celsius, got, tt.wantF)
+			}
+			if got := c.Kelvin(tt.celsius); got != tt.wantK {
+				t.Errorf("Kelvin(%v) = %v, want %v", tt.celsius, got, tt.wantK)
+			}
+		})
+	}
+}
+
+func TestConverterNotFinite(t *testing.T) {
+	c, _ := NewConverter(DefaultPrecision, DefaultRounding)
+
+	if got := c.Fahrenheit(math.NaN()); !math.IsNaN(got) {
+		t.Errorf("Fahrenheit(NaN) = %v, want NaN", got)
+	}
+	for _, v := range []float64{math.Inf(1), math.Inf(-1)} {
+		if got := c.Kelvin(v); got != v {
+			t.Errorf("Kelvin(%v) = %v, want %v", v, got, v)
+		}
+	}
+}
+
+func TestNewConverter(t *testing.T) {
+	tests := []struct {
+		name         string
+		precision    int
+		rounding     Rounding
+		wantRounding Rounding
+		wantErr      bool
+	}{
+		{name: "defaults", precision: DefaultPrecision, wantRounding: DefaultRounding},
+		{name: "min precision", precision: 0, rounding: RoundDown, wantRounding: RoundDown},
+		{name: "max precision", precision: MaxPrecision, rounding: RoundHalfEven, wantRounding: RoundHalfEven},
+		{name: "negative precision", precision: -1, wantErr: true},
+		{name: "precision too large", precision: MaxPrecision + 1, wantErr: true},
+		{name: "unknown rounding", precision: 2, rounding: "ceiling", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewConverter(tt.precision, tt.rounding)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewConverter(%d, %q) error = %v, want error %v", tt.precision, tt.rounding, err, tt.wantErr)
+			}
+			if err == nil && (c.Precision != tt.precision || c.Rounding != tt.wantRounding) {
+				t.Errorf("NewConverter(%d, %q) = %+v", tt.precision, tt.rounding, c)
+			}
+		})
+	}
+}
+
+func TestWithPrecision(t *testing.T) {
+	c, _ := NewConverter(2, RoundDown)
+
+	narrowed, err := c.WithPrecision(0)
+	if err != nil {
+		t.Fatalf("WithPrecision failed: %v", err)
+	}
+	if narrowed.Rounding != RoundDown {
+		t.Errorf("WithPrecision changed rounding to %q", narrowed.Rounding)
+	}
+	if got := narrowed.Celsius(28.9); got != 28 {
+		t.Errorf("Celsius(28.9) = %v, want 28", got)
+	}
+
+	if _, err := c.WithPrecision(MaxPrecision + 1); err == nil {
+		t.Error("WithPrecision accepted a precision above MaxPrecision")
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		v         float64
+		precision int
+		want      float64
+	}{
+		{v: 302.555, precision: 2, want: 302.56},
+		{v: 1.005, precision: 2, want: 1.01},
+		{v: -0.125, precision: 2, want: -0.13},
+		{v: 77.00000000000001, precision: 2, want: 77},
+		{v: 2.5, precision: 0, want: 3},
+		{v: 1.5, precision: -1, want: 1.5},
+	}
+
+	for _, tt := range tests {
+		if got := Round(tt.v, tt.precision); got != tt.want {
+			t.Errorf("Round(%v, %d) = %v, want %v", tt.v, tt.precision, got, tt.want)
+		}
+	}
+}
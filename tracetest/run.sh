@@ -0,0 +1,12 @@
+#!/bin/sh
+# Runs every trace-based test against the environment described by a
+# Tracetest variable set. Usage: ./run.sh [vars-file]
+set -e
+
+cd "$(dirname "$0")"
+
+VARS=${1:-vars/local.yaml}
+
+for test in tests/*.yaml; do
+	tracetest run test --file "$test" --vars "$VARS" --required-gates test-specs
+done